@@ -0,0 +1,238 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"path/filepath"
+	"strings"
+)
+
+func header(buf *bytes.Buffer, pkg, schemaPath string) {
+	fmt.Fprintf(buf, "// Code generated by packetgen from %s. DO NOT EDIT.\n\n", filepath.ToSlash(schemaPath))
+	fmt.Fprintf(buf, "package %s\n\n", pkg)
+}
+
+func generatePackets(schema *Schema, pkg, schemaPath string) ([]byte, error) {
+	var buf bytes.Buffer
+	header(&buf, pkg, schemaPath)
+	if schema.usesTypes() {
+		buf.WriteString("import (\n\t\"io\"\n\n\t\"mc-proxy/protocol/types\"\n)\n\n")
+	} else {
+		buf.WriteString("import \"io\"\n\n")
+	}
+
+	for i := range schema.Packets {
+		writePacket(&buf, &schema.Packets[i])
+	}
+
+	buf.WriteString("func init() {\n")
+	for _, p := range schema.Packets {
+		var ids []string
+		for _, release := range p.sortedReleases() {
+			ids = append(ids, fmt.Sprintf("%s: %s", protocolConstant(release), p.IDs[release]))
+		}
+		fmt.Fprintf(&buf, "\tRegisterVersionedPacket(%s, %s, VersionIDs{%s}, func() Packet { return &%s{} })\n",
+			states[p.State], directions[p.Direction], strings.Join(ids, ", "), p.Name)
+	}
+	buf.WriteString("}\n")
+
+	return formatSource(buf.Bytes())
+}
+
+func writePacket(buf *bytes.Buffer, p *PacketSchema) {
+	if p.Doc != "" {
+		fmt.Fprintf(buf, "// %s\n", p.Doc)
+	}
+	fmt.Fprintf(buf, "type %s struct {\n", p.Name)
+	for _, f := range p.Fields {
+		fmt.Fprintf(buf, "\t%s %s", f.Name, goType(f))
+		if f.Comment != "" {
+			fmt.Fprintf(buf, " // %s", f.Comment)
+		}
+		buf.WriteString("\n")
+	}
+	buf.WriteString("}\n\n")
+
+	fmt.Fprintf(buf, "func (p *%s) ID() int32 { return %s }\n\n", p.Name, p.latestID())
+
+	if p.versioned() {
+		fmt.Fprintf(buf, "func (p *%s) Encode(w io.Writer) error {\n\treturn p.EncodeVersion(w, LatestProtocol)\n}\n\n", p.Name)
+		fmt.Fprintf(buf, "func (p *%s) Decode(r io.Reader) error {\n\treturn p.DecodeVersion(r, LatestProtocol)\n}\n\n", p.Name)
+		fmt.Fprintf(buf, "func (p *%s) EncodeVersion(w io.Writer, version int32) error {\n", p.Name)
+	} else {
+		fmt.Fprintf(buf, "func (p *%s) Encode(w io.Writer) error {\n", p.Name)
+	}
+	for _, f := range p.Fields {
+		writeGated(buf, f, encodeField(f))
+	}
+	buf.WriteString("\treturn nil\n}\n\n")
+
+	if p.versioned() {
+		fmt.Fprintf(buf, "func (p *%s) DecodeVersion(r io.Reader, version int32) error {\n", p.Name)
+	} else {
+		fmt.Fprintf(buf, "func (p *%s) Decode(r io.Reader) error {\n", p.Name)
+	}
+	for _, f := range p.Fields {
+		writeGated(buf, f, decodeField(f))
+	}
+	buf.WriteString("\treturn nil\n}\n\n")
+}
+
+func goType(f FieldSchema) string {
+	t := fieldTypes[f.Type].goType
+	switch {
+	case f.Optional:
+		return "*" + t
+	case f.Array:
+		return "[]" + t
+	default:
+		return t
+	}
+}
+
+// writeGated wraps the code of a field in a version check if needed
+func writeGated(buf *bytes.Buffer, f FieldSchema, code string) {
+	var conditions []string
+	if f.Since != "" {
+		conditions = append(conditions, "version >= "+protocolConstant(f.Since))
+	}
+	if f.Until != "" {
+		conditions = append(conditions, "version < "+protocolConstant(f.Until))
+	}
+
+	if len(conditions) == 0 {
+		buf.WriteString(code)
+		return
+	}
+	fmt.Fprintf(buf, "\tif %s {\n%s\t}\n", strings.Join(conditions, " && "), code)
+}
+
+// readCall returns the expression reading a value of the field from r
+func readCall(f FieldSchema) string {
+	if f.Max > 0 {
+		return fmt.Sprintf("types.ReadStringMax(r, %d)", f.Max)
+	}
+	if f.Type == "chat" {
+		return "ReadChat(r, version)"
+	}
+	return fieldTypes[f.Type].read + "(r)"
+}
+
+// writeCall returns the expression writing value to w
+func writeCall(f FieldSchema, value string) string {
+	if f.Max > 0 {
+		return fmt.Sprintf("types.WriteStringMax(%s, w, %d)", value, f.Max)
+	}
+	if f.Type == "chat" {
+		return fmt.Sprintf("WriteChat(%s, version, w)", value)
+	}
+	return fmt.Sprintf("%s(%s, w)", fieldTypes[f.Type].write, value)
+}
+
+func encodeField(f FieldSchema) string {
+	switch {
+	case f.Type == "rest":
+		return fmt.Sprintf("\tif _, err := w.Write(p.%s); err != nil {\n\t\treturn err\n\t}\n", f.Name)
+	case f.Optional:
+		return fmt.Sprintf("\tif err := types.WriteBoolean(p.%[1]s != nil, w); err != nil {\n\t\treturn err\n\t}\n"+
+			"\tif p.%[1]s != nil {\n\t\tif err := %[2]s; err != nil {\n\t\t\treturn err\n\t\t}\n\t}\n", f.Name, writeCall(f, "*p."+f.Name))
+	case f.Array:
+		return fmt.Sprintf("\tif err := types.WriteVarInt(types.VarInt(len(p.%[1]s)), w); err != nil {\n\t\treturn err\n\t}\n"+
+			"\tfor _, v := range p.%[1]s {\n\t\tif err := %[2]s; err != nil {\n\t\t\treturn err\n\t\t}\n\t}\n", f.Name, writeCall(f, "v"))
+	default:
+		return fmt.Sprintf("\tif err := %s; err != nil {\n\t\treturn err\n\t}\n", writeCall(f, "p."+f.Name))
+	}
+}
+
+func decodeField(f FieldSchema) string {
+	switch {
+	case f.Type == "rest":
+		return fmt.Sprintf("\t{\n\t\tv, err := io.ReadAll(r)\n\t\tif err != nil {\n\t\t\treturn err\n\t\t}\n\t\tp.%s = v\n\t}\n", f.Name)
+	case f.Optional:
+		return fmt.Sprintf("\t{\n\t\tpresent, err := types.ReadBoolean(r)\n\t\tif err != nil {\n\t\t\treturn err\n\t\t}\n"+
+			"\t\tp.%[1]s = nil\n\t\tif present {\n\t\t\tv, err := %[2]s\n\t\t\tif err != nil {\n\t\t\t\treturn err\n\t\t\t}\n\t\t\tp.%[1]s = &v\n\t\t}\n\t}\n", f.Name, readCall(f))
+	case f.Array:
+		return fmt.Sprintf("\t{\n\t\tcount, err := readCount(r)\n\t\tif err != nil {\n\t\t\treturn err\n\t\t}\n"+
+			"\t\tp.%[1]s = nil\n\t\tfor i := 0; i < count; i++ {\n\t\t\tv, err := %[2]s\n\t\t\tif err != nil {\n\t\t\t\treturn err\n\t\t\t}\n\t\t\tp.%[1]s = append(p.%[1]s, v)\n\t\t}\n\t}\n", f.Name, readCall(f))
+	default:
+		return fmt.Sprintf("\t{\n\t\tv, err := %s\n\t\tif err != nil {\n\t\t\treturn err\n\t\t}\n\t\tp.%s = v\n\t}\n", readCall(f), f.Name)
+	}
+}
+
+// generateTests emits a test encoding and decoding every packet in each
+// protocol version it exists in
+func generateTests(schema *Schema, pkg, schemaPath string) ([]byte, error) {
+	var buf bytes.Buffer
+	header(&buf, pkg, schemaPath)
+	if schema.usesTypes() {
+		buf.WriteString("import (\n\t\"reflect\"\n\t\"testing\"\n\n\t\"mc-proxy/protocol/types\"\n)\n\n")
+	} else {
+		buf.WriteString("import (\n\t\"reflect\"\n\t\"testing\"\n)\n\n")
+	}
+
+	for _, p := range schema.Packets {
+		fmt.Fprintf(&buf, "func Test%sRoundTrip(t *testing.T) {\n", p.Name)
+		buf.WriteString("\tfor _, version := range Protocols() {\n")
+		fmt.Fprintf(&buf, "\t\twant := &%s{\n", p.Name)
+		for _, f := range p.Fields {
+			fmt.Fprintf(&buf, "\t\t\t%s: %s,\n", f.Name, sampleValue(f))
+		}
+		buf.WriteString("\t\t}\n")
+		for _, f := range p.Fields {
+			if f.Since == "" && f.Until == "" {
+				continue
+			}
+			// Fields missing from a version decode to their zero value
+			var conditions []string
+			if f.Since != "" {
+				conditions = append(conditions, "version < "+protocolConstant(f.Since))
+			}
+			if f.Until != "" {
+				conditions = append(conditions, "version >= "+protocolConstant(f.Until))
+			}
+			fmt.Fprintf(&buf, "\t\tif %s {\n\t\t\twant.%s = %s\n\t\t}\n", strings.Join(conditions, " || "), f.Name, zeroOf(f))
+		}
+		fmt.Fprintf(&buf, "\t\tif _, ok := DefaultRegistry.PacketID(%s, %s, version, want); !ok {\n\t\t\tcontinue\n\t\t}\n", states[p.State], directions[p.Direction])
+		fmt.Fprintf(&buf, "\t\tframe, err := DefaultRegistry.Encode(%s, %s, version, want)\n", states[p.State], directions[p.Direction])
+		buf.WriteString("\t\tif err != nil {\n\t\t\tt.Fatalf(\"protocol %d: encode: %v\", version, err)\n\t\t}\n")
+		fmt.Fprintf(&buf, "\t\tgot, err := DefaultRegistry.Decode(%s, %s, version, frame)\n", states[p.State], directions[p.Direction])
+		buf.WriteString("\t\tif err != nil {\n\t\t\tt.Fatalf(\"protocol %d: decode: %v\", version, err)\n\t\t}\n")
+		buf.WriteString("\t\tif !reflect.DeepEqual(got, want) {\n\t\t\tt.Errorf(\"protocol %d: got %+v, want %+v\", version, got, want)\n\t\t}\n")
+		buf.WriteString("\t}\n}\n\n")
+	}
+
+	return formatSource(buf.Bytes())
+}
+
+func sampleValue(f FieldSchema) string {
+	t := fieldTypes[f.Type]
+	sample := t.sample
+	if f.Type == "string" && f.Max > 0 && f.Max < len(sampleString) {
+		sample = fmt.Sprintf("types.String{Value: %q}", sampleString[:f.Max])
+	}
+
+	switch {
+	case f.Optional:
+		return fmt.Sprintf("func() *%s { v := %s(%s); return &v }()", t.goType, t.goType, sample)
+	case f.Array:
+		return fmt.Sprintf("[]%s{%s, %s}", t.goType, sample, sample)
+	case f.Type == "rest":
+		return sample
+	default:
+		return fmt.Sprintf("%s(%s)", t.goType, sample)
+	}
+}
+
+// zeroOf returns an expression for the zero value of a field
+func zeroOf(f FieldSchema) string {
+	return "*new(" + goType(f) + ")"
+}
+
+func formatSource(src []byte) ([]byte, error) {
+	formatted, err := format.Source(src)
+	if err != nil {
+		return nil, fmt.Errorf("generated invalid Go code: %v\n%s", err, src)
+	}
+	return formatted, nil
+}
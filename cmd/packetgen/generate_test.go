@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGeneratePackets(t *testing.T) {
+	p := validPacket()
+	p.Doc = "TestPacket is used by the tests"
+	p.IDs["1.20.1"] = "0x0F"
+	p.Fields[0].Comment = "some value"
+	schema := Schema{Packets: []PacketSchema{p}}
+
+	src, err := generatePackets(&schema, "packet", "schema/test.json")
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+
+	for _, want := range []string{
+		"// Code generated by packetgen from schema/test.json. DO NOT EDIT.",
+		"// TestPacket is used by the tests\ntype TestPacket struct {",
+		"Value types.VarInt // some value",
+		"Brand *types.String",
+		"Data  []byte",
+		"func (p *TestPacket) ID() int32 { return 0x11 }",
+		"func (p *TestPacket) EncodeVersion(w io.Writer, version int32) error {",
+		"if version >= Protocol1_20_5 {",
+		"RegisterVersionedPacket(StatePlay, Clientbound, VersionIDs{Protocol1_20: 0x0F, Protocol1_20_2: 0x10, Protocol1_21: 0x11}",
+	} {
+		if !bytes.Contains(src, []byte(want)) {
+			t.Errorf("generated source does not contain %q:\n%s", want, src)
+		}
+	}
+}
+
+func TestGeneratePacketsUnversioned(t *testing.T) {
+	schema := Schema{Packets: []PacketSchema{{
+		Name:      "RawPacket",
+		State:     "login",
+		Direction: "serverbound",
+		IDs:       map[string]string{"1.19.4": "0x05"},
+		Fields:    []FieldSchema{{Name: "Data", Type: "rest"}},
+	}}}
+
+	src, err := generatePackets(&schema, "other", "raw.json")
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	if bytes.Contains(src, []byte("mc-proxy/protocol/types")) {
+		t.Errorf("types imported without being used:\n%s", src)
+	}
+	if bytes.Contains(src, []byte("EncodeVersion")) {
+		t.Errorf("unversioned packet has EncodeVersion:\n%s", src)
+	}
+	if !bytes.Contains(src, []byte("package other\n")) {
+		t.Errorf("package name not used:\n%s", src)
+	}
+}
+
+func TestGenerateTests(t *testing.T) {
+	schema := Schema{Packets: []PacketSchema{validPacket()}}
+	src, err := generateTests(&schema, "packet", "schema/test.json")
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+
+	for _, want := range []string{
+		"func TestTestPacketRoundTrip(t *testing.T) {",
+		"Value: types.VarInt(-12345),",
+		"if version < Protocol1_20_5 {\n\t\t\twant.Brand = *new(*types.String)",
+	} {
+		if !strings.Contains(string(src), want) {
+			t.Errorf("generated tests do not contain %q:\n%s", want, src)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	schemaPath := filepath.Join(dir, "packets.json")
+	data, err := json.Marshal(Schema{Packets: []PacketSchema{validPacket()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(schemaPath, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	outPath, testPath := filepath.Join(dir, "out.go"), filepath.Join(dir, "out_test.go")
+	if err := run(schemaPath, outPath, testPath, "packet"); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	for _, path := range []string{outPath, testPath} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("%s not written: %v", path, err)
+		}
+	}
+
+	if err := os.WriteFile(schemaPath, []byte(`{"packets": [{"name": "bad"}]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := run(schemaPath, outPath, "", "packet"); err == nil || !strings.Contains(err.Error(), "invalid schema") {
+		t.Errorf("invalid schema: got error %v", err)
+	}
+}
+
+// TestGeneratedUpToDate checks that the committed packets match their schema
+func TestGeneratedUpToDate(t *testing.T) {
+	const dir = "../../protocol/packet"
+	data, err := os.ReadFile(filepath.Join(dir, "schema/packets.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var schema Schema
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatal(err)
+	}
+	if err := schema.validate(); err != nil {
+		t.Fatalf("invalid schema: %v", err)
+	}
+
+	for path, generate := range map[string]func(*Schema, string, string) ([]byte, error){
+		"packets_gen.go":      generatePackets,
+		"packets_gen_test.go": generateTests,
+	} {
+		want, err := generate(&schema, "packet", "schema/packets.json")
+		if err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		got, err := os.ReadFile(filepath.Join(dir, path))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s is out of date, run go generate ./protocol/packet", path)
+		}
+	}
+}
+
+func TestGenerateMaxLength(t *testing.T) {
+	p := validPacket()
+	p.Fields[1].Max = 8
+	p.Fields = append(p.Fields[:2], FieldSchema{Name: "Names", Type: "string", Array: true, Max: 64}, p.Fields[2])
+	schema := Schema{Packets: []PacketSchema{p}}
+	if err := schema.validate(); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+
+	src, err := generatePackets(&schema, "packet", "schema/test.json")
+	if err != nil {
+		t.Fatalf("generate packets: %v", err)
+	}
+	for _, want := range []string{"types.ReadStringMax(r, 8)", "types.WriteStringMax(*p.Brand, w, 8)", "types.ReadStringMax(r, 64)"} {
+		if !bytes.Contains(src, []byte(want)) {
+			t.Errorf("generated source does not contain %q:\n%s", want, src)
+		}
+	}
+
+	// Samples longer than the maximum would fail the generated round trip
+	src, err = generateTests(&schema, "packet", "schema/test.json")
+	if err != nil {
+		t.Fatalf("generate tests: %v", err)
+	}
+	for _, want := range []string{
+		`v := types.String(types.String{Value: "minecraf"})`,
+		`Names: []types.String{types.String{Value: "minecraft:brand"}, types.String{Value: "minecraft:brand"}},`,
+	} {
+		if !strings.Contains(string(src), want) {
+			t.Errorf("generated tests do not contain %q:\n%s", want, src)
+		}
+	}
+}
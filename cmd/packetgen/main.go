@@ -0,0 +1,62 @@
+// Command packetgen generates packet types for protocol/packet from a
+// declarative JSON schema.
+//
+//	packetgen -schema schema/packets.json -out packets_gen.go [-test packets_gen_test.go]
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+)
+
+func main() {
+	schemaPath := flag.String("schema", "", "Path of the packet schema")
+	outPath := flag.String("out", "", "Path of the generated Go file")
+	testPath := flag.String("test", "", "Path of the generated round-trip test file, if any")
+	pkg := flag.String("package", "packet", "Package name of the generated files")
+	flag.Parse()
+
+	if *schemaPath == "" || *outPath == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := run(*schemaPath, *outPath, *testPath, *pkg); err != nil {
+		fmt.Fprintf(os.Stderr, "packetgen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(schemaPath, outPath, testPath, pkg string) error {
+	data, err := os.ReadFile(schemaPath)
+	if err != nil {
+		return err
+	}
+
+	var schema Schema
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return fmt.Errorf("failed to parse %s: %v", schemaPath, err)
+	}
+	if err := schema.validate(); err != nil {
+		return fmt.Errorf("invalid schema %s: %v", schemaPath, err)
+	}
+
+	src, err := generatePackets(&schema, pkg, schemaPath)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(outPath, src, 0o644); err != nil {
+		return err
+	}
+
+	if testPath == "" {
+		return nil
+	}
+	src, err = generateTests(&schema, pkg, schemaPath)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(testPath, src, 0o644)
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"mc-proxy/protocol/packet"
+)
+
+// Schema describes a set of packets
+type Schema struct {
+	Packets []PacketSchema `json:"packets"`
+}
+
+// PacketSchema describes a single packet
+type PacketSchema struct {
+	Name      string            `json:"name"`      // Go type name
+	Doc       string            `json:"doc"`       // optional doc comment
+	State     string            `json:"state"`     // handshake, status, login, configuration or play
+	Direction string            `json:"direction"` // serverbound or clientbound
+	IDs       map[string]string `json:"ids"`       // first release using an ID to the ID, e.g. "1.20.2": "0x24"
+	Fields    []FieldSchema     `json:"fields"`
+}
+
+// FieldSchema describes a packet field
+type FieldSchema struct {
+	Name     string `json:"name"`     // Go field name
+	Type     string `json:"type"`     // one of fieldTypes
+	Comment  string `json:"comment"`  // optional trailing comment
+	Optional bool   `json:"optional"` // prefixed with a boolean
+	Array    bool   `json:"array"`    // prefixed with its length as a VarInt
+	Since    string `json:"since"`    // first release containing the field
+	Until    string `json:"until"`    // first release no longer containing the field
+	Max      int    `json:"max"`      // maximum length of a string in UTF-16 code units
+}
+
+// fieldType maps a schema type to its Go type and codec functions
+type fieldType struct {
+	goType string
+	read   string
+	write  string
+	sample string // Go expression used in round-trip tests
+}
+
+// sampleString is the sample of string fields, cut to their maximum length
+const sampleString = "minecraft:brand"
+
+var fieldTypes = map[string]fieldType{
+	"bool":       {"types.Boolean", "types.ReadBoolean", "types.WriteBoolean", "true"},
+	"byte":       {"types.Byte", "types.ReadByte", "types.WriteByte", "-12"},
+	"ubyte":      {"types.UnsignedByte", "types.ReadUnsignedByte", "types.WriteUnsignedByte", "200"},
+	"short":      {"types.Short", "types.ReadShort", "types.WriteShort", "-1234"},
+	"ushort":     {"types.UnsignedShort", "types.ReadUnsignedShort", "types.WriteUnsignedShort", "60000"},
+	"int":        {"types.Int", "types.ReadInt", "types.WriteInt", "-123456"},
+	"long":       {"types.Long", "types.ReadLong", "types.WriteLong", "-1234567890123"},
+	"float":      {"types.Float", "types.ReadFloat", "types.WriteFloat", "1.5"},
+	"double":     {"types.Double", "types.ReadDouble", "types.WriteDouble", "-2.25"},
+	"varint":     {"types.VarInt", "types.ReadVarInt", "types.WriteVarInt", "-12345"},
+	"varlong":    {"types.VarLong", "types.ReadVarLong", "types.WriteVarLong", "-1234567890123"},
+	"position":   {"types.Position", "types.ReadPosition", "types.WritePosition", "types.Position{X: -1, Y: 64, Z: 300}"},
+	"string":     {"types.String", "types.ReadString", "types.WriteString", `types.String{Value: "` + sampleString + `"}`},
+	"identifier": {"types.Identifier", "types.ReadIdentifier", "types.WriteIdentifier", `types.Identifier{Namespace: "minecraft", Path: "brand"}`},
+	"uuid":       {"types.UUID", "types.ReadUUID", "types.WriteUUID", "types.UUID{MostSignificantBits: 1, LeastSignificantBits: -2}"},
+	"bytearray":  {"types.ByteArray", "types.ReadByteArray", "types.WriteByteArray", "types.ByteArray{1, 2, 3}"},
+	"chat":       {"types.Chat", "ReadChat", "WriteChat", `types.Chat{Text: "hello"}`}, // needs the version
+	"nbt":        {"types.RawNBT", "types.ReadRawNBT", "types.WriteRawNBT", "types.RawNBT{8, 0, 2, 'h', 'i'}"},
+	"rest":       {"[]byte", "", "", "[]byte{4, 5, 6}"},
+}
+
+var identifierPattern = regexp.MustCompile(`^[A-Z][A-Za-z0-9]*$`)
+
+var states = map[string]string{
+	"handshake":     "StateHandshake",
+	"status":        "StateStatus",
+	"login":         "StateLogin",
+	"configuration": "StateConfiguration",
+	"play":          "StatePlay",
+}
+
+var directions = map[string]string{
+	"serverbound": "Serverbound",
+	"clientbound": "Clientbound",
+}
+
+func (s *Schema) validate() error {
+	names := make(map[string]bool)
+	for _, p := range s.Packets {
+		if !identifierPattern.MatchString(p.Name) {
+			return fmt.Errorf("invalid packet name %q", p.Name)
+		}
+		if names[p.Name] {
+			return fmt.Errorf("duplicate packet %s", p.Name)
+		}
+		names[p.Name] = true
+
+		if _, ok := states[p.State]; !ok {
+			return fmt.Errorf("%s: invalid state %q", p.Name, p.State)
+		}
+		if _, ok := directions[p.Direction]; !ok {
+			return fmt.Errorf("%s: invalid direction %q", p.Name, p.Direction)
+		}
+		if len(p.IDs) == 0 {
+			return fmt.Errorf("%s: no packet IDs", p.Name)
+		}
+		releases := make(map[int32]string)
+		for release, id := range p.IDs {
+			version, ok := packet.VersionByName(release)
+			if !ok {
+				return fmt.Errorf("%s: unsupported release %q", p.Name, release)
+			}
+			if other, ok := releases[version.Protocol]; ok {
+				return fmt.Errorf("%s: releases %s and %s share protocol %d", p.Name, other, release, version.Protocol)
+			}
+			releases[version.Protocol] = release
+			if _, err := strconv.ParseInt(id, 0, 32); err != nil {
+				return fmt.Errorf("%s: invalid packet ID %q", p.Name, id)
+			}
+		}
+
+		fields := make(map[string]bool)
+		for i, f := range p.Fields {
+			if !identifierPattern.MatchString(f.Name) {
+				return fmt.Errorf("%s: invalid field name %q", p.Name, f.Name)
+			}
+			if fields[f.Name] {
+				return fmt.Errorf("%s: duplicate field %s", p.Name, f.Name)
+			}
+			fields[f.Name] = true
+
+			if _, ok := fieldTypes[f.Type]; !ok {
+				return fmt.Errorf("%s.%s: unknown type %q", p.Name, f.Name, f.Type)
+			}
+			if f.Optional && f.Array {
+				return fmt.Errorf("%s.%s: a field cannot be both optional and an array", p.Name, f.Name)
+			}
+			if f.Max < 0 || f.Max > 0 && f.Type != "string" {
+				return fmt.Errorf("%s.%s: max requires a string type and a positive length", p.Name, f.Name)
+			}
+			if f.Type == "rest" && (f.Optional || f.Array || i != len(p.Fields)-1) {
+				return fmt.Errorf("%s.%s: rest must be the plain last field", p.Name, f.Name)
+			}
+			for _, release := range []string{f.Since, f.Until} {
+				if _, ok := packet.VersionByName(release); release != "" && !ok {
+					return fmt.Errorf("%s.%s: unsupported release %q", p.Name, f.Name, release)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// usesTypes reports whether the generated code refers to the types package
+func (s *Schema) usesTypes() bool {
+	for _, p := range s.Packets {
+		for _, f := range p.Fields {
+			if f.Type != "rest" || f.Optional || f.Array {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// versioned reports whether any field depends on the protocol version.
+// Chat is encoded as NBT since 1.20.3.
+func (p *PacketSchema) versioned() bool {
+	for _, f := range p.Fields {
+		if f.Since != "" || f.Until != "" || f.Type == "chat" {
+			return true
+		}
+	}
+	return false
+}
+
+// sortedReleases returns the releases of the ID mapping, oldest first
+func (p *PacketSchema) sortedReleases() []string {
+	releases := make([]string, 0, len(p.IDs))
+	for release := range p.IDs {
+		releases = append(releases, release)
+	}
+	sort.Slice(releases, func(i, j int) bool {
+		return compareReleases(releases[i], releases[j]) < 0
+	})
+	return releases
+}
+
+// latestID returns the ID used by the newest release of the mapping
+func (p *PacketSchema) latestID() string {
+	releases := p.sortedReleases()
+	return p.IDs[releases[len(releases)-1]]
+}
+
+func compareReleases(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y int
+		if i < len(as) {
+			x, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			y, _ = strconv.Atoi(bs[i])
+		}
+		if x != y {
+			return x - y
+		}
+	}
+	return 0
+}
+
+// protocolConstant returns the name of the protocol version constant of a
+// release. Constants are named after the first release of their protocol, so
+// 1.20.1 uses Protocol1_20.
+func protocolConstant(release string) string {
+	version, _ := packet.VersionByName(release)
+	for _, v := range packet.Versions {
+		if v.Protocol == version.Protocol {
+			release = v.Name
+			break
+		}
+	}
+	return "Protocol" + strings.ReplaceAll(release, ".", "_")
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func validPacket() PacketSchema {
+	return PacketSchema{
+		Name:      "TestPacket",
+		State:     "play",
+		Direction: "clientbound",
+		IDs:       map[string]string{"1.20.2": "0x10", "1.21": "0x11"},
+		Fields: []FieldSchema{
+			{Name: "Value", Type: "varint"},
+			{Name: "Brand", Type: "string", Optional: true, Since: "1.20.5"},
+			{Name: "Data", Type: "rest"},
+		},
+	}
+}
+
+func TestSchemaValidate(t *testing.T) {
+	schema := Schema{Packets: []PacketSchema{validPacket()}}
+	if err := schema.validate(); err != nil {
+		t.Fatalf("valid schema rejected: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(p *PacketSchema)
+		want   string
+	}{
+		{"packet name", func(p *PacketSchema) { p.Name = "testPacket" }, "invalid packet name"},
+		{"state", func(p *PacketSchema) { p.State = "game" }, "invalid state"},
+		{"direction", func(p *PacketSchema) { p.Direction = "both" }, "invalid direction"},
+		{"no IDs", func(p *PacketSchema) { p.IDs = nil }, "no packet IDs"},
+		{"unknown release", func(p *PacketSchema) { p.IDs["1.8"] = "0x01" }, `unsupported release "1.8"`},
+		{"malformed release", func(p *PacketSchema) { p.IDs["latest"] = "0x01" }, `unsupported release "latest"`},
+		{"shared protocol", func(p *PacketSchema) { p.IDs["1.21.1"] = "0x12" }, "share protocol 767"},
+		{"packet ID", func(p *PacketSchema) { p.IDs["1.21"] = "ten" }, "invalid packet ID"},
+		{"field name", func(p *PacketSchema) { p.Fields[0].Name = "value" }, "invalid field name"},
+		{"duplicate field", func(p *PacketSchema) { p.Fields[1].Name = "Value" }, "duplicate field"},
+		{"field type", func(p *PacketSchema) { p.Fields[0].Type = "int128" }, "unknown type"},
+		{"optional array", func(p *PacketSchema) { p.Fields[1].Array = true }, "both optional and an array"},
+		{"rest not last", func(p *PacketSchema) { p.Fields[0].Type = "rest" }, "rest must be the plain last field"},
+		{"optional rest", func(p *PacketSchema) { p.Fields[2].Optional = true }, "rest must be the plain last field"},
+		{"since release", func(p *PacketSchema) { p.Fields[1].Since = "1.20.7" }, `unsupported release "1.20.7"`},
+		{"until release", func(p *PacketSchema) { p.Fields[1].Until = "2.0" }, `unsupported release "2.0"`},
+		{"negative max", func(p *PacketSchema) { p.Fields[1].Max = -1 }, "max requires a string type"},
+		{"max of varint", func(p *PacketSchema) { p.Fields[0].Max = 8 }, "max requires a string type"},
+	}
+
+	for _, tt := range tests {
+		p := validPacket()
+		tt.modify(&p)
+		schema := Schema{Packets: []PacketSchema{p}}
+		err := schema.validate()
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: got error %v, want %q", tt.name, err, tt.want)
+		}
+	}
+
+	schema = Schema{Packets: []PacketSchema{validPacket(), validPacket()}}
+	if err := schema.validate(); err == nil || !strings.Contains(err.Error(), "duplicate packet") {
+		t.Errorf("duplicate packet: got error %v", err)
+	}
+}
+
+func TestSortedReleases(t *testing.T) {
+	p := PacketSchema{IDs: map[string]string{"1.21": "0x03", "1.20.10": "0x02", "1.20.2": "0x01", "1.19.4": "0x00"}}
+	got := strings.Join(p.sortedReleases(), " ")
+	if want := "1.19.4 1.20.2 1.20.10 1.21"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+	if id := p.latestID(); id != "0x03" {
+		t.Errorf("latest ID: got %s, want 0x03", id)
+	}
+}
+
+func TestProtocolConstant(t *testing.T) {
+	tests := map[string]string{
+		"1.19.4": "Protocol1_19_4",
+		"1.20":   "Protocol1_20",
+		"1.20.1": "Protocol1_20",
+		"1.20.4": "Protocol1_20_3",
+		"1.20.6": "Protocol1_20_5",
+		"1.21.1": "Protocol1_21",
+		"1.21.3": "Protocol1_21_2",
+		"1.21.4": "Protocol1_21_4",
+	}
+	for release, want := range tests {
+		if got := protocolConstant(release); got != want {
+			t.Errorf("%s: got %s, want %s", release, got, want)
+		}
+	}
+}
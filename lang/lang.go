@@ -0,0 +1,183 @@
+// Package lang renders translatable text components with language files in
+// the format of the game, such as assets/minecraft/lang/en_us.json
+package lang
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"mc-proxy/protocol/types"
+)
+
+// DefaultLocale is the language used when a client's locale is not available
+const DefaultLocale = "en_us"
+
+// Language is a table of translations. A nil Language knows no translations.
+type Language struct {
+	Locale   string    // such as en_us
+	Fallback *Language // consulted for keys missing in this language
+
+	translations map[string]string
+}
+
+// Parse reads a language file, a JSON object mapping keys to translations
+func Parse(locale string, data []byte) (*Language, error) {
+	var translations map[string]string
+	if err := json.Unmarshal(data, &translations); err != nil {
+		return nil, fmt.Errorf("failed to parse language %s: %v", locale, err)
+	}
+	return &Language{Locale: NormalizeLocale(locale), translations: translations}, nil
+}
+
+// Load reads a language file named after its locale, such as en_us.json
+func Load(path string) (*Language, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read language file: %v", err)
+	}
+	return Parse(strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)), data)
+}
+
+// Translate returns the translation of a key, looking at the fallback
+// languages if needed. It can be used as a types.Translator.
+func (l *Language) Translate(key string) (string, bool) {
+	for ; l != nil; l = l.Fallback {
+		if translation, ok := l.translations[key]; ok {
+			return translation, true
+		}
+	}
+	return "", false
+}
+
+// Len returns the number of translations, not counting the fallback
+func (l *Language) Len() int {
+	if l == nil {
+		return 0
+	}
+	return len(l.translations)
+}
+
+// Render replaces the translatable components of c by their text in this
+// language, substituting %s and %1$s with the arguments
+func (l *Language) Render(c types.ChatComponent) types.ChatComponent {
+	return c.Translated(l.Translate)
+}
+
+// Text returns the plain text of c in this language
+func (l *Language) Text(c types.ChatComponent) string {
+	return c.TranslatedText(l.Translate)
+}
+
+// NormalizeLocale converts a locale like en-US or en_US to the form of the
+// language files
+func NormalizeLocale(locale string) string {
+	return strings.ReplaceAll(strings.ToLower(locale), "-", "_")
+}
+
+// Languages selects a language for each client locale
+type Languages struct {
+	byLocale map[string]*Language
+	def      *Language
+}
+
+// NewLanguages creates a set of languages using def for unknown locales and
+// as fallback for missing translations
+func NewLanguages(def *Language) *Languages {
+	languages := &Languages{byLocale: map[string]*Language{}, def: def}
+	languages.Add(def)
+	return languages
+}
+
+// Add adds a language, replacing one with the same locale
+func (s *Languages) Add(language *Language) {
+	if language != s.def && language.Fallback == nil {
+		language.Fallback = s.def
+	}
+	s.byLocale[language.Locale] = language
+}
+
+// LoadDir loads all language files of a directory. The language of
+// defaultLocale must be among them.
+func LoadDir(dir string, defaultLocale string) (*Languages, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var loaded []*Language
+	var def *Language
+	for _, path := range paths {
+		language, err := Load(path)
+		if err != nil {
+			return nil, err
+		}
+		if language.Locale == NormalizeLocale(defaultLocale) {
+			def = language
+		}
+		loaded = append(loaded, language)
+	}
+	if def == nil {
+		return nil, fmt.Errorf("default language %s not found in %s", defaultLocale, dir)
+	}
+
+	languages := NewLanguages(def)
+	for _, language := range loaded {
+		languages.Add(language)
+	}
+	return languages, nil
+}
+
+// Default returns the default language
+func (s *Languages) Default() *Language {
+	if s == nil {
+		return nil
+	}
+	return s.def
+}
+
+// Get returns the language for a client locale. Without an exact match it
+// prefers the main variant of the same language, like de_de for de_at, then
+// any variant, then the default language.
+func (s *Languages) Get(locale string) *Language {
+	if s == nil {
+		return nil
+	}
+
+	locale = NormalizeLocale(locale)
+	if language, ok := s.byLocale[locale]; ok {
+		return language
+	}
+
+	prefix, _, _ := strings.Cut(locale, "_")
+	if language, ok := s.byLocale[prefix+"_"+prefix]; ok {
+		return language
+	}
+	var variants []string
+	for other := range s.byLocale {
+		if strings.HasPrefix(other, prefix+"_") {
+			variants = append(variants, other)
+		}
+	}
+	if len(variants) > 0 {
+		sort.Strings(variants)
+		return s.byLocale[variants[0]]
+	}
+	return s.def
+}
+
+// Locales returns the locales of all languages, sorted
+func (s *Languages) Locales() []string {
+	if s == nil {
+		return nil
+	}
+	locales := make([]string, 0, len(s.byLocale))
+	for locale := range s.byLocale {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
@@ -0,0 +1,142 @@
+package lang
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"mc-proxy/protocol/types"
+)
+
+func mustParse(t *testing.T, locale, data string) *Language {
+	t.Helper()
+	language, err := Parse(locale, []byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return language
+}
+
+func TestParse(t *testing.T) {
+	language := mustParse(t, "en-US", `{"greet":"Hello %s!","bye":"Bye"}`)
+	if language.Locale != "en_us" || language.Len() != 2 {
+		t.Errorf("Parse = %s with %d translations", language.Locale, language.Len())
+	}
+
+	for _, data := range []string{``, `[]`, `{"key":1}`} {
+		if _, err := Parse("en_us", []byte(data)); err == nil {
+			t.Errorf("Parse(%q) succeeded", data)
+		}
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	def := mustParse(t, "en_us", `{"greet":"Hello %s!","bye":"Bye"}`)
+	german := mustParse(t, "de_de", `{"greet":"Hallo %s!"}`)
+	german.Fallback = def
+
+	tests := []struct {
+		language *Language
+		key      string
+		want     string
+		ok       bool
+	}{
+		{german, "greet", "Hallo %s!", true},
+		{german, "bye", "Bye", true},
+		{german, "missing", "", false},
+		{nil, "greet", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := tt.language.Translate(tt.key)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("Translate(%q) = %q, %v, want %q, %v", tt.key, got, ok, tt.want, tt.ok)
+		}
+	}
+
+	c := types.ChatComponent{Translate: "greet", With: []types.ChatComponent{{Text: "Steve"}}}
+	if got := german.Text(c); got != "Hallo Steve!" {
+		t.Errorf("Text = %q", got)
+	}
+	want := types.ChatComponent{Text: "Hallo ", Extra: []types.ChatComponent{{Text: "Steve"}, {Text: "!"}}}
+	if got := german.Render(c); !reflect.DeepEqual(got, want) {
+		t.Errorf("Render = %+v, want %+v", got, want)
+	}
+	if got := (*Language)(nil).Text(c); got != "greet" {
+		t.Errorf("Text without a language = %q, want the key", got)
+	}
+}
+
+func TestNormalizeLocale(t *testing.T) {
+	for _, locale := range []string{"en_us", "en-US", "EN_us"} {
+		if got := NormalizeLocale(locale); got != "en_us" {
+			t.Errorf("NormalizeLocale(%q) = %q", locale, got)
+		}
+	}
+}
+
+func TestLanguagesGet(t *testing.T) {
+	languages := NewLanguages(mustParse(t, "en_us", `{}`))
+	for _, locale := range []string{"de_at", "de_de", "pt_pt", "pt_br", "es_mx", "es_ar"} {
+		languages.Add(mustParse(t, locale, `{}`))
+	}
+
+	tests := []struct {
+		locale string
+		want   string
+	}{
+		{"de_AT", "de_at"},
+		{"de_ch", "de_de"},
+		{"pt_xx", "pt_pt"},
+		{"es_es", "es_ar"},
+		{"fr_fr", "en_us"},
+		{"", "en_us"},
+	}
+	for _, tt := range tests {
+		if got := languages.Get(tt.locale).Locale; got != tt.want {
+			t.Errorf("Get(%q) = %s, want %s", tt.locale, got, tt.want)
+		}
+	}
+
+	if got := languages.Get("de_at").Fallback; got != languages.Default() {
+		t.Errorf("fallback of an added language = %v, want the default", got)
+	}
+	if got := (*Languages)(nil).Get("en_us"); got != nil {
+		t.Errorf("Get on nil Languages = %v", got)
+	}
+}
+
+func TestLoadDir(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"en_us.json": `{"a":"A","b":"B"}`,
+		"de_de.json": `{"a":"Ä"}`,
+		"readme.txt": `not a language`,
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	languages, err := LoadDir(dir, "en-US")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := languages.Locales(); !reflect.DeepEqual(got, []string{"de_de", "en_us"}) {
+		t.Errorf("Locales = %v", got)
+	}
+	if got, _ := languages.Get("de_de").Translate("b"); got != "B" {
+		t.Errorf("missing translation = %q, want the default B", got)
+	}
+
+	if _, err := LoadDir(dir, "fr_fr"); err == nil {
+		t.Error("LoadDir without the default language succeeded")
+	}
+	if err := os.WriteFile(filepath.Join(dir, "broken.json"), []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadDir(dir, "en_us"); err == nil {
+		t.Error("LoadDir with a broken file succeeded")
+	}
+}
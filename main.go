@@ -7,6 +7,8 @@ import (
 	"os/signal"
 	"syscall"
 
+	"mc-proxy/lang"
+	"mc-proxy/protocol/auth"
 	"mc-proxy/proxy"
 )
 
@@ -14,6 +16,10 @@ func main() {
 	// Parse command line flags
 	listenAddr := flag.String("listen", "127.0.0.1:25565", "Address to listen on")
 	serverAddr := flag.String("server", "127.0.0.1:25566", "Address of the Minecraft server")
+	compressionThreshold := flag.Int("compression-threshold", proxy.DefaultCompressionThreshold, "Compression threshold towards clients, -1 to disable")
+	onlineMode := flag.Bool("online-mode", false, "Authenticate players at the proxy, the server must run in offline mode")
+	sessionServer := flag.String("session-server", auth.MojangSessionServerURL, "Base URL of the session server")
+	langDir := flag.String("lang-dir", "", "Directory of language files like en_us.json for proxy messages")
 	flag.Parse()
 
 	// Create and start the proxy
@@ -22,6 +28,17 @@ func main() {
 		fmt.Printf("Failed to create proxy: %v\n", err)
 		os.Exit(1)
 	}
+	p.SetCompressionThreshold(*compressionThreshold)
+	p.SetOnlineMode(*onlineMode)
+	p.SetSessionServer(auth.NewHTTPSessionServer(*sessionServer))
+	if *langDir != "" {
+		languages, err := lang.LoadDir(*langDir, lang.DefaultLocale)
+		if err != nil {
+			fmt.Printf("Failed to load languages: %v\n", err)
+			os.Exit(1)
+		}
+		p.SetLanguages(languages)
+	}
 
 	// Handle graceful shutdown
 	sigChan := make(chan os.Signal, 1)
@@ -0,0 +1,45 @@
+package auth
+
+import (
+	"crypto/sha1"
+	"math/big"
+
+	"mc-proxy/protocol/types"
+)
+
+// Property is a signed profile property such as the skin textures
+type Property struct {
+	Name      string `json:"name"`
+	Value     string `json:"value"`
+	Signature string `json:"signature,omitempty"`
+}
+
+// Profile is a player profile as returned by the session server
+type Profile struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Properties []Property `json:"properties,omitempty"`
+}
+
+// UUID parses the undashed profile ID
+func (p Profile) UUID() (types.UUID, error) {
+	return types.ParseUUID(p.ID)
+}
+
+// ServerHash computes the server ID hash sent to the session server. It is
+// the SHA-1 digest of the server ID, shared secret and encoded public key,
+// printed as a signed hexadecimal number.
+func ServerHash(serverID string, sharedSecret, publicKey []byte) string {
+	h := sha1.New()
+	h.Write([]byte(serverID))
+	h.Write(sharedSecret)
+	h.Write(publicKey)
+	sum := h.Sum(nil)
+
+	n := new(big.Int).SetBytes(sum)
+	if sum[0]&0x80 != 0 {
+		// Interpret the digest as a two's complement number
+		n.Sub(n, new(big.Int).Lsh(big.NewInt(1), uint(len(sum)*8)))
+	}
+	return n.Text(16)
+}
@@ -0,0 +1,42 @@
+package auth
+
+import (
+	"testing"
+
+	"mc-proxy/protocol/types"
+)
+
+func TestServerHash(t *testing.T) {
+	// The well-known examples of the signed hexadecimal digest
+	tests := []struct {
+		serverID string
+		want     string
+	}{
+		{"Notch", "4ed1f46bbe04bc756bcb17c0c7ce3e4632f06a48"},
+		{"jeb_", "-7c9d5b0044c130109a5d7b5fb5c317c02b4e28c1"},
+		{"simon", "88e16a1019277b15d58faf0541e11910eb756f6"},
+	}
+	for _, tt := range tests {
+		if got := ServerHash(tt.serverID, nil, nil); got != tt.want {
+			t.Errorf("ServerHash(%q) = %s, want %s", tt.serverID, got, tt.want)
+		}
+	}
+
+	// The server ID, shared secret and public key are hashed in sequence
+	if got, want := ServerHash("", []byte("No"), []byte("tch")), ServerHash("Notch", nil, nil); got != want {
+		t.Errorf("ServerHash of the split name = %s, want %s", got, want)
+	}
+}
+
+func TestProfileUUID(t *testing.T) {
+	profile := Profile{ID: "069a79f444e94726a5befca90e38aaf5", Name: "Notch"}
+	got, err := profile.UUID()
+	want := types.UUID{MostSignificantBits: 0x069a79f444e94726, LeastSignificantBits: -0x5a410356f1c7550b}
+	if err != nil || got != want {
+		t.Errorf("UUID = %v, %v, want %v", got, err, want)
+	}
+
+	if _, err := (Profile{ID: "not a uuid"}).UUID(); err == nil {
+		t.Error("UUID of an invalid ID succeeded")
+	}
+}
@@ -0,0 +1,65 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+)
+
+type joinKey struct {
+	username   string
+	serverHash string
+}
+
+// LocalSessionServer is an in-memory stand-in for the session server. It can
+// be used directly as a SessionServer or served over HTTP, for example with
+// net/http/httptest, and queried through HTTPSessionServer.
+type LocalSessionServer struct {
+	mutex  sync.Mutex
+	joined map[joinKey]Profile
+}
+
+// NewLocalSessionServer creates a session server without joined players
+func NewLocalSessionServer() *LocalSessionServer {
+	return &LocalSessionServer{
+		joined: make(map[joinKey]Profile),
+	}
+}
+
+// Join records that the player announced joining the server identified by serverHash
+func (s *LocalSessionServer) Join(serverHash string, profile Profile) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.joined[joinKey{profile.Name, serverHash}] = profile
+}
+
+func (s *LocalSessionServer) HasJoined(ctx context.Context, username, serverHash, ip string) (*Profile, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	profile, ok := s.joined[joinKey{username, serverHash}]
+	if !ok {
+		return nil, ErrNotAuthenticated
+	}
+	return &profile, nil
+}
+
+// ServeHTTP implements the hasJoined endpoint of the session server API
+func (s *LocalSessionServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet || r.URL.Path != "/session/minecraft/hasJoined" {
+		http.NotFound(w, r)
+		return
+	}
+
+	query := r.URL.Query()
+	profile, err := s.HasJoined(r.Context(), query.Get("username"), query.Get("serverId"), query.Get("ip"))
+	if err != nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(profile)
+}
@@ -0,0 +1,73 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// MojangSessionServerURL is the base URL of the official session server
+const MojangSessionServerURL = "https://sessionserver.mojang.com"
+
+// ErrNotAuthenticated is returned when the player did not join the server
+// with the session server
+var ErrNotAuthenticated = errors.New("player is not authenticated")
+
+// SessionServer verifies that a player joined the server with their account
+type SessionServer interface {
+	// HasJoined returns the profile of the player if they announced joining
+	// the server identified by serverHash. The ip is optional.
+	HasJoined(ctx context.Context, username, serverHash, ip string) (*Profile, error)
+}
+
+// HTTPSessionServer talks to a session server over its HTTP API
+type HTTPSessionServer struct {
+	BaseURL string
+	Client  *http.Client
+}
+
+// NewHTTPSessionServer creates a session server client for the given base URL
+func NewHTTPSessionServer(baseURL string) *HTTPSessionServer {
+	return &HTTPSessionServer{
+		BaseURL: strings.TrimSuffix(baseURL, "/"),
+		Client:  http.DefaultClient,
+	}
+}
+
+func (s *HTTPSessionServer) HasJoined(ctx context.Context, username, serverHash, ip string) (*Profile, error) {
+	query := url.Values{}
+	query.Set("username", username)
+	query.Set("serverId", serverHash)
+	if ip != "" {
+		query.Set("ip", ip)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.BaseURL+"/session/minecraft/hasJoined?"+query.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create session request: %v", err)
+	}
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to contact session server: %v", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNoContent:
+		return nil, ErrNotAuthenticated
+	default:
+		return nil, fmt.Errorf("session server returned %s", resp.Status)
+	}
+
+	var profile Profile
+	if err := json.NewDecoder(resp.Body).Decode(&profile); err != nil {
+		return nil, fmt.Errorf("failed to decode session response: %v", err)
+	}
+	return &profile, nil
+}
@@ -0,0 +1,110 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestSessionServers(t *testing.T) {
+	local := NewLocalSessionServer()
+	notch := Profile{
+		ID:         "069a79f444e94726a5befca90e38aaf5",
+		Name:       "Notch",
+		Properties: []Property{{Name: "textures", Value: "e30=", Signature: "c2ln"}},
+	}
+	local.Join("-7c9d5b0044c130109a5d7b5fb5c317c02b4e28c1", notch)
+
+	server := httptest.NewServer(local)
+	defer server.Close()
+
+	servers := map[string]SessionServer{
+		"local": local,
+		"http":  NewHTTPSessionServer(server.URL + "/"),
+	}
+	tests := []struct {
+		name       string
+		username   string
+		serverHash string
+		want       *Profile
+	}{
+		{"joined", "Notch", "-7c9d5b0044c130109a5d7b5fb5c317c02b4e28c1", &notch},
+		{"other server", "Notch", "4ed1f46bbe04bc756bcb17c0c7ce3e4632f06a48", nil},
+		{"other player", "jeb_", "-7c9d5b0044c130109a5d7b5fb5c317c02b4e28c1", nil},
+	}
+	for serverName, s := range servers {
+		for _, tt := range tests {
+			t.Run(serverName+"/"+tt.name, func(t *testing.T) {
+				got, err := s.HasJoined(context.Background(), tt.username, tt.serverHash, "127.0.0.1")
+				if tt.want == nil {
+					if !errors.Is(err, ErrNotAuthenticated) {
+						t.Errorf("HasJoined = %+v, %v, want ErrNotAuthenticated", got, err)
+					}
+					return
+				}
+				if err != nil || !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("HasJoined = %+v, %v, want %+v", got, err, tt.want)
+				}
+			})
+		}
+	}
+}
+
+func TestHTTPSessionServerErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+	}{
+		{"server error", func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		}},
+		{"invalid JSON", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("{"))
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(tt.handler)
+			defer server.Close()
+
+			got, err := NewHTTPSessionServer(server.URL).HasJoined(context.Background(), "Notch", "hash", "")
+			if err == nil || errors.Is(err, ErrNotAuthenticated) {
+				t.Errorf("HasJoined = %+v, %v, want a server error", got, err)
+			}
+		})
+	}
+
+	// The request carries the query parameters of the hasJoined endpoint
+	var query map[string][]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/session/minecraft/hasJoined" {
+			t.Errorf("request path = %s", r.URL.Path)
+		}
+		query = r.URL.Query()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+	NewHTTPSessionServer(server.URL).HasJoined(context.Background(), "Notch", "-1a", "")
+	if want := map[string][]string{"username": {"Notch"}, "serverId": {"-1a"}}; !reflect.DeepEqual(query, want) {
+		t.Errorf("query = %v, want %v", query, want)
+	}
+}
+
+func TestLocalSessionServerHTTP(t *testing.T) {
+	server := httptest.NewServer(NewLocalSessionServer())
+	defer server.Close()
+
+	for _, path := range []string{"/", "/session/minecraft/join"} {
+		resp, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("GET %s = %s, want 404", path, resp.Status)
+		}
+	}
+}
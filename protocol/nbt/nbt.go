@@ -0,0 +1,556 @@
+// Package nbt maps Go values to NBT and back, like encoding/json does for
+// JSON. Struct fields are matched to compound entries by their nbt tag:
+//
+//	Height    int32   `nbt:"height"`
+//	Ambient   float32 `nbt:"ambient_light,omitempty"`
+//	Skipped   string  `nbt:"-"`
+//
+// Go values are mapped to tags as follows:
+//
+//	bool, int8, uint8        TAG_Byte
+//	int16, uint16            TAG_Short
+//	int32, uint32            TAG_Int
+//	int64, uint64, int, uint TAG_Long
+//	float32                  TAG_Float
+//	float64                  TAG_Double
+//	string                   TAG_String
+//	[]byte                   TAG_Byte_Array
+//	[]int32                  TAG_Int_Array
+//	[]int64                  TAG_Long_Array
+//	other slices and arrays  TAG_List
+//	structs, map[string]T    TAG_Compound
+//
+// The list option encodes a []byte, []int32 or []int64 field as TAG_List
+// instead of an array. Values of the types package, such as *NBTCompound,
+// are passed through unchanged and interface{} values decode to them.
+package nbt
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"mc-proxy/protocol/types"
+)
+
+// Marshal encodes a value as network NBT with a nameless root
+func Marshal(v any) ([]byte, error) {
+	value, err := ToValue(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := types.WriteNetworkNBT(value, &buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Unmarshal decodes network NBT with a nameless root into the value pointed
+// to by v
+func Unmarshal(data []byte, v any) error {
+	r := bytes.NewReader(data)
+	value, err := types.ReadNetworkNBT(r)
+	if err != nil {
+		return err
+	}
+	if r.Len() > 0 {
+		return fmt.Errorf("nbt: %d bytes left after the root tag", r.Len())
+	}
+	return FromValue(value, v)
+}
+
+// UnsupportedTypeError is returned when a Go type has no NBT representation
+type UnsupportedTypeError struct {
+	Type reflect.Type
+}
+
+func (e *UnsupportedTypeError) Error() string {
+	return "nbt: unsupported type " + e.Type.String()
+}
+
+// UnmarshalTypeError is returned when a tag cannot be stored in a Go value
+type UnmarshalTypeError struct {
+	Tag   types.NBTTag // tag type of the NBT value
+	Type  reflect.Type // type of the Go value
+	Field string       // dotted path of the struct field, if any
+}
+
+func (e *UnmarshalTypeError) Error() string {
+	if e.Field != "" {
+		return fmt.Sprintf("nbt: cannot unmarshal %s into Go struct field %s of type %s", e.Tag, e.Field, e.Type)
+	}
+	return fmt.Sprintf("nbt: cannot unmarshal %s into Go value of type %s", e.Tag, e.Type)
+}
+
+// ToValue converts a Go value to an NBT value of the types package
+func ToValue(v any) (types.NBTValue, error) {
+	value, ok, err := encode(reflect.ValueOf(v), false)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("nbt: cannot marshal nil value")
+	}
+	return value, nil
+}
+
+// FromValue stores an NBT value of the types package in the value pointed
+// to by v
+func FromValue(value types.NBTValue, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("nbt: cannot unmarshal into non-pointer %T", v)
+	}
+	return decode(value, rv.Elem(), "")
+}
+
+var (
+	compoundType = reflect.TypeOf((*types.NBTCompound)(nil))
+	listType     = reflect.TypeOf((*types.NBTList)(nil))
+)
+
+// encode converts a Go value. ok is false for nil pointers and interfaces,
+// which have no NBT representation and are left out of compounds.
+func encode(v reflect.Value, asList bool) (value types.NBTValue, ok bool, err error) {
+	if !v.IsValid() {
+		return nil, false, nil
+	}
+	if v.Type() == compoundType || v.Type() == listType {
+		if v.IsNil() {
+			return nil, false, nil
+		}
+		return v.Interface(), true, nil
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil, false, nil
+		}
+		return encode(v.Elem(), asList)
+	case reflect.Bool:
+		if v.Bool() {
+			return int8(1), true, nil
+		}
+		return int8(0), true, nil
+	case reflect.Int8:
+		return int8(v.Int()), true, nil
+	case reflect.Uint8:
+		return int8(v.Uint()), true, nil
+	case reflect.Int16:
+		return int16(v.Int()), true, nil
+	case reflect.Uint16:
+		return int16(v.Uint()), true, nil
+	case reflect.Int32:
+		return int32(v.Int()), true, nil
+	case reflect.Uint32:
+		return int32(v.Uint()), true, nil
+	case reflect.Int, reflect.Int64:
+		return v.Int(), true, nil
+	case reflect.Uint, reflect.Uint64:
+		return int64(v.Uint()), true, nil
+	case reflect.Float32:
+		return float32(v.Float()), true, nil
+	case reflect.Float64:
+		return v.Float(), true, nil
+	case reflect.String:
+		return v.String(), true, nil
+	case reflect.Slice, reflect.Array:
+		if !asList {
+			switch v.Type().Elem().Kind() {
+			case reflect.Uint8:
+				return toSlice[byte](v), true, nil
+			case reflect.Int32:
+				return toSlice[int32](v), true, nil
+			case reflect.Int64:
+				return toSlice[int64](v), true, nil
+			}
+		}
+		list, err := encodeList(v)
+		return list, err == nil, err
+	case reflect.Struct:
+		compound, err := encodeStruct(v)
+		return compound, err == nil, err
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return nil, false, &UnsupportedTypeError{v.Type()}
+		}
+		compound, err := encodeMap(v)
+		return compound, err == nil, err
+	default:
+		return nil, false, &UnsupportedTypeError{v.Type()}
+	}
+}
+
+// toSlice copies a slice or array of integers into a slice of T
+func toSlice[T byte | int32 | int64](v reflect.Value) []T {
+	values := make([]T, v.Len())
+	for i := range values {
+		if elem := v.Index(i); elem.CanInt() {
+			values[i] = T(elem.Int())
+		} else {
+			values[i] = T(elem.Uint())
+		}
+	}
+	return values
+}
+
+func encodeList(v reflect.Value) (*types.NBTList, error) {
+	list := &types.NBTList{}
+	for i := 0; i < v.Len(); i++ {
+		value, ok, err := encode(v.Index(i), false)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, fmt.Errorf("nbt: list element %d is nil", i)
+		}
+
+		tagType, err := tagOf(value)
+		if err != nil {
+			return nil, err
+		}
+		if i == 0 {
+			list.ElemType = tagType
+		} else if tagType != list.ElemType {
+			return nil, fmt.Errorf("nbt: list element %d is %s, not %s", i, tagType, list.ElemType)
+		}
+		list.Values = append(list.Values, value)
+	}
+	return list, nil
+}
+
+func encodeStruct(v reflect.Value) (*types.NBTCompound, error) {
+	compound := &types.NBTCompound{}
+	for _, f := range fieldsOf(v.Type()) {
+		field := v.FieldByIndex(f.index)
+		if f.omitEmpty && field.IsZero() {
+			continue
+		}
+
+		value, ok, err := encode(field, f.asList)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.name, err)
+		}
+		if ok {
+			compound.Entries = append(compound.Entries, types.NBTEntry{Name: f.name, Value: value})
+		}
+	}
+	return compound, nil
+}
+
+func encodeMap(v reflect.Value) (*types.NBTCompound, error) {
+	keys := make([]string, 0, v.Len())
+	for _, key := range v.MapKeys() {
+		keys = append(keys, key.String())
+	}
+	sort.Strings(keys)
+
+	compound := &types.NBTCompound{}
+	for _, key := range keys {
+		value, ok, err := encode(v.MapIndex(reflect.ValueOf(key).Convert(v.Type().Key())), false)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		if ok {
+			compound.Entries = append(compound.Entries, types.NBTEntry{Name: key, Value: value})
+		}
+	}
+	return compound, nil
+}
+
+// tagOf returns the tag type of an encoded value
+func tagOf(value types.NBTValue) (types.NBTTag, error) {
+	switch value.(type) {
+	case bool, int8:
+		return types.TagByte, nil
+	case int16:
+		return types.TagShort, nil
+	case int32:
+		return types.TagInt, nil
+	case int64:
+		return types.TagLong, nil
+	case float32:
+		return types.TagFloat, nil
+	case float64:
+		return types.TagDouble, nil
+	case string:
+		return types.TagString, nil
+	case []byte:
+		return types.TagByteArray, nil
+	case []int32:
+		return types.TagIntArray, nil
+	case []int64:
+		return types.TagLongArray, nil
+	case *types.NBTList:
+		return types.TagList, nil
+	case *types.NBTCompound:
+		return types.TagCompound, nil
+	default:
+		return types.TagEnd, fmt.Errorf("nbt: unsupported value %T", value)
+	}
+}
+
+func decode(value types.NBTValue, v reflect.Value, field string) error {
+	tagType, err := tagOf(value)
+	if err != nil {
+		return err
+	}
+	mismatch := func() error {
+		return &UnmarshalTypeError{Tag: tagType, Type: v.Type(), Field: field}
+	}
+
+	// Tree values and interfaces take the value as is
+	if v.Type() == compoundType || v.Type() == listType {
+		if reflect.TypeOf(value) != v.Type() {
+			return mismatch()
+		}
+		v.Set(reflect.ValueOf(value))
+		return nil
+	}
+	if v.Kind() == reflect.Interface {
+		if v.NumMethod() > 0 {
+			return mismatch()
+		}
+		v.Set(reflect.ValueOf(value))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return decode(value, v.Elem(), field)
+
+	case reflect.Bool:
+		n, ok := integer(value)
+		if !ok {
+			return mismatch()
+		}
+		v.SetBool(n != 0)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, ok := integer(value)
+		if !ok || v.OverflowInt(n) {
+			return mismatch()
+		}
+		v.SetInt(n)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, ok := integer(value)
+		if !ok {
+			return mismatch()
+		}
+		// Unsigned fields hold the bits of the signed tag
+		v.SetUint(uint64(n) & (1<<v.Type().Bits() - 1))
+
+	case reflect.Float32, reflect.Float64:
+		switch f := value.(type) {
+		case float32:
+			v.SetFloat(float64(f))
+		case float64:
+			v.SetFloat(f)
+		default:
+			n, ok := integer(value)
+			if !ok {
+				return mismatch()
+			}
+			v.SetFloat(float64(n))
+		}
+
+	case reflect.String:
+		s, ok := value.(string)
+		if !ok {
+			return mismatch()
+		}
+		v.SetString(s)
+
+	case reflect.Slice, reflect.Array:
+		elems, ok := elementsOf(value)
+		if !ok {
+			return mismatch()
+		}
+		if v.Kind() == reflect.Slice {
+			v.Set(reflect.MakeSlice(v.Type(), len(elems), len(elems)))
+		} else if len(elems) != v.Len() {
+			return fmt.Errorf("nbt: cannot unmarshal %d elements into Go value of type %s", len(elems), v.Type())
+		}
+		for i, elem := range elems {
+			if err := decode(elem, v.Index(i), fmt.Sprintf("%s[%d]", field, i)); err != nil {
+				return err
+			}
+		}
+
+	case reflect.Struct:
+		compound, ok := value.(*types.NBTCompound)
+		if !ok {
+			return mismatch()
+		}
+		return decodeStruct(compound, v, field)
+
+	case reflect.Map:
+		compound, ok := value.(*types.NBTCompound)
+		if !ok || v.Type().Key().Kind() != reflect.String {
+			return mismatch()
+		}
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(v.Type()))
+		}
+		for _, entry := range compound.Entries {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			if err := decode(entry.Value, elem, join(field, entry.Name)); err != nil {
+				return err
+			}
+			v.SetMapIndex(reflect.ValueOf(entry.Name).Convert(v.Type().Key()), elem)
+		}
+
+	default:
+		return &UnsupportedTypeError{v.Type()}
+	}
+	return nil
+}
+
+func decodeStruct(compound *types.NBTCompound, v reflect.Value, field string) error {
+	fields := fieldsOf(v.Type())
+	for _, entry := range compound.Entries {
+		f := lookupField(fields, entry.Name)
+		if f == nil {
+			continue // unknown entries are ignored
+		}
+		if err := decode(entry.Value, v.FieldByIndex(f.index), join(field, f.goName)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// integer returns the value of an integer tag
+func integer(value types.NBTValue) (int64, bool) {
+	switch n := value.(type) {
+	case int8:
+		return int64(n), true
+	case bool:
+		if n {
+			return 1, true
+		}
+		return 0, true
+	case int16:
+		return int64(n), true
+	case int32:
+		return int64(n), true
+	case int64:
+		return n, true
+	}
+	return 0, false
+}
+
+// elementsOf returns the elements of a list or array tag
+func elementsOf(value types.NBTValue) ([]types.NBTValue, bool) {
+	switch a := value.(type) {
+	case *types.NBTList:
+		return a.Values, true
+	case []byte:
+		elems := make([]types.NBTValue, len(a))
+		for i, b := range a {
+			elems[i] = int8(b)
+		}
+		return elems, true
+	case []int32:
+		elems := make([]types.NBTValue, len(a))
+		for i, n := range a {
+			elems[i] = n
+		}
+		return elems, true
+	case []int64:
+		elems := make([]types.NBTValue, len(a))
+		for i, n := range a {
+			elems[i] = n
+		}
+		return elems, true
+	}
+	return nil, false
+}
+
+// field is an encoded struct field
+type field struct {
+	name      string // compound entry name
+	goName    string
+	index     []int
+	omitEmpty bool
+	asList    bool
+}
+
+var fieldCache sync.Map // reflect.Type -> []field
+
+func fieldsOf(t reflect.Type) []field {
+	if fields, ok := fieldCache.Load(t); ok {
+		return fields.([]field)
+	}
+	fields, _ := fieldCache.LoadOrStore(t, collectFields(t, nil))
+	return fields.([]field)
+}
+
+func collectFields(t reflect.Type, index []int) []field {
+	var fields []field
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("nbt")
+		if tag == "-" {
+			continue
+		}
+		fieldIndex := append(append([]int(nil), index...), i)
+
+		// Embedded structs without a name are flattened like in encoding/json
+		name, options, _ := strings.Cut(tag, ",")
+		if sf.Anonymous && sf.Type.Kind() == reflect.Struct && name == "" {
+			fields = append(fields, collectFields(sf.Type, fieldIndex)...)
+			continue
+		}
+		if !sf.IsExported() {
+			continue
+		}
+
+		f := field{name: name, goName: sf.Name, index: fieldIndex}
+		if f.name == "" {
+			f.name = sf.Name
+		}
+		for _, option := range strings.Split(options, ",") {
+			switch option {
+			case "omitempty":
+				f.omitEmpty = true
+			case "list":
+				f.asList = true
+			}
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+// lookupField finds the field of an entry, preferring an exact match
+func lookupField(fields []field, name string) *field {
+	for i := range fields {
+		if fields[i].name == name {
+			return &fields[i]
+		}
+	}
+	for i := range fields {
+		if strings.EqualFold(fields[i].name, name) {
+			return &fields[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package nbt
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"mc-proxy/protocol/types"
+)
+
+type Base struct {
+	ID string `nbt:"id"`
+}
+
+type Item struct {
+	Base
+	Count   int8               `nbt:"Count"`
+	Damage  uint16             `nbt:"damage"`
+	Enabled bool               `nbt:"enabled"`
+	Scale   float32            `nbt:"scale,omitempty"`
+	Ratio   float64            `nbt:"ratio"`
+	Seed    int64              `nbt:"seed"`
+	Bytes   []byte             `nbt:"bytes"`
+	Ints    []int32            `nbt:"ints,list"`
+	Names   []string           `nbt:"names"`
+	Pos     [3]int32           `nbt:"pos"`
+	Tags    map[string]int32   `nbt:"tags"`
+	Next    *Item              `nbt:"next"`
+	Extra   types.NBTValue     `nbt:"extra"`
+	Raw     *types.NBTCompound `nbt:"raw"`
+	Skipped string             `nbt:"-"`
+}
+
+func TestRoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		value any
+		ptr   func() any
+	}{
+		{"int", int32(5), func() any { return new(int32) }},
+		{"string", "text", func() any { return new(string) }},
+		{"slice", []int64{1, 2}, func() any { return new([]int64) }},
+		{"map", map[string]string{"a": "b"}, func() any { return new(map[string]string) }},
+		{
+			"struct",
+			Item{
+				Base:    Base{ID: "minecraft:stone"},
+				Count:   3,
+				Damage:  65000,
+				Enabled: true,
+				Ratio:   0.5,
+				Seed:    -1 << 40,
+				Bytes:   []byte{1, 2},
+				Ints:    []int32{7},
+				Names:   []string{"a", "b"},
+				Pos:     [3]int32{1, -2, 3},
+				Tags:    map[string]int32{"x": 1},
+				Next:    &Item{Base: Base{ID: "inner"}, Bytes: []byte{}, Ints: []int32{}, Names: []string{}, Tags: map[string]int32{}},
+				Extra:   "anything",
+				Raw:     &types.NBTCompound{Entries: []types.NBTEntry{{Name: "k", Value: int8(1)}}},
+			},
+			func() any { return new(Item) },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := Marshal(tt.value)
+			if err != nil {
+				t.Fatalf("Marshal: %v", err)
+			}
+			got := tt.ptr()
+			if err := Unmarshal(data, got); err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			if got := reflect.ValueOf(got).Elem().Interface(); !reflect.DeepEqual(got, tt.value) {
+				t.Errorf("round trip = %#v, want %#v", got, tt.value)
+			}
+		})
+	}
+}
+
+func TestToValue(t *testing.T) {
+	value, err := ToValue(Item{Base: Base{ID: "a"}, Ints: []int32{1}, Skipped: "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	compound := value.(*types.NBTCompound)
+
+	if id, _ := compound.Get("id"); id != "a" {
+		t.Errorf("embedded field id = %v, want a", id)
+	}
+	if _, ok := compound.Get("scale"); ok {
+		t.Error("empty omitempty field was encoded")
+	}
+	if _, ok := compound.Get("Skipped"); ok {
+		t.Error("field tagged - was encoded")
+	}
+	if _, ok := compound.Get("next"); ok {
+		t.Error("nil pointer was encoded")
+	}
+	if ints, _ := compound.Get("ints"); !reflect.DeepEqual(ints, &types.NBTList{ElemType: types.TagInt, Values: []types.NBTValue{int32(1)}}) {
+		t.Errorf("list option encoded %#v, want a TAG_List", ints)
+	}
+	if bytes, _ := compound.Get("bytes"); !reflect.DeepEqual(bytes, []byte{}) {
+		t.Errorf("bytes encoded as %#v, want TAG_Byte_Array", bytes)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	var unsupported *UnsupportedTypeError
+	if _, err := Marshal(make(chan int)); !errors.As(err, &unsupported) {
+		t.Errorf("Marshal(chan) = %v, want UnsupportedTypeError", err)
+	}
+	if _, err := Marshal(nil); err == nil {
+		t.Error("Marshal(nil) succeeded")
+	}
+
+	data, err := Marshal(map[string]any{"Count": "many"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var item Item
+	var typeErr *UnmarshalTypeError
+	if err := Unmarshal(data, &item); !errors.As(err, &typeErr) || typeErr.Field != "Count" {
+		t.Errorf("Unmarshal of a string into int8 = %v, want UnmarshalTypeError for Count", err)
+	}
+
+	data, err = Marshal(int32(300))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var small int8
+	if err := Unmarshal(data, &small); !errors.As(err, &typeErr) {
+		t.Errorf("Unmarshal of 300 into int8 = %v, want UnmarshalTypeError", err)
+	}
+	if err := Unmarshal(data, small); err == nil {
+		t.Error("Unmarshal into a non-pointer succeeded")
+	}
+	if err := Unmarshal(append(data, 0), &small); err == nil {
+		t.Error("Unmarshal with trailing data succeeded")
+	}
+}
@@ -0,0 +1,25 @@
+package packet
+
+import (
+	"io"
+
+	"mc-proxy/protocol/types"
+)
+
+// WriteChat writes a text component in the format of the protocol version.
+// Play and configuration packets send network NBT since 1.20.3 and JSON
+// before. Login and status packets always send JSON.
+func WriteChat(c types.Chat, version int32, w io.Writer) error {
+	if version >= Protocol1_20_3 {
+		return types.WriteNBTChat(c, w)
+	}
+	return types.WriteChat(c, w)
+}
+
+// ReadChat reads a text component in the format of the protocol version
+func ReadChat(r io.Reader, version int32) (types.Chat, error) {
+	if version >= Protocol1_20_3 {
+		return types.ReadNBTChat(r)
+	}
+	return types.ReadChat(r)
+}
@@ -0,0 +1,76 @@
+package packet
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"fmt"
+)
+
+// cfb8 implements AES in 8-bit cipher feedback mode as used by the protocol
+type cfb8 struct {
+	block   cipher.Block
+	iv      []byte
+	tmp     []byte
+	decrypt bool
+}
+
+func newCFB8(block cipher.Block, iv []byte, decrypt bool) cipher.Stream {
+	return &cfb8{
+		block:   block,
+		iv:      append([]byte(nil), iv...),
+		tmp:     make([]byte, block.BlockSize()),
+		decrypt: decrypt,
+	}
+}
+
+func (c *cfb8) XORKeyStream(dst, src []byte) {
+	last := len(c.iv) - 1
+	for i := range src {
+		c.block.Encrypt(c.tmp, c.iv)
+		in := src[i]
+		out := in ^ c.tmp[0]
+
+		// Shift the ciphertext byte into the feedback register
+		copy(c.iv, c.iv[1:])
+		if c.decrypt {
+			c.iv[last] = in
+		} else {
+			c.iv[last] = out
+		}
+		dst[i] = out
+	}
+}
+
+// newStreams creates the encrypting and decrypting streams for a shared
+// secret, which the protocol uses as both the AES key and the IV
+func newStreams(sharedSecret []byte) (encrypt, decrypt cipher.Stream, err error) {
+	block, err := aes.NewCipher(sharedSecret)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid shared secret: %v", err)
+	}
+	return newCFB8(block, sharedSecret, false), newCFB8(block, sharedSecret, true), nil
+}
+
+// decryptReader decrypts bytes as they are taken from a buffered reader, so
+// bytes buffered before encryption was enabled are decrypted as well
+type decryptReader struct {
+	reader *bufio.Reader
+	stream cipher.Stream
+}
+
+func (d *decryptReader) Read(p []byte) (int, error) {
+	n, err := d.reader.Read(p)
+	d.stream.XORKeyStream(p[:n], p[:n])
+	return n, err
+}
+
+func (d *decryptReader) ReadByte() (byte, error) {
+	b, err := d.reader.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	buf := [1]byte{b}
+	d.stream.XORKeyStream(buf[:], buf[:])
+	return buf[0], nil
+}
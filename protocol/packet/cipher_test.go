@@ -0,0 +1,90 @@
+package packet
+
+import (
+	"bytes"
+	"crypto/aes"
+	"encoding/hex"
+	"net"
+	"reflect"
+	"testing"
+
+	"mc-proxy/protocol/types"
+)
+
+func TestCFB8(t *testing.T) {
+	// CFB8-AES128 example of NIST SP 800-38A, F.3.7 and F.3.8
+	key, _ := hex.DecodeString("2b7e151628aed2a6abf7158809cf4f3c")
+	iv, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	plaintext, _ := hex.DecodeString("6bc1bee22e409f96e93d7e117393172aae2d")
+	ciphertext, _ := hex.DecodeString("3b79424c9c0dd436bace9e0ed4586a4f32b9")
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The stream keeps its state between calls of any size
+	for _, split := range []int{0, 1, 7, len(plaintext)} {
+		got := make([]byte, len(plaintext))
+		encrypt := newCFB8(block, iv, false)
+		encrypt.XORKeyStream(got[:split], plaintext[:split])
+		encrypt.XORKeyStream(got[split:], plaintext[split:])
+		if !bytes.Equal(got, ciphertext) {
+			t.Errorf("split %d: encrypted = %x, want %x", split, got, ciphertext)
+		}
+
+		decrypt := newCFB8(block, iv, true)
+		decrypt.XORKeyStream(got[:split], got[:split])
+		decrypt.XORKeyStream(got[split:], got[split:])
+		if !bytes.Equal(got, plaintext) {
+			t.Errorf("split %d: decrypted = %x, want %x", split, got, plaintext)
+		}
+	}
+
+	if _, _, err := newStreams(make([]byte, 5)); err == nil {
+		t.Error("newStreams accepted a 5 byte secret")
+	}
+}
+
+func TestConnEncryption(t *testing.T) {
+	secret := []byte("0123456789abcdef")
+	clientSide, serverSide := net.Pipe()
+	client := NewConn(clientSide, Clientbound)
+	server := NewConn(serverSide, Serverbound)
+	defer client.Close()
+	defer server.Close()
+	client.SetState(StateLogin)
+	server.SetState(StateLogin)
+
+	sent := []Packet{
+		&LoginStart{Name: types.String{Value: "Notch"}},
+		&LoginStart{Name: types.String{Value: "jeb_"}},
+	}
+	errs := make(chan error, 1)
+	go func() {
+		if err := client.EnableEncryption(secret); err != nil {
+			errs <- err
+			return
+		}
+		for _, p := range sent {
+			if err := client.WritePacket(p); err != nil {
+				errs <- err
+				return
+			}
+		}
+		errs <- nil
+	}()
+
+	if err := server.EnableEncryption(secret); err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range sent {
+		got, err := server.ReadPacket()
+		if err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("packet %d = %+v, %v, want %+v", i, got, err, want)
+		}
+	}
+	if err := <-errs; err != nil {
+		t.Fatal(err)
+	}
+}
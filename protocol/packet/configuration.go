@@ -0,0 +1,450 @@
+package packet
+
+import (
+	"fmt"
+	"io"
+
+	"mc-proxy/protocol/types"
+)
+
+type ClientboundPluginMessage struct {
+	Channel types.String
+	Data    []byte // rest of the packet
+}
+
+func (p *ClientboundPluginMessage) ID() int32 { return 0x01 }
+
+func (p *ClientboundPluginMessage) Encode(w io.Writer) error {
+	if err := types.WriteString(p.Channel, w); err != nil {
+		return err
+	}
+	_, err := w.Write(p.Data)
+	return err
+}
+
+func (p *ClientboundPluginMessage) Decode(r io.Reader) error {
+	var err error
+	if p.Channel, err = types.ReadString(r); err != nil {
+		return err
+	}
+	p.Data, err = io.ReadAll(r)
+	return err
+}
+
+// ConfigurationDisconnect kicks the client during configuration
+type ConfigurationDisconnect struct {
+	Reason types.Chat
+}
+
+func (p *ConfigurationDisconnect) ID() int32 { return 0x02 }
+
+func (p *ConfigurationDisconnect) Encode(w io.Writer) error {
+	return p.EncodeVersion(w, LatestProtocol)
+}
+
+func (p *ConfigurationDisconnect) Decode(r io.Reader) error {
+	return p.DecodeVersion(r, LatestProtocol)
+}
+
+func (p *ConfigurationDisconnect) EncodeVersion(w io.Writer, version int32) error {
+	return WriteChat(p.Reason, version, w)
+}
+
+func (p *ConfigurationDisconnect) DecodeVersion(r io.Reader, version int32) error {
+	var err error
+	p.Reason, err = ReadChat(r, version)
+	return err
+}
+
+// FinishConfiguration asks the client to switch to the play state
+type FinishConfiguration struct{}
+
+func (p *FinishConfiguration) ID() int32 { return 0x03 }
+
+func (p *FinishConfiguration) Encode(w io.Writer) error { return nil }
+
+func (p *FinishConfiguration) Decode(r io.Reader) error { return nil }
+
+type RegistryEntry struct {
+	EntryID types.String
+	Data    types.RawNBT // nil if the client should use the data of a known pack
+}
+
+// RegistryData sends the entries of one registry. Before 1.20.5 all
+// registries were sent as a single NBT compound, which is not supported.
+type RegistryData struct {
+	RegistryID types.String
+	Entries    []RegistryEntry
+}
+
+func (p *RegistryData) ID() int32 { return 0x07 }
+
+func (p *RegistryData) Encode(w io.Writer) error {
+	if err := types.WriteString(p.RegistryID, w); err != nil {
+		return err
+	}
+	if err := types.WriteVarInt(types.VarInt(len(p.Entries)), w); err != nil {
+		return err
+	}
+	for _, entry := range p.Entries {
+		if err := types.WriteString(entry.EntryID, w); err != nil {
+			return err
+		}
+		if err := types.WriteBoolean(entry.Data != nil, w); err != nil {
+			return err
+		}
+		if entry.Data != nil {
+			if err := types.WriteRawNBT(entry.Data, w); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (p *RegistryData) Decode(r io.Reader) error {
+	var err error
+	if p.RegistryID, err = types.ReadString(r); err != nil {
+		return err
+	}
+
+	count, err := readCount(r)
+	if err != nil {
+		return err
+	}
+
+	p.Entries = nil
+	for i := 0; i < count; i++ {
+		var entry RegistryEntry
+		if entry.EntryID, err = types.ReadString(r); err != nil {
+			return err
+		}
+		hasData, err := types.ReadBoolean(r)
+		if err != nil {
+			return err
+		}
+		if hasData {
+			if entry.Data, err = types.ReadRawNBT(r); err != nil {
+				return err
+			}
+		}
+		p.Entries = append(p.Entries, entry)
+	}
+	return nil
+}
+
+type FeatureFlags struct {
+	Flags []types.String
+}
+
+func (p *FeatureFlags) ID() int32 { return 0x0C }
+
+func (p *FeatureFlags) Encode(w io.Writer) error {
+	return writeStrings(p.Flags, w)
+}
+
+func (p *FeatureFlags) Decode(r io.Reader) error {
+	var err error
+	p.Flags, err = readStrings(r)
+	return err
+}
+
+type Tag struct {
+	Name    types.String
+	Entries []types.VarInt // IDs in the tagged registry
+}
+
+type RegistryTags struct {
+	Registry types.String
+	Tags     []Tag
+}
+
+type UpdateTags struct {
+	Registries []RegistryTags
+}
+
+func (p *UpdateTags) ID() int32 { return 0x0D }
+
+func (p *UpdateTags) Encode(w io.Writer) error {
+	if err := types.WriteVarInt(types.VarInt(len(p.Registries)), w); err != nil {
+		return err
+	}
+	for _, registry := range p.Registries {
+		if err := types.WriteString(registry.Registry, w); err != nil {
+			return err
+		}
+		if err := types.WriteVarInt(types.VarInt(len(registry.Tags)), w); err != nil {
+			return err
+		}
+		for _, tag := range registry.Tags {
+			if err := types.WriteString(tag.Name, w); err != nil {
+				return err
+			}
+			if err := types.WriteVarInt(types.VarInt(len(tag.Entries)), w); err != nil {
+				return err
+			}
+			for _, entry := range tag.Entries {
+				if err := types.WriteVarInt(entry, w); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (p *UpdateTags) Decode(r io.Reader) error {
+	registryCount, err := readCount(r)
+	if err != nil {
+		return err
+	}
+
+	p.Registries = nil
+	for i := 0; i < registryCount; i++ {
+		var registry RegistryTags
+		if registry.Registry, err = types.ReadString(r); err != nil {
+			return err
+		}
+
+		tagCount, err := readCount(r)
+		if err != nil {
+			return err
+		}
+		for j := 0; j < tagCount; j++ {
+			var tag Tag
+			if tag.Name, err = types.ReadString(r); err != nil {
+				return err
+			}
+
+			entryCount, err := readCount(r)
+			if err != nil {
+				return err
+			}
+			for k := 0; k < entryCount; k++ {
+				entry, err := types.ReadVarInt(r)
+				if err != nil {
+					return err
+				}
+				tag.Entries = append(tag.Entries, entry)
+			}
+			registry.Tags = append(registry.Tags, tag)
+		}
+		p.Registries = append(p.Registries, registry)
+	}
+	return nil
+}
+
+// KnownPack identifies a data pack both sides may already have
+type KnownPack struct {
+	Namespace types.String
+	ID        types.String
+	Version   types.String
+}
+
+type ClientboundKnownPacks struct {
+	Packs []KnownPack
+}
+
+func (p *ClientboundKnownPacks) ID() int32 { return 0x0E }
+
+func (p *ClientboundKnownPacks) Encode(w io.Writer) error {
+	return writeKnownPacks(p.Packs, w)
+}
+
+func (p *ClientboundKnownPacks) Decode(r io.Reader) error {
+	var err error
+	p.Packs, err = readKnownPacks(r)
+	return err
+}
+
+type ServerboundPluginMessage struct {
+	Channel types.String
+	Data    []byte // rest of the packet
+}
+
+func (p *ServerboundPluginMessage) ID() int32 { return 0x02 }
+
+func (p *ServerboundPluginMessage) Encode(w io.Writer) error {
+	if err := types.WriteString(p.Channel, w); err != nil {
+		return err
+	}
+	_, err := w.Write(p.Data)
+	return err
+}
+
+func (p *ServerboundPluginMessage) Decode(r io.Reader) error {
+	var err error
+	if p.Channel, err = types.ReadString(r); err != nil {
+		return err
+	}
+	p.Data, err = io.ReadAll(r)
+	return err
+}
+
+// ClientInformation carries the client's settings. It is sent during
+// configuration and again in play whenever they change.
+type ClientInformation struct {
+	Locale              types.String `mc:"string,max=16"` // such as en_us
+	ViewDistance        types.Byte
+	ChatMode            types.VarInt // 0: enabled, 1: commands only, 2: hidden
+	ChatColors          types.Boolean
+	DisplayedSkinParts  types.UnsignedByte
+	MainHand            types.VarInt // 0: left, 1: right
+	EnableTextFiltering types.Boolean
+	AllowServerListings types.Boolean
+	ParticleStatus      types.VarInt `mc:"-"` // since 1.21.2, 0: all, 1: decreased, 2: minimal
+}
+
+func (p *ClientInformation) ID() int32 { return 0x00 }
+
+func (p *ClientInformation) Encode(w io.Writer) error {
+	return p.EncodeVersion(w, LatestProtocol)
+}
+
+func (p *ClientInformation) Decode(r io.Reader) error {
+	return p.DecodeVersion(r, LatestProtocol)
+}
+
+func (p *ClientInformation) EncodeVersion(w io.Writer, version int32) error {
+	if err := MarshalTo(w, p); err != nil {
+		return err
+	}
+	if version >= Protocol1_21_2 {
+		return types.WriteVarInt(p.ParticleStatus, w)
+	}
+	return nil
+}
+
+func (p *ClientInformation) DecodeVersion(r io.Reader, version int32) error {
+	if err := UnmarshalFrom(r, p); err != nil {
+		return err
+	}
+	p.ParticleStatus = 0
+	if version >= Protocol1_21_2 {
+		var err error
+		p.ParticleStatus, err = types.ReadVarInt(r)
+		return err
+	}
+	return nil
+}
+
+// AcknowledgeFinishConfiguration switches the connection to the play state
+type AcknowledgeFinishConfiguration struct{}
+
+func (p *AcknowledgeFinishConfiguration) ID() int32 { return 0x03 }
+
+func (p *AcknowledgeFinishConfiguration) Encode(w io.Writer) error { return nil }
+
+func (p *AcknowledgeFinishConfiguration) Decode(r io.Reader) error { return nil }
+
+type ServerboundKnownPacks struct {
+	Packs []KnownPack
+}
+
+func (p *ServerboundKnownPacks) ID() int32 { return 0x07 }
+
+func (p *ServerboundKnownPacks) Encode(w io.Writer) error {
+	return writeKnownPacks(p.Packs, w)
+}
+
+func (p *ServerboundKnownPacks) Decode(r io.Reader) error {
+	var err error
+	p.Packs, err = readKnownPacks(r)
+	return err
+}
+
+func writeKnownPacks(packs []KnownPack, w io.Writer) error {
+	if err := types.WriteVarInt(types.VarInt(len(packs)), w); err != nil {
+		return err
+	}
+	for _, pack := range packs {
+		if err := types.WriteString(pack.Namespace, w); err != nil {
+			return err
+		}
+		if err := types.WriteString(pack.ID, w); err != nil {
+			return err
+		}
+		if err := types.WriteString(pack.Version, w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readKnownPacks(r io.Reader) ([]KnownPack, error) {
+	count, err := readCount(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var packs []KnownPack
+	for i := 0; i < count; i++ {
+		var pack KnownPack
+		if pack.Namespace, err = types.ReadString(r); err != nil {
+			return nil, err
+		}
+		if pack.ID, err = types.ReadString(r); err != nil {
+			return nil, err
+		}
+		if pack.Version, err = types.ReadString(r); err != nil {
+			return nil, err
+		}
+		packs = append(packs, pack)
+	}
+	return packs, nil
+}
+
+func writeStrings(strs []types.String, w io.Writer) error {
+	if err := types.WriteVarInt(types.VarInt(len(strs)), w); err != nil {
+		return err
+	}
+	for _, str := range strs {
+		if err := types.WriteString(str, w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readStrings(r io.Reader) ([]types.String, error) {
+	count, err := readCount(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var strs []types.String
+	for i := 0; i < count; i++ {
+		str, err := types.ReadString(r)
+		if err != nil {
+			return nil, err
+		}
+		strs = append(strs, str)
+	}
+	return strs, nil
+}
+
+// readCount reads the VarInt length prefix of an array
+func readCount(r io.Reader) (int, error) {
+	count, err := types.ReadVarInt(r)
+	if err != nil {
+		return 0, err
+	}
+	if count < 0 {
+		return 0, fmt.Errorf("array length is negative")
+	}
+	return int(count), nil
+}
+
+func init() {
+	RegisterVersionedPacket(StateConfiguration, Clientbound, VersionIDs{Protocol1_20_2: 0x00, Protocol1_20_5: 0x01}, func() Packet { return &ClientboundPluginMessage{} })
+	RegisterVersionedPacket(StateConfiguration, Clientbound, VersionIDs{Protocol1_20_2: 0x01, Protocol1_20_5: 0x02}, func() Packet { return &ConfigurationDisconnect{} })
+	RegisterVersionedPacket(StateConfiguration, Clientbound, VersionIDs{Protocol1_20_2: 0x02, Protocol1_20_5: 0x03}, func() Packet { return &FinishConfiguration{} })
+	RegisterVersionedPacket(StateConfiguration, Clientbound, Since(Protocol1_20_5, 0x07), func() Packet { return &RegistryData{} })
+	RegisterVersionedPacket(StateConfiguration, Clientbound, VersionIDs{Protocol1_20_2: 0x07, Protocol1_20_3: 0x08, Protocol1_20_5: 0x0C}, func() Packet { return &FeatureFlags{} })
+	RegisterVersionedPacket(StateConfiguration, Clientbound, VersionIDs{Protocol1_20_2: 0x08, Protocol1_20_3: 0x09, Protocol1_20_5: 0x0D}, func() Packet { return &UpdateTags{} })
+	RegisterVersionedPacket(StateConfiguration, Clientbound, Since(Protocol1_20_5, 0x0E), func() Packet { return &ClientboundKnownPacks{} })
+	RegisterVersionedPacket(StateConfiguration, Serverbound, Since(Protocol1_20_2, 0x00), func() Packet { return &ClientInformation{} })
+	RegisterVersionedPacket(StateConfiguration, Serverbound, VersionIDs{Protocol1_20_2: 0x01, Protocol1_20_5: 0x02}, func() Packet { return &ServerboundPluginMessage{} })
+	RegisterVersionedPacket(StateConfiguration, Serverbound, VersionIDs{Protocol1_20_2: 0x02, Protocol1_20_5: 0x03}, func() Packet { return &AcknowledgeFinishConfiguration{} })
+	RegisterVersionedPacket(StateConfiguration, Serverbound, Since(Protocol1_20_5, 0x07), func() Packet { return &ServerboundKnownPacks{} })
+}
@@ -0,0 +1,55 @@
+package packet
+
+import (
+	"bytes"
+	"testing"
+
+	"mc-proxy/protocol/types"
+)
+
+func TestClientInformationVersions(t *testing.T) {
+	sent := ClientInformation{
+		Locale:              types.String{Value: "de_at"},
+		ViewDistance:        12,
+		ChatMode:            1,
+		ChatColors:          true,
+		DisplayedSkinParts:  0x7f,
+		MainHand:            1,
+		AllowServerListings: true,
+		ParticleStatus:      2,
+	}
+
+	tests := []struct {
+		version        int32
+		length         int
+		particleStatus types.VarInt
+	}{
+		{Protocol1_21, 13, 0},
+		{Protocol1_21_2, 14, 2},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := sent.EncodeVersion(&buf, tt.version); err != nil {
+			t.Fatal(err)
+		}
+		if buf.Len() != tt.length {
+			t.Errorf("protocol %d: encoded %d bytes, want %d", tt.version, buf.Len(), tt.length)
+		}
+
+		received := ClientInformation{ParticleStatus: 1}
+		if err := received.DecodeVersion(&buf, tt.version); err != nil {
+			t.Fatal(err)
+		}
+		want := sent
+		want.ParticleStatus = tt.particleStatus
+		if received != want || buf.Len() != 0 {
+			t.Errorf("protocol %d: decoded %+v with %d bytes left, want %+v", tt.version, received, buf.Len(), want)
+		}
+	}
+
+	long := sent
+	long.Locale = types.String{Value: "a_locale_longer_than_16"}
+	if err := long.Encode(&bytes.Buffer{}); err == nil {
+		t.Error("Encode of a locale over 16 characters succeeded")
+	}
+}
@@ -0,0 +1,204 @@
+package packet
+
+import (
+	"net"
+	"sync"
+
+	"mc-proxy/protocol/types"
+)
+
+// Conn reads and writes packets over a network connection. It keeps track of
+// the connection state and protocol version used to look up packets.
+type Conn struct {
+	conn      net.Conn
+	reader    *FrameReader
+	writer    *FrameWriter
+	inbound   Direction
+	registry  *Registry
+	mutex     sync.RWMutex
+	writeLock sync.Mutex
+	state     State
+	version   int32
+}
+
+// NewConn wraps a network connection. Packets read from it are looked up as
+// inbound packets, packets written to it are sent in the opposite direction.
+func NewConn(conn net.Conn, inbound Direction) *Conn {
+	return &Conn{
+		conn:     conn,
+		reader:   NewFrameReader(conn),
+		writer:   NewFrameWriter(conn),
+		inbound:  inbound,
+		registry: DefaultRegistry,
+		state:    StateHandshake,
+		version:  AnyVersion,
+	}
+}
+
+// Dial connects to a server and returns a Conn reading clientbound packets
+func Dial(addr string) (*Conn, error) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return NewConn(conn, Clientbound), nil
+}
+
+// SetRegistry changes the registry used to decode packets
+func (c *Conn) SetRegistry(registry *Registry) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.registry = registry
+}
+
+// State returns the current connection state
+func (c *Conn) State() State {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.state
+}
+
+// SetState changes the connection state
+func (c *Conn) SetState(state State) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.state = state
+}
+
+// Version returns the protocol version of the connection
+func (c *Conn) Version() int32 {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.version
+}
+
+// SetVersion changes the protocol version of the connection
+func (c *Conn) SetVersion(version int32) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.version = version
+}
+
+// SetCompressionThreshold switches both directions of the connection to the
+// compressed frame format. A negative threshold disables compression.
+func (c *Conn) SetCompressionThreshold(threshold int) {
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+
+	c.reader.SetCompressionThreshold(threshold)
+	c.writer.SetCompressionThreshold(threshold)
+}
+
+// CompressionThreshold returns the compression threshold of the connection
+func (c *Conn) CompressionThreshold() int {
+	return c.reader.CompressionThreshold()
+}
+
+// EnableEncryption switches both directions of the connection to AES/CFB8
+// using the shared secret as key and IV. It must be called between frames,
+// while no other goroutine is reading from the connection.
+func (c *Conn) EnableEncryption(sharedSecret []byte) error {
+	encrypt, decrypt, err := newStreams(sharedSecret)
+	if err != nil {
+		return err
+	}
+
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+
+	c.reader.EnableEncryption(decrypt)
+	return c.writer.EnableEncryption(encrypt)
+}
+
+// ReadFrame reads a raw frame consisting of the packet ID and body
+func (c *Conn) ReadFrame() ([]byte, error) {
+	return c.reader.ReadFrame()
+}
+
+// ReadFrameTo reads a raw frame into the storage of buf. The frame is only
+// valid until buf is used again.
+func (c *Conn) ReadFrameTo(buf *types.Buffer) ([]byte, error) {
+	return c.reader.ReadFrameTo(buf)
+}
+
+// WriteFrame writes a raw frame consisting of the packet ID and body
+func (c *Conn) WriteFrame(frame []byte) error {
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+	return c.writer.WriteFrame(frame)
+}
+
+// ReadPacket reads a frame and decodes it using the current state and
+// version. Receiving Set Compression enables compression on the connection.
+func (c *Conn) ReadPacket() (Packet, error) {
+	frame, err := c.ReadFrame()
+	if err != nil {
+		return nil, err
+	}
+
+	c.mutex.RLock()
+	registry, state, version := c.registry, c.state, c.version
+	c.mutex.RUnlock()
+
+	p, err := registry.Decode(state, c.inbound, version, frame)
+	if err != nil {
+		return nil, err
+	}
+
+	if setCompression, ok := p.(*SetCompression); ok {
+		c.SetCompressionThreshold(int(setCompression.Threshold))
+	}
+	return p, nil
+}
+
+// WritePacket encodes a packet and writes it as a single frame. Sending Set
+// Compression enables compression on the connection.
+func (c *Conn) WritePacket(p Packet) error {
+	c.mutex.RLock()
+	registry, state, version := c.registry, c.state, c.version
+	c.mutex.RUnlock()
+
+	frame, err := registry.Encode(state, c.outbound(), version, p)
+	if err != nil {
+		return err
+	}
+
+	setCompression, ok := p.(*SetCompression)
+	if !ok {
+		return c.WriteFrame(frame)
+	}
+
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+
+	// The peer may answer with compressed frames as soon as it receives the packet
+	c.reader.SetCompressionThreshold(int(setCompression.Threshold))
+	if err := c.writer.WriteFrame(frame); err != nil {
+		return err
+	}
+	c.writer.SetCompressionThreshold(int(setCompression.Threshold))
+	return nil
+}
+
+// Inbound returns the direction of packets read from the connection
+func (c *Conn) Inbound() Direction {
+	return c.inbound
+}
+
+// outbound returns the direction of packets written to the connection
+func (c *Conn) outbound() Direction {
+	if c.inbound == Serverbound {
+		return Clientbound
+	}
+	return Serverbound
+}
+
+// NetConn returns the underlying network connection
+func (c *Conn) NetConn() net.Conn {
+	return c.conn
+}
+
+// Close closes the underlying network connection
+func (c *Conn) Close() error {
+	return c.conn.Close()
+}
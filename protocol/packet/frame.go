@@ -0,0 +1,300 @@
+package packet
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/zlib"
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/adler32"
+	"io"
+	"sync/atomic"
+
+	"mc-proxy/protocol/types"
+)
+
+const (
+	// MaxFrameSize is the largest frame the vanilla server accepts (2 MiB - 1)
+	MaxFrameSize = 1<<21 - 1
+	// MaxLengthPrefixSize is the maximum number of bytes in a frame length prefix
+	MaxLengthPrefixSize = 3
+	// MaxUncompressedSize is the largest size a compressed frame may inflate to (8 MiB)
+	MaxUncompressedSize = 1 << 23
+	// CompressionDisabled is the threshold used before Set Compression is received
+	CompressionDisabled = -1
+)
+
+var (
+	ErrFrameTooLarge    = errors.New("frame exceeds maximum size")
+	ErrLengthPrefixSize = errors.New("frame length prefix is too long")
+	ErrNegativeLength   = errors.New("frame length is negative")
+	ErrBadlyCompressed  = errors.New("badly compressed frame")
+)
+
+type byteReader interface {
+	io.Reader
+	io.ByteReader
+}
+
+// FrameReader reads length-prefixed frames from a buffered stream
+type FrameReader struct {
+	buffered   *bufio.Reader
+	reader     byteReader
+	maxSize    int
+	threshold  atomic.Int32
+	compressed bytes.Reader
+	inflater   io.ReadCloser
+	scratch    [1]byte
+}
+
+// NewFrameReader creates a FrameReader enforcing MaxFrameSize
+func NewFrameReader(r io.Reader) *FrameReader {
+	buffered := bufio.NewReader(r)
+	fr := &FrameReader{
+		buffered: buffered,
+		reader:   buffered,
+		maxSize:  MaxFrameSize,
+	}
+	fr.threshold.Store(CompressionDisabled)
+	return fr
+}
+
+// EnableEncryption decrypts everything read after the current frame with the
+// given stream. It must not be called while a frame is being read.
+func (fr *FrameReader) EnableEncryption(stream cipher.Stream) {
+	fr.reader = &decryptReader{reader: fr.buffered, stream: stream}
+}
+
+// SetCompressionThreshold switches the reader to the compressed frame format.
+// A negative threshold switches back to uncompressed frames.
+func (fr *FrameReader) SetCompressionThreshold(threshold int) {
+	fr.threshold.Store(int32(threshold))
+}
+
+// CompressionThreshold returns the current compression threshold
+func (fr *FrameReader) CompressionThreshold() int {
+	return int(fr.threshold.Load())
+}
+
+// SetMaxSize changes the largest frame the reader accepts
+func (fr *FrameReader) SetMaxSize(size int) {
+	fr.maxSize = size
+}
+
+// ReadFrame reads a single frame without its length prefix. It returns io.EOF
+// if the stream ended cleanly before a new frame started.
+func (fr *FrameReader) ReadFrame() ([]byte, error) {
+	var buf types.Buffer
+	return fr.ReadFrameTo(&buf)
+}
+
+// ReadFrameTo reads a frame like ReadFrame into the storage of buf, which is
+// reset first. The frame is only valid until buf is used again, which lets
+// callers forwarding frames reuse a single buffer.
+func (fr *FrameReader) ReadFrameTo(buf *types.Buffer) ([]byte, error) {
+	length, err := fr.readLength()
+	if err != nil {
+		return nil, err
+	}
+
+	buf.Reset()
+	frame := buf.Extend(length)
+	if _, err := io.ReadFull(fr.reader, frame); err != nil {
+		return nil, fmt.Errorf("failed to read frame: %w", err)
+	}
+
+	threshold := fr.CompressionThreshold()
+	if threshold < 0 {
+		return frame, nil
+	}
+	return fr.decompress(buf, threshold)
+}
+
+// decompress unpacks a frame in the compressed format, which prefixes the
+// packet with its uncompressed length or zero if it was sent uncompressed.
+// The packet is inflated into buf after the compressed frame.
+func (fr *FrameReader) decompress(buf *types.Buffer, threshold int) ([]byte, error) {
+	length := buf.Len()
+	dataLength, err := buf.GetVarInt()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read data length: %w", err)
+	}
+	if dataLength == 0 {
+		return buf.Bytes(), nil
+	}
+
+	if dataLength < types.VarInt(threshold) {
+		return nil, fmt.Errorf("%w: data length %d is below threshold %d", ErrBadlyCompressed, dataLength, threshold)
+	}
+	if dataLength > MaxUncompressedSize {
+		return nil, fmt.Errorf("%w: %d > %d", ErrFrameTooLarge, dataLength, MaxUncompressedSize)
+	}
+
+	// Extending may move the storage, so the compressed part is sliced after
+	headerSize := length - buf.Len()
+	data := buf.Extend(int(dataLength))
+	compressed := buf.Bytes()[:length-headerSize]
+
+	// The zlib header and checksum are handled here, as zlib.Reader
+	// allocates a new checksum on every Reset
+	if !isZlibHeader(compressed) {
+		return nil, fmt.Errorf("%w: invalid zlib header", ErrBadlyCompressed)
+	}
+	fr.compressed.Reset(compressed[2:])
+	if fr.inflater == nil {
+		fr.inflater = flate.NewReader(&fr.compressed)
+	} else if err := fr.inflater.(flate.Resetter).Reset(&fr.compressed, nil); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBadlyCompressed, err)
+	}
+
+	if _, err := io.ReadFull(fr.inflater, data); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBadlyCompressed, err)
+	}
+	if n, _ := fr.inflater.Read(fr.scratch[:]); n != 0 {
+		return nil, fmt.Errorf("%w: data exceeds declared length %d", ErrBadlyCompressed, dataLength)
+	}
+	checksum := compressed[len(compressed)-fr.compressed.Len():]
+	if len(checksum) != 4 || binary.BigEndian.Uint32(checksum) != adler32.Checksum(data) {
+		return nil, fmt.Errorf("%w: checksum mismatch", ErrBadlyCompressed)
+	}
+	return data, nil
+}
+
+// isZlibHeader reports whether data starts with the header of a zlib stream
+// using deflate without a preset dictionary
+func isZlibHeader(data []byte) bool {
+	if len(data) < 2 {
+		return false
+	}
+	return data[0]&0x0f == 8 && data[1]&0x20 == 0 && (uint16(data[0])<<8|uint16(data[1]))%31 == 0
+}
+
+func (fr *FrameReader) readLength() (int, error) {
+	var value uint32
+	for i := 0; i < MaxLengthPrefixSize; i++ {
+		b, err := fr.reader.ReadByte()
+		if err != nil {
+			if i == 0 && err == io.EOF {
+				return 0, io.EOF
+			}
+			return 0, fmt.Errorf("failed to read frame length: %w", err)
+		}
+
+		value |= uint32(b&0x7F) << (7 * i)
+		if b&0x80 == 0 {
+			length := int(int32(value))
+			if length < 0 {
+				return 0, ErrNegativeLength
+			}
+			if length > fr.maxSize {
+				return 0, fmt.Errorf("%w: %d > %d", ErrFrameTooLarge, length, fr.maxSize)
+			}
+			return length, nil
+		}
+	}
+	return 0, ErrLengthPrefixSize
+}
+
+// FrameWriter writes length-prefixed frames to a buffered stream
+type FrameWriter struct {
+	dst        io.Writer
+	writer     *bufio.Writer
+	maxSize    int
+	threshold  int
+	compressed bytes.Buffer
+	zlibWriter *zlib.Writer
+	header     [types.MaxVarIntSize]byte // data length of compressed frames
+	prefix     [types.MaxVarIntSize]byte // frame length
+}
+
+// NewFrameWriter creates a FrameWriter enforcing MaxFrameSize
+func NewFrameWriter(w io.Writer) *FrameWriter {
+	return &FrameWriter{
+		dst:       w,
+		writer:    bufio.NewWriter(w),
+		maxSize:   MaxFrameSize,
+		threshold: CompressionDisabled,
+	}
+}
+
+// SetCompressionThreshold switches the writer to the compressed frame format.
+// Frames at least threshold bytes long are compressed, a negative threshold
+// switches back to uncompressed frames.
+func (fw *FrameWriter) SetCompressionThreshold(threshold int) {
+	fw.threshold = threshold
+}
+
+// CompressionThreshold returns the current compression threshold
+func (fw *FrameWriter) CompressionThreshold() int {
+	return fw.threshold
+}
+
+// EnableEncryption encrypts every frame written from now on with the given stream
+func (fw *FrameWriter) EnableEncryption(stream cipher.Stream) error {
+	if err := fw.writer.Flush(); err != nil {
+		return err
+	}
+	fw.writer.Reset(cipher.StreamWriter{S: stream, W: fw.dst})
+	return nil
+}
+
+// SetMaxSize changes the largest frame the writer produces
+func (fw *FrameWriter) SetMaxSize(size int) {
+	fw.maxSize = size
+}
+
+// WriteFrame writes a single frame with its length prefix and flushes it
+func (fw *FrameWriter) WriteFrame(frame []byte) error {
+	if fw.threshold < 0 {
+		return fw.writeRaw(nil, frame)
+	}
+
+	if len(frame) < fw.threshold {
+		// Frames below the threshold are sent with a zero data length
+		fw.header[0] = 0x00
+		return fw.writeRaw(fw.header[:1], frame)
+	}
+
+	if len(frame) > MaxUncompressedSize {
+		return fmt.Errorf("%w: %d > %d", ErrFrameTooLarge, len(frame), MaxUncompressedSize)
+	}
+
+	fw.compressed.Reset()
+	if fw.zlibWriter == nil {
+		fw.zlibWriter = zlib.NewWriter(&fw.compressed)
+	} else {
+		fw.zlibWriter.Reset(&fw.compressed)
+	}
+	if _, err := fw.zlibWriter.Write(frame); err != nil {
+		return fmt.Errorf("failed to compress frame: %w", err)
+	}
+	if err := fw.zlibWriter.Close(); err != nil {
+		return fmt.Errorf("failed to compress frame: %w", err)
+	}
+
+	n := types.PutVarInt(fw.header[:], types.VarInt(len(frame)))
+	return fw.writeRaw(fw.header[:n], fw.compressed.Bytes())
+}
+
+// writeRaw writes a length prefix covering header and body followed by both
+func (fw *FrameWriter) writeRaw(header, body []byte) error {
+	length := len(header) + len(body)
+	if length > fw.maxSize {
+		return fmt.Errorf("%w: %d > %d", ErrFrameTooLarge, length, fw.maxSize)
+	}
+
+	n := types.PutVarInt(fw.prefix[:], types.VarInt(length))
+	if _, err := fw.writer.Write(fw.prefix[:n]); err != nil {
+		return fmt.Errorf("failed to write frame length: %w", err)
+	}
+	if _, err := fw.writer.Write(header); err != nil {
+		return fmt.Errorf("failed to write frame: %w", err)
+	}
+	if _, err := fw.writer.Write(body); err != nil {
+		return fmt.Errorf("failed to write frame: %w", err)
+	}
+	return fw.writer.Flush()
+}
@@ -0,0 +1,148 @@
+package packet
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"io"
+	"testing"
+
+	"mc-proxy/protocol/types"
+)
+
+// repeatReader returns data over and over
+type repeatReader struct {
+	data []byte
+	off  int
+}
+
+func (r *repeatReader) Read(p []byte) (int, error) {
+	n := copy(p, r.data[r.off:])
+	r.off = (r.off + n) % len(r.data)
+	return n, nil
+}
+
+// encodeFrames writes frames with the given compression threshold
+func encodeFrames(t testing.TB, threshold int, frames ...[]byte) []byte {
+	var buf bytes.Buffer
+	fw := NewFrameWriter(&buf)
+	fw.SetCompressionThreshold(threshold)
+	for _, frame := range frames {
+		if err := fw.WriteFrame(frame); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return buf.Bytes()
+}
+
+func TestFrameRoundTrip(t *testing.T) {
+	frames := [][]byte{{0x00}, bytes.Repeat([]byte("abc"), 100), make([]byte, 300)}
+	for _, threshold := range []int{CompressionDisabled, 0, 256} {
+		fr := NewFrameReader(bytes.NewReader(encodeFrames(t, threshold, frames...)))
+		fr.SetCompressionThreshold(threshold)
+
+		var buf types.Buffer
+		for i, want := range frames {
+			got, err := fr.ReadFrameTo(&buf)
+			if err != nil {
+				t.Fatalf("threshold %d, frame %d: %v", threshold, i, err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("threshold %d, frame %d = % x, want % x", threshold, i, got, want)
+			}
+		}
+		if _, err := fr.ReadFrame(); err != io.EOF {
+			t.Errorf("threshold %d: read after the last frame = %v, want io.EOF", threshold, err)
+		}
+	}
+}
+
+func TestFrameErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      []byte
+		threshold int
+		want      error
+	}{
+		{"prefix too long", []byte{0x80, 0x80, 0x80, 0x01}, CompressionDisabled, ErrLengthPrefixSize},
+		{"inflates too large", []byte{0x05, 0x80, 0x80, 0x80, 0x08, 0x00}, 256, ErrFrameTooLarge},
+		{"truncated", []byte{0x05, 0x01}, CompressionDisabled, io.ErrUnexpectedEOF},
+		{"below threshold", []byte{0x02, 0x10, 0x00}, 256, ErrBadlyCompressed},
+		{"not zlib", []byte{0x04, 0x80, 0x02, 0x00, 0x00}, 256, ErrBadlyCompressed},
+		{"data after the zlib stream", compressedFrame(t, []byte("hello"), 0xff), 0, ErrBadlyCompressed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fr := NewFrameReader(bytes.NewReader(tt.data))
+			fr.SetCompressionThreshold(tt.threshold)
+			if _, err := fr.ReadFrame(); !errors.Is(err, tt.want) {
+				t.Errorf("ReadFrame = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+// compressedFrame builds a compressed frame of a short packet by hand,
+// followed by trailing bytes inside the frame
+func compressedFrame(t testing.TB, packet []byte, trailing ...byte) []byte {
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	if _, err := zw.Write(packet); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	body := append([]byte{byte(len(packet))}, compressed.Bytes()...)
+	body = append(body, trailing...)
+	return append([]byte{byte(len(body))}, body...)
+}
+
+func TestFrameChecksum(t *testing.T) {
+	data := encodeFrames(t, 0, bytes.Repeat([]byte("abc"), 100))
+	data[len(data)-1] ^= 0xff
+
+	fr := NewFrameReader(bytes.NewReader(data))
+	fr.SetCompressionThreshold(0)
+	if _, err := fr.ReadFrame(); !errors.Is(err, ErrBadlyCompressed) {
+		t.Errorf("ReadFrame with a wrong checksum = %v, want ErrBadlyCompressed", err)
+	}
+
+	// The same frame without trailing bytes is accepted
+	fr = NewFrameReader(bytes.NewReader(compressedFrame(t, []byte("hello"))))
+	fr.SetCompressionThreshold(0)
+	if got, err := fr.ReadFrame(); err != nil || string(got) != "hello" {
+		t.Errorf("ReadFrame = %q, %v, want hello", got, err)
+	}
+}
+
+func benchmarkReadFrame(b *testing.B, threshold int, read func(fr *FrameReader, buf *types.Buffer) ([]byte, error)) {
+	frame := bytes.Repeat([]byte("chunk data "), 100)
+	data := encodeFrames(b, threshold, frame)
+	fr := NewFrameReader(&repeatReader{data: data})
+	fr.SetCompressionThreshold(threshold)
+	var buf types.Buffer
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(frame)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := read(fr, &buf); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkReadFrameTo reuses one buffer for all frames and should not
+// allocate, unlike BenchmarkReadFrame
+func BenchmarkReadFrameTo(b *testing.B) {
+	readTo := func(fr *FrameReader, buf *types.Buffer) ([]byte, error) { return fr.ReadFrameTo(buf) }
+	b.Run("Uncompressed", func(b *testing.B) { benchmarkReadFrame(b, CompressionDisabled, readTo) })
+	b.Run("Compressed", func(b *testing.B) { benchmarkReadFrame(b, 256, readTo) })
+}
+
+func BenchmarkReadFrame(b *testing.B) {
+	read := func(fr *FrameReader, _ *types.Buffer) ([]byte, error) { return fr.ReadFrame() }
+	b.Run("Uncompressed", func(b *testing.B) { benchmarkReadFrame(b, CompressionDisabled, read) })
+	b.Run("Compressed", func(b *testing.B) { benchmarkReadFrame(b, 256, read) })
+}
@@ -1,11 +1,14 @@
 package packet
 
 import (
-	"encoding/binary"
 	"io"
+
 	"mc-proxy/protocol/types"
 )
 
+// MaxServerAddressLength is the maximum length of the address in a handshake
+const MaxServerAddressLength = 255
+
 type Handshake struct {
 	ProtocolVersion types.VarInt
 	ServerAddress   types.String
@@ -16,12 +19,11 @@ type Handshake struct {
 func (p *Handshake) ID() int32 { return 0x00 }
 
 func (p *Handshake) Encode(w io.Writer) error {
-
 	if err := types.WriteVarInt(p.ProtocolVersion, w); err != nil {
 		return err
 	}
 
-	if err := types.WriteString(p.ServerAddress, w); err != nil {
+	if err := types.WriteStringMax(p.ServerAddress, w, MaxServerAddressLength); err != nil {
 		return err
 	}
 
@@ -37,16 +39,16 @@ func (p *Handshake) Decode(r io.Reader) error {
 	if p.ProtocolVersion, err = types.ReadVarInt(r); err != nil {
 		return err
 	}
-	if p.ServerAddress, err = ReadString(r); err != nil {
+	if p.ServerAddress, err = types.ReadStringMax(r, MaxServerAddressLength); err != nil {
 		return err
 	}
-	if err = binary.Read(r, binary.BigEndian, &p.ServerPort); err != nil {
+	if p.ServerPort, err = types.ReadUnsignedShort(r); err != nil {
 		return err
 	}
-	p.NextState, err = ReadVarInt(r)
+	p.NextState, err = types.ReadVarInt(r)
 	return err
 }
 
 func init() {
-	RegisterPacket(0x00, func() Packet { return &Handshake{} })
+	RegisterPacket(StateHandshake, Serverbound, func() Packet { return &Handshake{} })
 }
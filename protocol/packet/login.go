@@ -0,0 +1,357 @@
+package packet
+
+import (
+	"io"
+
+	"mc-proxy/protocol/types"
+)
+
+// MaxUsernameLength is the maximum length of a player name
+const MaxUsernameLength = 16
+
+// Limits of other strings during login
+const (
+	maxServerIDLength          = 20
+	maxPropertyNameLength      = 64
+	maxPropertySignatureLength = 1024
+)
+
+type LoginDisconnect struct {
+	Reason types.Chat
+}
+
+func (p *LoginDisconnect) ID() int32 { return 0x00 }
+
+func (p *LoginDisconnect) Encode(w io.Writer) error {
+	return types.WriteChat(p.Reason, w)
+}
+
+func (p *LoginDisconnect) Decode(r io.Reader) error {
+	var err error
+	p.Reason, err = types.ReadChat(r)
+	return err
+}
+
+type LoginStart struct {
+	Name       types.String
+	PlayerUUID types.UUID
+}
+
+func (p *LoginStart) ID() int32 { return 0x00 }
+
+func (p *LoginStart) Encode(w io.Writer) error {
+	return p.EncodeVersion(w, LatestProtocol)
+}
+
+func (p *LoginStart) Decode(r io.Reader) error {
+	return p.DecodeVersion(r, LatestProtocol)
+}
+
+func (p *LoginStart) EncodeVersion(w io.Writer, version int32) error {
+	if err := types.WriteStringMax(p.Name, w, MaxUsernameLength); err != nil {
+		return err
+	}
+	if version < Protocol1_20_2 {
+		// The UUID used to be optional
+		if err := types.WriteBoolean(true, w); err != nil {
+			return err
+		}
+	}
+	return types.WriteUUID(p.PlayerUUID, w)
+}
+
+func (p *LoginStart) DecodeVersion(r io.Reader, version int32) error {
+	var err error
+	if p.Name, err = types.ReadStringMax(r, MaxUsernameLength); err != nil {
+		return err
+	}
+	if version < Protocol1_20_2 {
+		hasUUID, err := types.ReadBoolean(r)
+		if err != nil || !hasUUID {
+			p.PlayerUUID = types.UUID{}
+			return err
+		}
+	}
+	p.PlayerUUID, err = types.ReadUUID(r)
+	return err
+}
+
+type EncryptionRequest struct {
+	ServerID           types.String // always empty since 1.7
+	PublicKey          types.ByteArray
+	VerifyToken        types.ByteArray
+	ShouldAuthenticate types.Boolean // since 1.20.5, older clients always authenticate
+}
+
+func (p *EncryptionRequest) ID() int32 { return 0x01 }
+
+func (p *EncryptionRequest) Encode(w io.Writer) error {
+	return p.EncodeVersion(w, LatestProtocol)
+}
+
+func (p *EncryptionRequest) Decode(r io.Reader) error {
+	return p.DecodeVersion(r, LatestProtocol)
+}
+
+func (p *EncryptionRequest) EncodeVersion(w io.Writer, version int32) error {
+	if err := types.WriteStringMax(p.ServerID, w, maxServerIDLength); err != nil {
+		return err
+	}
+	if err := types.WriteByteArray(p.PublicKey, w); err != nil {
+		return err
+	}
+	if err := types.WriteByteArray(p.VerifyToken, w); err != nil {
+		return err
+	}
+	if version < Protocol1_20_5 {
+		return nil
+	}
+	return types.WriteBoolean(p.ShouldAuthenticate, w)
+}
+
+func (p *EncryptionRequest) DecodeVersion(r io.Reader, version int32) error {
+	var err error
+	if p.ServerID, err = types.ReadStringMax(r, maxServerIDLength); err != nil {
+		return err
+	}
+	if p.PublicKey, err = types.ReadByteArray(r); err != nil {
+		return err
+	}
+	if p.VerifyToken, err = types.ReadByteArray(r); err != nil {
+		return err
+	}
+	if version < Protocol1_20_5 {
+		p.ShouldAuthenticate = true
+		return nil
+	}
+	p.ShouldAuthenticate, err = types.ReadBoolean(r)
+	return err
+}
+
+// maxEncryptedLength bounds the encrypted shared secret and verify token a
+// client may send. Encrypted with the 1024-bit key of the proxy or server they
+// are 128 bytes long.
+const maxEncryptedLength = 256
+
+type EncryptionResponse struct {
+	SharedSecret types.ByteArray // encrypted with the server's public key
+	VerifyToken  types.ByteArray // encrypted with the server's public key
+}
+
+func (p *EncryptionResponse) ID() int32 { return 0x01 }
+
+func (p *EncryptionResponse) Encode(w io.Writer) error {
+	if err := types.WriteByteArray(p.SharedSecret, w); err != nil {
+		return err
+	}
+	return types.WriteByteArray(p.VerifyToken, w)
+}
+
+func (p *EncryptionResponse) Decode(r io.Reader) error {
+	var err error
+	if p.SharedSecret, err = types.ReadByteArrayMax(r, maxEncryptedLength); err != nil {
+		return err
+	}
+	p.VerifyToken, err = types.ReadByteArrayMax(r, maxEncryptedLength)
+	return err
+}
+
+// LoginProperty is a profile property such as the skin textures
+type LoginProperty struct {
+	Name      types.String
+	Value     types.String
+	Signature *types.String // nil if the property is unsigned
+}
+
+type LoginSuccess struct {
+	UUID                types.UUID
+	Username            types.String
+	Properties          []LoginProperty
+	StrictErrorHandling types.Boolean // only sent by 1.20.5 to 1.21.1
+}
+
+func (p *LoginSuccess) ID() int32 { return 0x02 }
+
+func (p *LoginSuccess) Encode(w io.Writer) error {
+	return p.EncodeVersion(w, LatestProtocol)
+}
+
+func (p *LoginSuccess) Decode(r io.Reader) error {
+	return p.DecodeVersion(r, LatestProtocol)
+}
+
+func (p *LoginSuccess) EncodeVersion(w io.Writer, version int32) error {
+	if err := types.WriteUUID(p.UUID, w); err != nil {
+		return err
+	}
+	if err := types.WriteStringMax(p.Username, w, MaxUsernameLength); err != nil {
+		return err
+	}
+	if err := types.WriteVarInt(types.VarInt(len(p.Properties)), w); err != nil {
+		return err
+	}
+	for _, property := range p.Properties {
+		if err := types.WriteStringMax(property.Name, w, maxPropertyNameLength); err != nil {
+			return err
+		}
+		if err := types.WriteString(property.Value, w); err != nil {
+			return err
+		}
+		if err := types.WriteBoolean(property.Signature != nil, w); err != nil {
+			return err
+		}
+		if property.Signature != nil {
+			if err := types.WriteStringMax(*property.Signature, w, maxPropertySignatureLength); err != nil {
+				return err
+			}
+		}
+	}
+	if version >= Protocol1_20_5 && version < Protocol1_21_2 {
+		return types.WriteBoolean(p.StrictErrorHandling, w)
+	}
+	return nil
+}
+
+func (p *LoginSuccess) DecodeVersion(r io.Reader, version int32) error {
+	var err error
+	if p.UUID, err = types.ReadUUID(r); err != nil {
+		return err
+	}
+	if p.Username, err = types.ReadStringMax(r, MaxUsernameLength); err != nil {
+		return err
+	}
+
+	count, err := readCount(r)
+	if err != nil {
+		return err
+	}
+
+	p.Properties = nil
+	for i := 0; i < count; i++ {
+		var property LoginProperty
+		if property.Name, err = types.ReadStringMax(r, maxPropertyNameLength); err != nil {
+			return err
+		}
+		if property.Value, err = types.ReadString(r); err != nil {
+			return err
+		}
+		signed, err := types.ReadBoolean(r)
+		if err != nil {
+			return err
+		}
+		if signed {
+			signature, err := types.ReadStringMax(r, maxPropertySignatureLength)
+			if err != nil {
+				return err
+			}
+			property.Signature = &signature
+		}
+		p.Properties = append(p.Properties, property)
+	}
+	if version >= Protocol1_20_5 && version < Protocol1_21_2 {
+		p.StrictErrorHandling, err = types.ReadBoolean(r)
+	}
+	return err
+}
+
+type SetCompression struct {
+	Threshold types.VarInt // frames of at least this size are compressed, negative disables compression
+}
+
+func (p *SetCompression) ID() int32 { return 0x03 }
+
+func (p *SetCompression) Encode(w io.Writer) error {
+	return types.WriteVarInt(p.Threshold, w)
+}
+
+func (p *SetCompression) Decode(r io.Reader) error {
+	var err error
+	p.Threshold, err = types.ReadVarInt(r)
+	return err
+}
+
+type LoginPluginRequest struct {
+	MessageID types.VarInt
+	Channel   types.String
+	Data      []byte // rest of the packet
+}
+
+func (p *LoginPluginRequest) ID() int32 { return 0x04 }
+
+func (p *LoginPluginRequest) Encode(w io.Writer) error {
+	if err := types.WriteVarInt(p.MessageID, w); err != nil {
+		return err
+	}
+	if err := types.WriteString(p.Channel, w); err != nil {
+		return err
+	}
+	_, err := w.Write(p.Data)
+	return err
+}
+
+func (p *LoginPluginRequest) Decode(r io.Reader) error {
+	var err error
+	if p.MessageID, err = types.ReadVarInt(r); err != nil {
+		return err
+	}
+	if p.Channel, err = types.ReadString(r); err != nil {
+		return err
+	}
+	p.Data, err = io.ReadAll(r)
+	return err
+}
+
+type LoginPluginResponse struct {
+	MessageID  types.VarInt
+	Successful types.Boolean // false if the client did not understand the channel
+	Data       []byte        // rest of the packet, only present if successful
+}
+
+func (p *LoginPluginResponse) ID() int32 { return 0x02 }
+
+func (p *LoginPluginResponse) Encode(w io.Writer) error {
+	if err := types.WriteVarInt(p.MessageID, w); err != nil {
+		return err
+	}
+	if err := types.WriteBoolean(p.Successful, w); err != nil {
+		return err
+	}
+	if !p.Successful {
+		return nil
+	}
+	_, err := w.Write(p.Data)
+	return err
+}
+
+func (p *LoginPluginResponse) Decode(r io.Reader) error {
+	var err error
+	if p.MessageID, err = types.ReadVarInt(r); err != nil {
+		return err
+	}
+	if p.Successful, err = types.ReadBoolean(r); err != nil {
+		return err
+	}
+	p.Data, err = io.ReadAll(r)
+	return err
+}
+
+// LoginAcknowledged switches the connection to the configuration state
+type LoginAcknowledged struct{}
+
+func (p *LoginAcknowledged) ID() int32 { return 0x03 }
+
+func (p *LoginAcknowledged) Encode(w io.Writer) error { return nil }
+
+func (p *LoginAcknowledged) Decode(r io.Reader) error { return nil }
+
+func init() {
+	RegisterPacket(StateLogin, Clientbound, func() Packet { return &LoginDisconnect{} })
+	RegisterPacket(StateLogin, Serverbound, func() Packet { return &LoginStart{} })
+	RegisterPacket(StateLogin, Clientbound, func() Packet { return &EncryptionRequest{} })
+	RegisterPacket(StateLogin, Serverbound, func() Packet { return &EncryptionResponse{} })
+	RegisterPacket(StateLogin, Clientbound, func() Packet { return &LoginSuccess{} })
+	RegisterPacket(StateLogin, Clientbound, func() Packet { return &SetCompression{} })
+	RegisterPacket(StateLogin, Clientbound, func() Packet { return &LoginPluginRequest{} })
+	RegisterPacket(StateLogin, Serverbound, func() Packet { return &LoginPluginResponse{} })
+	RegisterVersionedPacket(StateLogin, Serverbound, Since(Protocol1_20_2, 0x03), func() Packet { return &LoginAcknowledged{} })
+}
@@ -0,0 +1,783 @@
+package packet
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"mc-proxy/protocol/types"
+)
+
+// Marshal encodes the exported fields of a struct in declaration order.
+// The wire format of each field is taken from its mc struct tag, which is a
+// comma separated list of a type and options:
+//
+//	varint, varlong, bool, byte, ubyte, short, ushort, int, long, float,
+//	double, string, identifier, uuid, bytearray, chat, nbt, position, rest
+//
+//	optional        pointer field prefixed with a boolean
+//	prefixed_array  slice field prefixed with its length as a VarInt
+//	max=N           limits the length of a string, byte array or array;
+//	                strings default to types.DefaultMaxStringLength
+//
+// The type may be omitted when it follows from the Go type of the field, e.g.
+// types.VarInt, string or int64. Types with their own Marshal and Decode
+// methods, such as types.Optional and types.PrefixedArray, encode themselves.
+// Other struct fields without a type are encoded recursively and fields
+// tagged mc:"-" are skipped. A rest field holds the remaining bytes of the
+// packet and must be the last field.
+func Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := MarshalTo(&buf, v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// MarshalTo encodes a struct like Marshal and writes it to w. Packets can use
+// it to implement Encode.
+func MarshalTo(w io.Writer, v any) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return fmt.Errorf("cannot marshal %T: not a struct", v)
+	}
+
+	codec, err := codecFor(rv.Type())
+	if err != nil {
+		return err
+	}
+	return codec.encode(w, rv)
+}
+
+// Unmarshal decodes data into the struct pointed to by v, see Marshal. It
+// fails if data is not consumed entirely.
+func Unmarshal(data []byte, v any) error {
+	r := bytes.NewReader(data)
+	if err := UnmarshalFrom(r, v); err != nil {
+		return err
+	}
+	if r.Len() > 0 {
+		return fmt.Errorf("%d bytes left after unmarshalling %T", r.Len(), v)
+	}
+	return nil
+}
+
+// UnmarshalFrom decodes a struct like Unmarshal, reading from r. Packets can
+// use it to implement Decode.
+func UnmarshalFrom(r io.Reader, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("cannot unmarshal into %T: not a pointer to a struct", v)
+	}
+
+	codec, err := codecFor(rv.Elem().Type())
+	if err != nil {
+		return err
+	}
+	return codec.decode(r, rv.Elem())
+}
+
+// valueCodec encodes and decodes a value of one Go type
+type valueCodec struct {
+	encode func(w io.Writer, v reflect.Value) error
+	decode func(r io.Reader, v reflect.Value) error
+}
+
+// fieldTag is a parsed mc struct tag
+type fieldTag struct {
+	kind     string
+	optional bool
+	array    bool
+	max      int // 0 if unlimited
+}
+
+var codecCache sync.Map // reflect.Type -> *valueCodec
+
+// codecFor returns the cached codec of a struct type
+func codecFor(t reflect.Type) (*valueCodec, error) {
+	if codec, ok := codecCache.Load(t); ok {
+		return codec.(*valueCodec), nil
+	}
+
+	codec, err := newStructCodec(t)
+	if err != nil {
+		return nil, err
+	}
+	actual, _ := codecCache.LoadOrStore(t, codec)
+	return actual.(*valueCodec), nil
+}
+
+func newStructCodec(t reflect.Type) (*valueCodec, error) {
+	type field struct {
+		index int
+		name  string
+		rest  bool
+		codec *valueCodec
+	}
+
+	var fields []field
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tagValue := sf.Tag.Get("mc")
+		if !sf.IsExported() || tagValue == "-" {
+			continue
+		}
+
+		name := t.Name() + "." + sf.Name
+		tag, err := parseTag(tagValue)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+		// Only encoded fields may not follow rest, skipped ones are fine
+		if n := len(fields); n > 0 && fields[n-1].rest {
+			return nil, fmt.Errorf("%s: rest must be the last field", fields[n-1].name)
+		}
+
+		codec, err := newFieldCodec(sf.Type, tag)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+		fields = append(fields, field{i, name, tag.kind == "rest", codec})
+	}
+
+	return &valueCodec{
+		encode: func(w io.Writer, v reflect.Value) error {
+			for _, f := range fields {
+				if err := f.codec.encode(w, v.Field(f.index)); err != nil {
+					return fmt.Errorf("failed to encode %s: %v", f.name, err)
+				}
+			}
+			return nil
+		},
+		decode: func(r io.Reader, v reflect.Value) error {
+			for _, f := range fields {
+				if err := f.codec.decode(r, v.Field(f.index)); err != nil {
+					return fmt.Errorf("failed to decode %s: %v", f.name, err)
+				}
+			}
+			return nil
+		},
+	}, nil
+}
+
+func parseTag(tag string) (fieldTag, error) {
+	var parsed fieldTag
+	if tag == "" {
+		return parsed, nil
+	}
+
+	for _, option := range strings.Split(tag, ",") {
+		switch {
+		case option == "optional":
+			parsed.optional = true
+		case option == "prefixed_array":
+			parsed.array = true
+		case strings.HasPrefix(option, "max="):
+			max, err := strconv.Atoi(strings.TrimPrefix(option, "max="))
+			if err != nil || max <= 0 {
+				return parsed, fmt.Errorf("invalid option %q", option)
+			}
+			parsed.max = max
+		case isKind(option):
+			if parsed.kind != "" {
+				return parsed, fmt.Errorf("more than one type in tag %q", tag)
+			}
+			parsed.kind = option
+		default:
+			return parsed, fmt.Errorf("unknown tag option %q", option)
+		}
+	}
+
+	if parsed.optional && parsed.array {
+		return parsed, fmt.Errorf("a field cannot be both optional and a prefixed array")
+	}
+	if parsed.kind == "rest" && (parsed.optional || parsed.array) {
+		return parsed, fmt.Errorf("rest cannot be optional or a prefixed array")
+	}
+	return parsed, nil
+}
+
+func newFieldCodec(t reflect.Type, tag fieldTag) (*valueCodec, error) {
+	switch {
+	case tag.optional:
+		if t.Kind() != reflect.Pointer {
+			return nil, fmt.Errorf("optional field must be a pointer, not %s", t)
+		}
+		elem, err := newValueCodec(t.Elem(), tag.kind, tag.max)
+		if err != nil {
+			return nil, err
+		}
+		return optionalCodec(t, elem), nil
+
+	case tag.array:
+		if t.Kind() != reflect.Slice {
+			return nil, fmt.Errorf("prefixed array field must be a slice, not %s", t)
+		}
+		elem, err := newValueCodec(t.Elem(), tag.kind, 0)
+		if err != nil {
+			return nil, err
+		}
+		return arrayCodec(t, elem, tag.max), nil
+
+	default:
+		return newValueCodec(t, tag.kind, tag.max)
+	}
+}
+
+func optionalCodec(t reflect.Type, elem *valueCodec) *valueCodec {
+	return &valueCodec{
+		encode: func(w io.Writer, v reflect.Value) error {
+			if err := types.WriteBoolean(types.Boolean(!v.IsNil()), w); err != nil {
+				return err
+			}
+			if v.IsNil() {
+				return nil
+			}
+			return elem.encode(w, v.Elem())
+		},
+		decode: func(r io.Reader, v reflect.Value) error {
+			present, err := types.ReadBoolean(r)
+			if err != nil {
+				return err
+			}
+			if !present {
+				v.Set(reflect.Zero(t))
+				return nil
+			}
+
+			ptr := reflect.New(t.Elem())
+			if err := elem.decode(r, ptr.Elem()); err != nil {
+				return err
+			}
+			v.Set(ptr)
+			return nil
+		},
+	}
+}
+
+func arrayCodec(t reflect.Type, elem *valueCodec, max int) *valueCodec {
+	return &valueCodec{
+		encode: func(w io.Writer, v reflect.Value) error {
+			if max > 0 && v.Len() > max {
+				return fmt.Errorf("array has %d elements, more than the maximum of %d", v.Len(), max)
+			}
+			if err := types.WriteVarInt(types.VarInt(v.Len()), w); err != nil {
+				return err
+			}
+			for i := 0; i < v.Len(); i++ {
+				if err := elem.encode(w, v.Index(i)); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		decode: func(r io.Reader, v reflect.Value) error {
+			count, err := readCount(r)
+			if err != nil {
+				return err
+			}
+			if max > 0 && count > max {
+				return fmt.Errorf("array has %d elements, more than the maximum of %d", count, max)
+			}
+
+			// Grow the slice while reading so a bogus count cannot allocate much
+			slice := reflect.MakeSlice(t, 0, 0)
+			for i := 0; i < count; i++ {
+				slice = reflect.Append(slice, reflect.Zero(t.Elem()))
+				if err := elem.decode(r, slice.Index(i)); err != nil {
+					return err
+				}
+			}
+			v.Set(slice)
+			return nil
+		},
+	}
+}
+
+var (
+	stringType     = reflect.TypeOf(types.String{})
+	identifierType = reflect.TypeOf(types.Identifier{})
+	uuidType       = reflect.TypeOf(types.UUID{})
+	chatType       = reflect.TypeOf(types.Chat{})
+	positionType   = reflect.TypeOf(types.Position{})
+)
+
+// kindsByType maps types of the types package to their wire format
+var kindsByType = map[reflect.Type]string{
+	reflect.TypeOf(types.VarInt(0)):        "varint",
+	reflect.TypeOf(types.VarLong(0)):       "varlong",
+	reflect.TypeOf(types.Boolean(false)):   "bool",
+	reflect.TypeOf(types.Byte(0)):          "byte",
+	reflect.TypeOf(types.UnsignedByte(0)):  "ubyte",
+	reflect.TypeOf(types.Short(0)):         "short",
+	reflect.TypeOf(types.UnsignedShort(0)): "ushort",
+	reflect.TypeOf(types.Int(0)):           "int",
+	reflect.TypeOf(types.Long(0)):          "long",
+	reflect.TypeOf(types.Float(0)):         "float",
+	reflect.TypeOf(types.Double(0)):        "double",
+	reflect.TypeOf(types.ByteArray(nil)):   "bytearray",
+	reflect.TypeOf(types.RawNBT(nil)):      "nbt",
+	stringType:                             "string",
+	identifierType:                         "identifier",
+	uuidType:                               "uuid",
+	chatType:                               "chat",
+	positionType:                           "position",
+}
+
+// kindsByGoKind maps plain Go types to their wire format
+var kindsByGoKind = map[reflect.Kind]string{
+	reflect.Bool:    "bool",
+	reflect.Int8:    "byte",
+	reflect.Uint8:   "ubyte",
+	reflect.Int16:   "short",
+	reflect.Uint16:  "ushort",
+	reflect.Int32:   "int",
+	reflect.Int64:   "long",
+	reflect.Float32: "float",
+	reflect.Float64: "double",
+	reflect.String:  "string",
+}
+
+func isKind(s string) bool {
+	switch s {
+	case "varint", "varlong", "bool", "byte", "ubyte", "short", "ushort", "int", "long",
+		"float", "double", "string", "identifier", "uuid", "bytearray", "chat", "nbt", "position", "rest":
+		return true
+	}
+	return false
+}
+
+// inferKind returns the wire format implied by a Go type, if any
+func inferKind(t reflect.Type) string {
+	if kind, ok := kindsByType[t]; ok {
+		return kind
+	}
+	if isByteSlice(t) {
+		return "bytearray"
+	}
+	return kindsByGoKind[t.Kind()]
+}
+
+func newValueCodec(t reflect.Type, kind string, max int) (*valueCodec, error) {
+	if kind == "" {
+		kind = inferKind(t)
+	}
+	if kind == "" && isSelfCoding(t) {
+		if max > 0 {
+			return nil, fmt.Errorf("max is not supported for %s", t)
+		}
+		return selfCodec(t), nil
+	}
+	if kind == "" {
+		if t.Kind() != reflect.Struct {
+			return nil, fmt.Errorf("cannot infer the wire format of %s, add a type to the mc tag", t)
+		}
+		// Nested structs are resolved lazily so types may refer to themselves
+		return &valueCodec{
+			encode: func(w io.Writer, v reflect.Value) error {
+				codec, err := codecFor(t)
+				if err != nil {
+					return err
+				}
+				return codec.encode(w, v)
+			},
+			decode: func(r io.Reader, v reflect.Value) error {
+				codec, err := codecFor(t)
+				if err != nil {
+					return err
+				}
+				return codec.decode(r, v)
+			},
+		}, nil
+	}
+
+	if !kindAccepts(kind, t) {
+		return nil, fmt.Errorf("cannot encode %s as %s", t, kind)
+	}
+	if max > 0 && !hasLength(kind) {
+		return nil, fmt.Errorf("max is not supported for %s", kind)
+	}
+	switch kind {
+	case "string":
+		return stringCodec(max), nil
+	case "bytearray":
+		return byteArrayCodec(max), nil
+	}
+	return &valueCodec{
+		encode: func(w io.Writer, v reflect.Value) error {
+			if err := checkLength(kind, v, max); err != nil {
+				return err
+			}
+			return encodeValue(w, kind, v)
+		},
+		decode: func(r io.Reader, v reflect.Value) error {
+			if err := decodeValue(r, kind, v); err != nil {
+				return err
+			}
+			return checkLength(kind, v, max)
+		},
+	}, nil
+}
+
+var decoderType = reflect.TypeOf((*types.Decoder)(nil)).Elem()
+
+// isSelfCoding reports whether a type encodes itself like the composite types
+// of the types package, with Marshal on values and Decode on pointers
+func isSelfCoding(t reflect.Type) bool {
+	return t.Implements(reflect.TypeOf((*marshaler)(nil)).Elem()) && reflect.PointerTo(t).Implements(decoderType)
+}
+
+// selfCodec encodes values of a self-coding type with their own methods
+func selfCodec(t reflect.Type) *valueCodec {
+	return &valueCodec{
+		encode: func(w io.Writer, v reflect.Value) error {
+			data, err := v.Interface().(marshaler).Marshal()
+			if err != nil {
+				return err
+			}
+			_, err = w.Write(data)
+			return err
+		},
+		decode: func(r io.Reader, v reflect.Value) error {
+			return v.Addr().Interface().(types.Decoder).Decode(r)
+		},
+	}
+}
+
+// kindAccepts reports whether values of a Go type can be encoded as kind
+func kindAccepts(kind string, t reflect.Type) bool {
+	switch kind {
+	case "varint", "varlong", "byte", "ubyte", "short", "ushort", "int", "long":
+		return isInteger(t)
+	case "bool":
+		return t.Kind() == reflect.Bool
+	case "float", "double":
+		return t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64
+	case "string":
+		return t.Kind() == reflect.String || t == stringType
+	case "identifier":
+		return t == identifierType
+	case "uuid":
+		return t == uuidType
+	case "chat":
+		return t == chatType
+	case "position":
+		return t == positionType
+	case "bytearray", "nbt", "rest":
+		return isByteSlice(t)
+	}
+	return false
+}
+
+// byteArrayCodec encodes byte arrays of at most max bytes. Unlike other
+// lengths, the length of a byte array is checked before it is read, so that a
+// bogus length cannot allocate much.
+func byteArrayCodec(max int) *valueCodec {
+	return &valueCodec{
+		encode: func(w io.Writer, v reflect.Value) error {
+			if err := checkLength("bytearray", v, max); err != nil {
+				return err
+			}
+			return encodeValue(w, "bytearray", v)
+		},
+		decode: func(r io.Reader, v reflect.Value) error {
+			limit := max
+			if limit <= 0 {
+				limit = math.MaxInt32
+			}
+			value, err := types.ReadByteArrayMax(r, limit)
+			if err != nil {
+				return err
+			}
+			v.SetBytes(value)
+			return nil
+		},
+	}
+}
+
+func hasLength(kind string) bool {
+	return kind == "string" || kind == "bytearray" || kind == "nbt" || kind == "rest"
+}
+
+// stringCodec encodes strings of at most max UTF-16 code units. Like byte
+// arrays, oversized strings are rejected before they are read.
+func stringCodec(max int) *valueCodec {
+	if max <= 0 {
+		max = types.DefaultMaxStringLength
+	}
+	return &valueCodec{
+		encode: func(w io.Writer, v reflect.Value) error {
+			return types.WriteStringMax(types.String{Value: stringOf(v)}, w, max)
+		},
+		decode: func(r io.Reader, v reflect.Value) error {
+			value, err := types.ReadStringMax(r, max)
+			if err != nil {
+				return err
+			}
+			if v.Kind() == reflect.String {
+				v.SetString(value.Value)
+			} else {
+				v.Set(reflect.ValueOf(value))
+			}
+			return nil
+		},
+	}
+}
+
+// checkLength enforces the max option
+func checkLength(kind string, v reflect.Value, max int) error {
+	if max <= 0 {
+		return nil
+	}
+
+	length := v.Len()
+	if length > max {
+		return fmt.Errorf("%s has length %d, more than the maximum of %d", kind, length, max)
+	}
+	return nil
+}
+
+// marshaler is the encoding half of types.MarshallableType, which values of
+// the types package implement
+type marshaler interface {
+	Marshal() ([]byte, error)
+}
+
+func encodeValue(w io.Writer, kind string, v reflect.Value) error {
+	if isInteger(v.Type()) {
+		x, err := intInRange(kind, v)
+		if err != nil {
+			return err
+		}
+		v = reflect.ValueOf(x)
+	}
+
+	var value marshaler
+	switch kind {
+	case "varint":
+		value = types.VarInt(intOf(v))
+	case "varlong":
+		value = types.VarLong(intOf(v))
+	case "bool":
+		value = types.Boolean(v.Bool())
+	case "byte":
+		value = types.Byte(intOf(v))
+	case "ubyte":
+		value = types.UnsignedByte(intOf(v))
+	case "short":
+		value = types.Short(intOf(v))
+	case "ushort":
+		value = types.UnsignedShort(intOf(v))
+	case "int":
+		value = types.Int(intOf(v))
+	case "long":
+		value = types.Long(intOf(v))
+	case "float":
+		value = types.Float(v.Float())
+	case "double":
+		value = types.Double(v.Float())
+	case "identifier", "uuid", "chat", "position":
+		value = v.Interface().(marshaler)
+	case "bytearray":
+		value = types.ByteArray(v.Bytes())
+	case "nbt":
+		value = types.RawNBT(v.Bytes())
+	case "rest":
+		_, err := w.Write(v.Bytes())
+		return err
+	}
+
+	buf, err := value.Marshal()
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(buf)
+	return err
+}
+
+func decodeValue(r io.Reader, kind string, v reflect.Value) error {
+	switch kind {
+	case "varint":
+		value, err := types.ReadVarInt(r)
+		if err != nil {
+			return err
+		}
+		return setInt(v, int64(value))
+	case "varlong":
+		value, err := types.ReadVarLong(r)
+		if err != nil {
+			return err
+		}
+		return setInt(v, int64(value))
+	case "bool":
+		value, err := types.ReadBoolean(r)
+		if err != nil {
+			return err
+		}
+		v.SetBool(bool(value))
+	case "byte":
+		value, err := types.ReadByte(r)
+		if err != nil {
+			return err
+		}
+		return setInt(v, int64(value))
+	case "ubyte":
+		value, err := types.ReadUnsignedByte(r)
+		if err != nil {
+			return err
+		}
+		return setInt(v, int64(value))
+	case "short":
+		value, err := types.ReadShort(r)
+		if err != nil {
+			return err
+		}
+		return setInt(v, int64(value))
+	case "ushort":
+		value, err := types.ReadUnsignedShort(r)
+		if err != nil {
+			return err
+		}
+		return setInt(v, int64(value))
+	case "int":
+		value, err := types.ReadInt(r)
+		if err != nil {
+			return err
+		}
+		return setInt(v, int64(value))
+	case "long":
+		value, err := types.ReadLong(r)
+		if err != nil {
+			return err
+		}
+		return setInt(v, int64(value))
+	case "float":
+		value, err := types.ReadFloat(r)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(float64(value))
+	case "double":
+		value, err := types.ReadDouble(r)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(float64(value))
+	case "identifier":
+		value, err := types.ReadIdentifier(r)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(value))
+	case "uuid":
+		value, err := types.ReadUUID(r)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(value))
+	case "chat":
+		value, err := types.ReadChat(r)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(value))
+	case "position":
+		value, err := types.ReadPosition(r)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(value))
+	case "nbt":
+		value, err := types.ReadRawNBT(r)
+		if err != nil {
+			return err
+		}
+		v.SetBytes(value)
+	case "rest":
+		value, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		v.SetBytes(value)
+	}
+	return nil
+}
+
+func isInteger(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+func isByteSlice(t reflect.Type) bool {
+	return t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8
+}
+
+func intOf(v reflect.Value) int64 {
+	if v.CanInt() {
+		return v.Int()
+	}
+	return int64(v.Uint())
+}
+
+// intRanges are the values each integer kind can encode
+var intRanges = map[string][2]int64{
+	"byte":    {math.MinInt8, math.MaxInt8},
+	"ubyte":   {0, math.MaxUint8},
+	"short":   {math.MinInt16, math.MaxInt16},
+	"ushort":  {0, math.MaxUint16},
+	"int":     {math.MinInt32, math.MaxInt32},
+	"varint":  {math.MinInt32, math.MaxInt32},
+	"long":    {math.MinInt64, math.MaxInt64},
+	"varlong": {math.MinInt64, math.MaxInt64},
+}
+
+// intInRange returns an integer to encode, failing if the kind cannot hold
+// it instead of truncating it
+func intInRange(kind string, v reflect.Value) (int64, error) {
+	bounds := intRanges[kind]
+	if v.CanUint() {
+		if x := v.Uint(); x > uint64(bounds[1]) {
+			return 0, fmt.Errorf("value %d overflows %s", x, kind)
+		}
+		return int64(v.Uint()), nil
+	}
+	if x := v.Int(); x < bounds[0] || x > bounds[1] {
+		return 0, fmt.Errorf("value %d overflows %s", x, kind)
+	}
+	return v.Int(), nil
+}
+
+// setInt stores a decoded integer, failing if it does not fit the field
+func setInt(v reflect.Value, x int64) error {
+	if v.CanInt() {
+		if v.OverflowInt(x) {
+			return fmt.Errorf("value %d overflows %s", x, v.Type())
+		}
+		v.SetInt(x)
+		return nil
+	}
+	if x < 0 || v.OverflowUint(uint64(x)) {
+		return fmt.Errorf("value %d overflows %s", x, v.Type())
+	}
+	v.SetUint(uint64(x))
+	return nil
+}
+
+func stringOf(v reflect.Value) string {
+	if v.Kind() == reflect.String {
+		return v.String()
+	}
+	return v.Interface().(types.String).Value
+}
@@ -0,0 +1,281 @@
+package packet
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"mc-proxy/protocol/types"
+)
+
+func TestMarshalIntegerRange(t *testing.T) {
+	type varInt struct {
+		X int `mc:"varint"`
+	}
+	type unsignedAsByte struct {
+		X uint8 `mc:"byte"`
+	}
+	type unsignedAsLong struct {
+		X uint64 `mc:"long"`
+	}
+	type shortAsUbyte struct {
+		X int16 `mc:"ubyte"`
+	}
+
+	tests := []struct {
+		name    string
+		value   any
+		wantErr bool
+	}{
+		{"varint in range", &varInt{-5}, false},
+		{"varint overflow", &varInt{1 << 40}, true},
+		{"byte in range", &unsignedAsByte{100}, false},
+		{"byte overflow", &unsignedAsByte{200}, true},
+		{"long overflow", &unsignedAsLong{1 << 63}, true},
+		{"ubyte in range", &shortAsUbyte{255}, false},
+		{"ubyte negative", &shortAsUbyte{-1}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := Marshal(tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Marshal(%+v) = %v, want error", tt.value, data)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Marshal(%+v): %v", tt.value, err)
+			}
+
+			got := reflect.New(reflect.TypeOf(tt.value).Elem()).Interface()
+			if err := UnmarshalFrom(bytes.NewReader(data), got); err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.value) {
+				t.Errorf("round trip = %+v, want %+v", got, tt.value)
+			}
+		})
+	}
+}
+
+type marshalInner struct {
+	Name string
+	X    types.VarInt
+}
+
+type marshalOuter struct {
+	ID      types.VarInt
+	Brand   *types.String `mc:"optional"`
+	Missing *int32        `mc:"optional"`
+	Names   []string      `mc:"prefixed_array,max=4"`
+	Inner   marshalInner
+	Inners  []marshalInner `mc:"prefixed_array"`
+	Secret  []byte         `mc:"bytearray,max=16"`
+	Ignored int            `mc:"-"`
+	Data    []byte         `mc:"rest"`
+	hidden  int
+}
+
+func TestMarshalRoundTrip(t *testing.T) {
+	brand := types.String{Value: "vanilla"}
+	want := &marshalOuter{
+		ID:     7,
+		Brand:  &brand,
+		Names:  []string{"a", "bc"},
+		Inner:  marshalInner{"inner", 1},
+		Inners: []marshalInner{{"x", 2}, {"y", 3}},
+		Secret: []byte{1, 2, 3},
+		Data:   []byte{9, 8},
+	}
+	data, err := Marshal(want)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	expected := []byte{
+		7,                                       // ID
+		1, 7, 'v', 'a', 'n', 'i', 'l', 'l', 'a', // Brand
+		0,                      // Missing
+		2, 1, 'a', 2, 'b', 'c', // Names
+		5, 'i', 'n', 'n', 'e', 'r', 1, // Inner
+		2, 1, 'x', 2, 1, 'y', 3, // Inners
+		3, 1, 2, 3, // Secret
+		9, 8, // Data
+	}
+	if !bytes.Equal(data, expected) {
+		t.Fatalf("Marshal = %v, want %v", data, expected)
+	}
+
+	got := &marshalOuter{Ignored: 5}
+	if err := Unmarshal(data, got); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	want.Ignored = 5
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("round trip = %+v, want %+v", got, want)
+	}
+
+	inner, err := Marshal(&want.Inner)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if err := Unmarshal(append(inner, 0), &marshalInner{}); err == nil {
+		t.Error("Unmarshal accepted trailing bytes")
+	}
+}
+
+func TestMarshalRestLast(t *testing.T) {
+	type restThenSkipped struct {
+		Data    []byte `mc:"rest"`
+		Ignored int    `mc:"-"`
+		hidden  int
+	}
+	type restThenField struct {
+		Data []byte `mc:"rest"`
+		X    types.VarInt
+	}
+
+	data, err := Marshal(&restThenSkipped{Data: []byte{1, 2}})
+	if err != nil {
+		t.Fatalf("rest followed by skipped fields: %v", err)
+	}
+	if !bytes.Equal(data, []byte{1, 2}) {
+		t.Errorf("Marshal = %v, want [1 2]", data)
+	}
+
+	if _, err := Marshal(&restThenField{}); err == nil || !strings.Contains(err.Error(), "rest must be the last field") {
+		t.Errorf("rest followed by a field: got error %v", err)
+	}
+}
+
+func TestMarshalMax(t *testing.T) {
+	type limited struct {
+		Name   string   `mc:"string,max=3"`
+		Secret []byte   `mc:"bytearray,max=4"`
+		Items  []string `mc:"prefixed_array,max=2"`
+	}
+
+	for name, value := range map[string]*limited{
+		"string":    {Name: "long"},
+		"bytearray": {Secret: []byte{1, 2, 3, 4, 5}},
+		"array":     {Items: []string{"a", "b", "c"}},
+	} {
+		if _, err := Marshal(value); err == nil {
+			t.Errorf("%s: Marshal accepted a value over the maximum", name)
+		}
+	}
+
+	if _, err := Marshal(&limited{Name: "äöü", Secret: []byte{1, 2, 3, 4}, Items: []string{"a", "b"}}); err != nil {
+		t.Errorf("Marshal of values at the maximum: %v", err)
+	}
+
+	tests := map[string][]byte{
+		"string":    {4, 'l', 'o', 'n', 'g', 0, 0},
+		"bytearray": {0, 5, 1, 2, 3, 4, 5, 0},
+		"array":     {0, 0, 3, 1, 'a', 1, 'b', 1, 'c'},
+		// A huge length must fail before anything is read or allocated
+		"bytearray length": {0, 0xff, 0xff, 0xff, 0xff, 0x07},
+	}
+	for name, data := range tests {
+		err := Unmarshal(data, &limited{})
+		if err == nil {
+			t.Errorf("%s: Unmarshal accepted a value over the maximum", name)
+		} else if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || strings.Contains(err.Error(), "EOF") {
+			t.Errorf("%s: length checked after reading: %v", name, err)
+		}
+	}
+}
+
+func TestMarshalTagErrors(t *testing.T) {
+	tests := []struct {
+		value any
+		want  string
+	}{
+		{&struct {
+			X int `mc:"varint,nope"`
+		}{}, "unknown tag option"},
+		{&struct {
+			X int `mc:"varint,long"`
+		}{}, "more than one type"},
+		{&struct {
+			X string `mc:"max=0"`
+		}{}, "invalid option"},
+		{&struct {
+			X string `mc:"max=abc"`
+		}{}, "invalid option"},
+		{&struct {
+			X []int `mc:"varint,optional,prefixed_array"`
+		}{}, "both optional and a prefixed array"},
+		{&struct {
+			X *[]byte `mc:"rest,optional"`
+		}{}, "rest cannot be optional"},
+		{&struct {
+			X int `mc:"varint,optional"`
+		}{}, "must be a pointer"},
+		{&struct {
+			X int `mc:"varint,prefixed_array"`
+		}{}, "must be a slice"},
+		{&struct {
+			X int `mc:"string"`
+		}{}, "cannot encode int as string"},
+		{&struct {
+			X int `mc:"varint,max=3"`
+		}{}, "max is not supported"},
+		{&struct {
+			X map[string]int
+		}{}, "cannot infer the wire format"},
+	}
+	for _, tt := range tests {
+		_, err := Marshal(tt.value)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%T: got error %v, want %q", tt.value, err, tt.want)
+		}
+	}
+
+	if _, err := Marshal(5); err == nil {
+		t.Error("Marshal accepted a non-struct")
+	}
+	if err := Unmarshal(nil, marshalInner{}); err == nil {
+		t.Error("Unmarshal accepted a non-pointer")
+	}
+}
+
+func TestMarshalSelfCoding(t *testing.T) {
+	type selfCoding struct {
+		Brand types.Optional[types.String, *types.String]
+		Empty types.Optional[types.VarInt, *types.VarInt]
+		IDs   types.PrefixedArray[types.VarInt, *types.VarInt]
+	}
+
+	want := &selfCoding{
+		Brand: types.Some[types.String](types.String{Value: "hi"}),
+		IDs:   types.PrefixedArray[types.VarInt, *types.VarInt]{1, 300},
+	}
+	data, err := Marshal(want)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	expected := []byte{1, 2, 'h', 'i', 0, 2, 1, 0xac, 0x02}
+	if !bytes.Equal(data, expected) {
+		t.Fatalf("Marshal = %v, want %v", data, expected)
+	}
+
+	got := &selfCoding{}
+	if err := Unmarshal(data, got); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("round trip = %+v, want %+v", got, want)
+	}
+
+	type selfCodingMax struct {
+		IDs types.PrefixedArray[types.VarInt, *types.VarInt] `mc:"max=2"`
+	}
+	if _, err := Marshal(&selfCodingMax{}); err == nil || !strings.Contains(err.Error(), "max is not supported") {
+		t.Errorf("max on a self-coding field: got error %v", err)
+	}
+}
@@ -1,17 +1,277 @@
 package packet
 
+//go:generate go run ../../cmd/packetgen -schema schema/packets.json -out packets_gen.go -test packets_gen_test.go
+
 import (
+	"bytes"
+	"fmt"
 	"io"
+	"reflect"
+	"sync"
+
+	"mc-proxy/protocol/types"
 )
 
 type Packet interface {
-	ID() int32
+	ID() int32 // packet ID in the latest protocol version
 	Encode(w io.Writer) error
 	Decode(r io.Reader) error
 }
 
-var packetRegistry = map[int32]func() Packet{}
+// State is the connection state a packet is sent in
+type State int
+
+const (
+	StateHandshake State = iota
+	StateStatus
+	StateLogin
+	StateConfiguration
+	StatePlay
+)
+
+func (s State) String() string {
+	switch s {
+	case StateHandshake:
+		return "handshake"
+	case StateStatus:
+		return "status"
+	case StateLogin:
+		return "login"
+	case StateConfiguration:
+		return "configuration"
+	case StatePlay:
+		return "play"
+	default:
+		return fmt.Sprintf("State(%d)", int(s))
+	}
+}
+
+// Direction is the side of the connection a packet is sent to
+type Direction int
+
+const (
+	Serverbound Direction = iota
+	Clientbound
+)
+
+func (d Direction) String() string {
+	switch d {
+	case Serverbound:
+		return "serverbound"
+	case Clientbound:
+		return "clientbound"
+	default:
+		return fmt.Sprintf("Direction(%d)", int(d))
+	}
+}
+
+// VersionedPacket is implemented by packets whose fields differ between
+// protocol versions. Their Encode and Decode use the latest format.
+type VersionedPacket interface {
+	Packet
+	EncodeVersion(w io.Writer, version int32) error
+	DecodeVersion(r io.Reader, version int32) error
+}
+
+// AnyVersion is used for protocol versions missing from the versions table
+const AnyVersion int32 = -1
+
+type registryKey struct {
+	state     State
+	direction Direction
+	version   int32
+	id        int32
+}
+
+type typeKey struct {
+	state      State
+	direction  Direction
+	version    int32
+	packetType reflect.Type
+}
+
+// Registry maps a state, direction, protocol version and packet ID to the
+// constructor of the matching Packet, and a packet type back to its ID
+type Registry struct {
+	mutex   sync.RWMutex
+	packets map[registryKey]func() Packet
+	ids     map[typeKey]int32
+}
+
+// NewRegistry creates an empty packet registry
+func NewRegistry() *Registry {
+	return &Registry{
+		packets: make(map[registryKey]func() Packet),
+		ids:     make(map[typeKey]int32),
+	}
+}
+
+// Register adds a packet constructor for a single protocol version and ID.
+// It panics if a packet is already registered for them, so that colliding IDs
+// fail when the packets are registered instead of replacing each other.
+func (r *Registry) Register(state State, direction Direction, version int32, id int32, constructor func() Packet) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	key := registryKey{state, direction, version, id}
+	if _, ok := r.packets[key]; ok {
+		panic(fmt.Sprintf("%s %s packet 0x%02X is already registered for protocol %d", state, direction, id, version))
+	}
+	r.packets[key] = constructor
+	r.ids[typeKey{state, direction, version, reflect.TypeOf(constructor())}] = id
+}
+
+// RegisterIDs adds a packet constructor for every supported protocol version
+// the packet exists in, using the ID of each version. The newest ID is also
+// registered for AnyVersion.
+func (r *Registry) RegisterIDs(state State, direction Direction, ids VersionIDs, constructor func() Packet) {
+	for _, version := range Protocols() {
+		if id, ok := ids.lookup(version); ok {
+			r.Register(state, direction, version, id, constructor)
+		}
+	}
+	if id, ok := ids.lookup(LatestProtocol); ok {
+		r.Register(state, direction, AnyVersion, id, constructor)
+	}
+}
+
+// Lookup returns the constructor registered for the packet ID. Protocol
+// versions missing from the versions table use the AnyVersion registration.
+func (r *Registry) Lookup(state State, direction Direction, version int32, id int32) (func() Packet, bool) {
+	if !IsSupported(version) {
+		version = AnyVersion
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	constructor, ok := r.packets[registryKey{state, direction, version, id}]
+	return constructor, ok
+}
+
+// PacketID returns the ID of the packet in the given protocol version. It
+// reports false if the packet is registered but does not exist in that
+// version. Unregistered packets use their own ID.
+func (r *Registry) PacketID(state State, direction Direction, version int32, p Packet) (int32, bool) {
+	if !IsSupported(version) {
+		version = AnyVersion
+	}
+	packetType := reflect.TypeOf(p)
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	if id, ok := r.ids[typeKey{state, direction, version, packetType}]; ok {
+		return id, true
+	}
+	if _, ok := r.ids[typeKey{state, direction, AnyVersion, packetType}]; ok {
+		return 0, false
+	}
+	return p.ID(), true
+}
+
+// New creates an empty packet for the given state, direction, protocol version and ID
+func (r *Registry) New(state State, direction Direction, version int32, id int32) (Packet, error) {
+	constructor, ok := r.Lookup(state, direction, version, id)
+	if !ok {
+		return nil, fmt.Errorf("unknown %s %s packet 0x%02X for protocol %d", state, direction, id, version)
+	}
+	return constructor(), nil
+}
+
+// Decode builds a packet from a raw frame consisting of the packet ID
+// followed by the packet body
+func (r *Registry) Decode(state State, direction Direction, version int32, frame []byte) (Packet, error) {
+	reader := bytes.NewReader(frame)
+
+	id, err := types.ReadVarInt(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read packet ID: %v", err)
+	}
+
+	p, err := r.New(state, direction, version, int32(id))
+	if err != nil {
+		return nil, err
+	}
+
+	if versioned, ok := p.(VersionedPacket); ok {
+		err = versioned.DecodeVersion(reader, normalizeVersion(version))
+	} else {
+		err = p.Decode(reader)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s %s packet 0x%02X: %v", state, direction, int32(id), err)
+	}
+	if reader.Len() != 0 {
+		return nil, fmt.Errorf("%s %s packet 0x%02X has %d trailing bytes", state, direction, int32(id), reader.Len())
+	}
+	return p, nil
+}
+
+// Encode serializes a packet into a raw frame using the packet ID and format
+// of the given protocol version
+func (r *Registry) Encode(state State, direction Direction, version int32, p Packet) ([]byte, error) {
+	id, ok := r.PacketID(state, direction, version, p)
+	if !ok {
+		return nil, fmt.Errorf("%s %s packet %T does not exist in protocol %d", state, direction, p, version)
+	}
+
+	var buf bytes.Buffer
+	if err := types.WriteVarInt(types.VarInt(id), &buf); err != nil {
+		return nil, fmt.Errorf("failed to write packet ID: %v", err)
+	}
+
+	var err error
+	if versioned, ok := p.(VersionedPacket); ok {
+		err = versioned.EncodeVersion(&buf, normalizeVersion(version))
+	} else {
+		err = p.Encode(&buf)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode packet 0x%02X: %v", id, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// FrameID returns the packet ID at the start of a raw frame
+func FrameID(frame []byte) (int32, error) {
+	id, err := types.ReadVarInt(bytes.NewReader(frame))
+	if err != nil {
+		return 0, fmt.Errorf("failed to read packet ID: %v", err)
+	}
+	return int32(id), nil
+}
+
+// DefaultRegistry holds all packets registered by this package
+var DefaultRegistry = NewRegistry()
+
+// RegisterPacket adds a packet that exists in every supported protocol
+// version to the default registry, using the ID reported by the packet itself
+func RegisterPacket(state State, direction Direction, constructor func() Packet) {
+	DefaultRegistry.RegisterIDs(state, direction, VersionIDs{0: constructor().ID()}, constructor)
+}
+
+// RegisterVersionedPacket adds a packet whose ID depends on the protocol
+// version to the default registry
+func RegisterVersionedPacket(state State, direction Direction, ids VersionIDs, constructor func() Packet) {
+	DefaultRegistry.RegisterIDs(state, direction, ids, constructor)
+}
+
+// Decode builds a packet from a raw frame using the default registry
+func Decode(state State, direction Direction, version int32, frame []byte) (Packet, error) {
+	return DefaultRegistry.Decode(state, direction, version, frame)
+}
+
+// Encode serializes a packet into a raw frame consisting of the packet ID
+// followed by the packet body, in the format of the latest protocol version
+func Encode(p Packet) ([]byte, error) {
+	var buf bytes.Buffer
 
-func RegisterPacket(id int32, constructor func() Packet) {
-	packetRegistry[id] = constructor
+	if err := types.WriteVarInt(types.VarInt(p.ID()), &buf); err != nil {
+		return nil, fmt.Errorf("failed to write packet ID: %v", err)
+	}
+	if err := p.Encode(&buf); err != nil {
+		return nil, fmt.Errorf("failed to encode packet 0x%02X: %v", p.ID(), err)
+	}
+	return buf.Bytes(), nil
 }
@@ -0,0 +1,206 @@
+package packet
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+
+	"mc-proxy/protocol/types"
+)
+
+// testPacket is a packet with a configurable ID and a single VarInt field
+type testPacket struct {
+	id    int32
+	Value types.VarInt
+}
+
+func (p *testPacket) ID() int32 { return p.id }
+
+func (p *testPacket) Encode(w io.Writer) error {
+	return types.WriteVarInt(p.Value, w)
+}
+
+func (p *testPacket) Decode(r io.Reader) (err error) {
+	p.Value, err = types.ReadVarInt(r)
+	return err
+}
+
+func newTestPacket(id int32) func() Packet {
+	return func() Packet { return &testPacket{id: id} }
+}
+
+func TestRegistryLookup(t *testing.T) {
+	r := NewRegistry()
+	r.Register(StatePlay, Clientbound, AnyVersion, 0x01, newTestPacket(0x01))
+	r.Register(StatePlay, Clientbound, Protocol1_21, 0x01, newTestPacket(0x02))
+
+	tests := []struct {
+		name      string
+		state     State
+		direction Direction
+		version   int32
+		wantID    int32 // 0 if no packet is registered
+	}{
+		{"exact version", StatePlay, Clientbound, Protocol1_21, 0x02},
+		{"unsupported older version", StatePlay, Clientbound, 700, 0x01},
+		{"unsupported newer version", StatePlay, Clientbound, 9999, 0x01},
+		{"AnyVersion", StatePlay, Clientbound, AnyVersion, 0x01},
+		{"supported version without registration", StatePlay, Clientbound, Protocol1_20_5, 0},
+		{"other direction", StatePlay, Serverbound, Protocol1_21, 0},
+		{"other state", StateConfiguration, Clientbound, Protocol1_21, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			constructor, ok := r.Lookup(tt.state, tt.direction, tt.version, 0x01)
+			if tt.wantID == 0 {
+				if ok {
+					t.Errorf("Lookup found packet 0x%02X", constructor().ID())
+				}
+				if _, err := r.New(tt.state, tt.direction, tt.version, 0x01); err == nil {
+					t.Error("New of an unregistered packet succeeded")
+				}
+				return
+			}
+			if !ok || constructor().ID() != tt.wantID {
+				t.Errorf("Lookup = %v, want packet 0x%02X", ok, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestRegistryRegisterDuplicate(t *testing.T) {
+	r := NewRegistry()
+	r.Register(StateLogin, Serverbound, AnyVersion, 0x00, newTestPacket(0x00))
+	// The same ID in another state, direction or version does not collide
+	r.Register(StateLogin, Clientbound, AnyVersion, 0x00, newTestPacket(0x00))
+	r.Register(StateLogin, Serverbound, Protocol1_21, 0x00, newTestPacket(0x00))
+
+	defer func() {
+		if recover() == nil {
+			t.Error("registering a packet twice did not panic")
+		}
+	}()
+	r.Register(StateLogin, Serverbound, AnyVersion, 0x00, newTestPacket(0x00))
+}
+
+func TestRegistryDecode(t *testing.T) {
+	r := NewRegistry()
+	r.RegisterIDs(StatePlay, Serverbound, VersionIDs{0: 0x05}, newTestPacket(0x05))
+
+	want := &testPacket{id: 0x05, Value: 300}
+	frame, err := Encode(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(frame, []byte{0x05, 0xac, 0x02}) {
+		t.Errorf("Encode = % x", frame)
+	}
+	got, err := r.Decode(StatePlay, Serverbound, Protocol1_21, frame)
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("Decode = %+v, %v, want %+v", got, err, want)
+	}
+
+	tests := []struct {
+		name  string
+		frame []byte
+	}{
+		{"empty", nil},
+		{"unknown ID", []byte{0x06, 0x00}},
+		{"truncated body", []byte{0x05, 0x80}},
+		{"trailing bytes", []byte{0x05, 0x01, 0x00}},
+	}
+	for _, tt := range tests {
+		if p, err := r.Decode(StatePlay, Serverbound, Protocol1_21, tt.frame); err == nil {
+			t.Errorf("%s: Decode = %+v, want error", tt.name, p)
+		}
+	}
+}
+
+func TestHandshakeRoundTrip(t *testing.T) {
+	want := &Handshake{
+		ProtocolVersion: types.VarInt(Protocol1_21),
+		ServerAddress:   types.String{Value: "localhost"},
+		ServerPort:      25565,
+		NextState:       2,
+	}
+	frame, err := Encode(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Decode(StateHandshake, Serverbound, Protocol1_21, frame)
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("Decode = %+v, %v, want %+v", got, err, want)
+	}
+}
+
+// versionedTestPacket records the protocol version it was coded with
+type versionedTestPacket struct {
+	version int32
+}
+
+func (p *versionedTestPacket) ID() int32 { return 0x10 }
+
+func (p *versionedTestPacket) Encode(w io.Writer) error { return p.EncodeVersion(w, LatestProtocol) }
+
+func (p *versionedTestPacket) Decode(r io.Reader) error { return p.DecodeVersion(r, LatestProtocol) }
+
+func (p *versionedTestPacket) EncodeVersion(w io.Writer, version int32) error {
+	p.version = version
+	return nil
+}
+
+func (p *versionedTestPacket) DecodeVersion(r io.Reader, version int32) error {
+	p.version = version
+	return nil
+}
+
+func TestRegistryVersionedIDs(t *testing.T) {
+	r := NewRegistry()
+	ids := VersionIDs{Protocol1_20_2: 0x10, Protocol1_21: 0x11}
+	r.RegisterIDs(StatePlay, Clientbound, ids, func() Packet { return &versionedTestPacket{} })
+
+	tests := []struct {
+		name        string
+		version     int32
+		wantID      int32 // -1 if the packet does not exist
+		wantVersion int32 // the version the packet is coded with
+	}{
+		{"before the packet existed", Protocol1_20, -1, 0},
+		{"first version", Protocol1_20_2, 0x10, Protocol1_20_2},
+		{"ID changed", Protocol1_21, 0x11, Protocol1_21},
+		{"latest", LatestProtocol, 0x11, LatestProtocol},
+		{"unsupported older version", 700, 0x11, LatestProtocol},
+		{"unsupported newer version", 9999, 0x11, LatestProtocol},
+		{"AnyVersion", AnyVersion, 0x11, LatestProtocol},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &versionedTestPacket{}
+			id, ok := r.PacketID(StatePlay, Clientbound, tt.version, p)
+			frame, err := r.Encode(StatePlay, Clientbound, tt.version, p)
+			if tt.wantID < 0 {
+				if ok || err == nil {
+					t.Errorf("PacketID = 0x%02X, %v and Encode = %v, want no packet", id, ok, err)
+				}
+				return
+			}
+			if !ok || id != tt.wantID {
+				t.Errorf("PacketID = 0x%02X, %v, want 0x%02X", id, ok, tt.wantID)
+			}
+			if err != nil || !bytes.Equal(frame, []byte{byte(tt.wantID)}) || p.version != tt.wantVersion {
+				t.Fatalf("Encode = % x, %v with protocol %d, want %02x with protocol %d", frame, err, p.version, tt.wantID, tt.wantVersion)
+			}
+
+			got, err := r.Decode(StatePlay, Clientbound, tt.version, frame)
+			if err != nil || got.(*versionedTestPacket).version != tt.wantVersion {
+				t.Errorf("Decode = %+v, %v, want protocol %d", got, err, tt.wantVersion)
+			}
+		})
+	}
+
+	// Packets the registry does not know keep their own ID
+	if id, ok := r.PacketID(StatePlay, Clientbound, Protocol1_21, &testPacket{id: 0x20}); !ok || id != 0x20 {
+		t.Errorf("PacketID of an unregistered packet = 0x%02X, %v", id, ok)
+	}
+}
@@ -0,0 +1,186 @@
+// Code generated by packetgen from schema/packets.json. DO NOT EDIT.
+
+package packet
+
+import (
+	"io"
+
+	"mc-proxy/protocol/types"
+)
+
+// ClientboundConfigurationKeepAlive must be echoed by the client during configuration
+type ClientboundConfigurationKeepAlive struct {
+	KeepAliveID types.Long
+}
+
+func (p *ClientboundConfigurationKeepAlive) ID() int32 { return 0x04 }
+
+func (p *ClientboundConfigurationKeepAlive) Encode(w io.Writer) error {
+	if err := types.WriteLong(p.KeepAliveID, w); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (p *ClientboundConfigurationKeepAlive) Decode(r io.Reader) error {
+	{
+		v, err := types.ReadLong(r)
+		if err != nil {
+			return err
+		}
+		p.KeepAliveID = v
+	}
+	return nil
+}
+
+type ServerboundConfigurationKeepAlive struct {
+	KeepAliveID types.Long // echoes the ID of the clientbound keep alive
+}
+
+func (p *ServerboundConfigurationKeepAlive) ID() int32 { return 0x04 }
+
+func (p *ServerboundConfigurationKeepAlive) Encode(w io.Writer) error {
+	if err := types.WriteLong(p.KeepAliveID, w); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (p *ServerboundConfigurationKeepAlive) Decode(r io.Reader) error {
+	{
+		v, err := types.ReadLong(r)
+		if err != nil {
+			return err
+		}
+		p.KeepAliveID = v
+	}
+	return nil
+}
+
+// ClientboundKeepAlive must be echoed by the client during play
+type ClientboundKeepAlive struct {
+	KeepAliveID types.Long
+}
+
+func (p *ClientboundKeepAlive) ID() int32 { return 0x27 }
+
+func (p *ClientboundKeepAlive) Encode(w io.Writer) error {
+	if err := types.WriteLong(p.KeepAliveID, w); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (p *ClientboundKeepAlive) Decode(r io.Reader) error {
+	{
+		v, err := types.ReadLong(r)
+		if err != nil {
+			return err
+		}
+		p.KeepAliveID = v
+	}
+	return nil
+}
+
+type ServerboundKeepAlive struct {
+	KeepAliveID types.Long // echoes the ID of the clientbound keep alive
+}
+
+func (p *ServerboundKeepAlive) ID() int32 { return 0x1A }
+
+func (p *ServerboundKeepAlive) Encode(w io.Writer) error {
+	if err := types.WriteLong(p.KeepAliveID, w); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (p *ServerboundKeepAlive) Decode(r io.Reader) error {
+	{
+		v, err := types.ReadLong(r)
+		if err != nil {
+			return err
+		}
+		p.KeepAliveID = v
+	}
+	return nil
+}
+
+type ClientboundPlayPluginMessage struct {
+	Channel types.String
+	Data    []byte // rest of the packet
+}
+
+func (p *ClientboundPlayPluginMessage) ID() int32 { return 0x19 }
+
+func (p *ClientboundPlayPluginMessage) Encode(w io.Writer) error {
+	if err := types.WriteString(p.Channel, w); err != nil {
+		return err
+	}
+	if _, err := w.Write(p.Data); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (p *ClientboundPlayPluginMessage) Decode(r io.Reader) error {
+	{
+		v, err := types.ReadString(r)
+		if err != nil {
+			return err
+		}
+		p.Channel = v
+	}
+	{
+		v, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		p.Data = v
+	}
+	return nil
+}
+
+type ServerboundPlayPluginMessage struct {
+	Channel types.String
+	Data    []byte // rest of the packet
+}
+
+func (p *ServerboundPlayPluginMessage) ID() int32 { return 0x14 }
+
+func (p *ServerboundPlayPluginMessage) Encode(w io.Writer) error {
+	if err := types.WriteString(p.Channel, w); err != nil {
+		return err
+	}
+	if _, err := w.Write(p.Data); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (p *ServerboundPlayPluginMessage) Decode(r io.Reader) error {
+	{
+		v, err := types.ReadString(r)
+		if err != nil {
+			return err
+		}
+		p.Channel = v
+	}
+	{
+		v, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		p.Data = v
+	}
+	return nil
+}
+
+func init() {
+	RegisterVersionedPacket(StateConfiguration, Clientbound, VersionIDs{Protocol1_20_2: 0x03, Protocol1_20_5: 0x04}, func() Packet { return &ClientboundConfigurationKeepAlive{} })
+	RegisterVersionedPacket(StateConfiguration, Serverbound, VersionIDs{Protocol1_20_2: 0x03, Protocol1_20_5: 0x04}, func() Packet { return &ServerboundConfigurationKeepAlive{} })
+	RegisterVersionedPacket(StatePlay, Clientbound, VersionIDs{Protocol1_19_4: 0x23, Protocol1_20_2: 0x24, Protocol1_20_5: 0x26, Protocol1_21_2: 0x27}, func() Packet { return &ClientboundKeepAlive{} })
+	RegisterVersionedPacket(StatePlay, Serverbound, VersionIDs{Protocol1_19_4: 0x12, Protocol1_20_2: 0x14, Protocol1_20_3: 0x15, Protocol1_20_5: 0x18, Protocol1_21_2: 0x1A}, func() Packet { return &ServerboundKeepAlive{} })
+	RegisterVersionedPacket(StatePlay, Clientbound, VersionIDs{Protocol1_19_4: 0x17, Protocol1_20_2: 0x18, Protocol1_20_5: 0x19}, func() Packet { return &ClientboundPlayPluginMessage{} })
+	RegisterVersionedPacket(StatePlay, Serverbound, VersionIDs{Protocol1_19_4: 0x0D, Protocol1_20_2: 0x0F, Protocol1_20_3: 0x10, Protocol1_20_5: 0x12, Protocol1_21_2: 0x14}, func() Packet { return &ServerboundPlayPluginMessage{} })
+}
@@ -0,0 +1,144 @@
+// Code generated by packetgen from schema/packets.json. DO NOT EDIT.
+
+package packet
+
+import (
+	"reflect"
+	"testing"
+
+	"mc-proxy/protocol/types"
+)
+
+func TestClientboundConfigurationKeepAliveRoundTrip(t *testing.T) {
+	for _, version := range Protocols() {
+		want := &ClientboundConfigurationKeepAlive{
+			KeepAliveID: types.Long(-1234567890123),
+		}
+		if _, ok := DefaultRegistry.PacketID(StateConfiguration, Clientbound, version, want); !ok {
+			continue
+		}
+		frame, err := DefaultRegistry.Encode(StateConfiguration, Clientbound, version, want)
+		if err != nil {
+			t.Fatalf("protocol %d: encode: %v", version, err)
+		}
+		got, err := DefaultRegistry.Decode(StateConfiguration, Clientbound, version, frame)
+		if err != nil {
+			t.Fatalf("protocol %d: decode: %v", version, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("protocol %d: got %+v, want %+v", version, got, want)
+		}
+	}
+}
+
+func TestServerboundConfigurationKeepAliveRoundTrip(t *testing.T) {
+	for _, version := range Protocols() {
+		want := &ServerboundConfigurationKeepAlive{
+			KeepAliveID: types.Long(-1234567890123),
+		}
+		if _, ok := DefaultRegistry.PacketID(StateConfiguration, Serverbound, version, want); !ok {
+			continue
+		}
+		frame, err := DefaultRegistry.Encode(StateConfiguration, Serverbound, version, want)
+		if err != nil {
+			t.Fatalf("protocol %d: encode: %v", version, err)
+		}
+		got, err := DefaultRegistry.Decode(StateConfiguration, Serverbound, version, frame)
+		if err != nil {
+			t.Fatalf("protocol %d: decode: %v", version, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("protocol %d: got %+v, want %+v", version, got, want)
+		}
+	}
+}
+
+func TestClientboundKeepAliveRoundTrip(t *testing.T) {
+	for _, version := range Protocols() {
+		want := &ClientboundKeepAlive{
+			KeepAliveID: types.Long(-1234567890123),
+		}
+		if _, ok := DefaultRegistry.PacketID(StatePlay, Clientbound, version, want); !ok {
+			continue
+		}
+		frame, err := DefaultRegistry.Encode(StatePlay, Clientbound, version, want)
+		if err != nil {
+			t.Fatalf("protocol %d: encode: %v", version, err)
+		}
+		got, err := DefaultRegistry.Decode(StatePlay, Clientbound, version, frame)
+		if err != nil {
+			t.Fatalf("protocol %d: decode: %v", version, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("protocol %d: got %+v, want %+v", version, got, want)
+		}
+	}
+}
+
+func TestServerboundKeepAliveRoundTrip(t *testing.T) {
+	for _, version := range Protocols() {
+		want := &ServerboundKeepAlive{
+			KeepAliveID: types.Long(-1234567890123),
+		}
+		if _, ok := DefaultRegistry.PacketID(StatePlay, Serverbound, version, want); !ok {
+			continue
+		}
+		frame, err := DefaultRegistry.Encode(StatePlay, Serverbound, version, want)
+		if err != nil {
+			t.Fatalf("protocol %d: encode: %v", version, err)
+		}
+		got, err := DefaultRegistry.Decode(StatePlay, Serverbound, version, frame)
+		if err != nil {
+			t.Fatalf("protocol %d: decode: %v", version, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("protocol %d: got %+v, want %+v", version, got, want)
+		}
+	}
+}
+
+func TestClientboundPlayPluginMessageRoundTrip(t *testing.T) {
+	for _, version := range Protocols() {
+		want := &ClientboundPlayPluginMessage{
+			Channel: types.String(types.String{Value: "minecraft:brand"}),
+			Data:    []byte{4, 5, 6},
+		}
+		if _, ok := DefaultRegistry.PacketID(StatePlay, Clientbound, version, want); !ok {
+			continue
+		}
+		frame, err := DefaultRegistry.Encode(StatePlay, Clientbound, version, want)
+		if err != nil {
+			t.Fatalf("protocol %d: encode: %v", version, err)
+		}
+		got, err := DefaultRegistry.Decode(StatePlay, Clientbound, version, frame)
+		if err != nil {
+			t.Fatalf("protocol %d: decode: %v", version, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("protocol %d: got %+v, want %+v", version, got, want)
+		}
+	}
+}
+
+func TestServerboundPlayPluginMessageRoundTrip(t *testing.T) {
+	for _, version := range Protocols() {
+		want := &ServerboundPlayPluginMessage{
+			Channel: types.String(types.String{Value: "minecraft:brand"}),
+			Data:    []byte{4, 5, 6},
+		}
+		if _, ok := DefaultRegistry.PacketID(StatePlay, Serverbound, version, want); !ok {
+			continue
+		}
+		frame, err := DefaultRegistry.Encode(StatePlay, Serverbound, version, want)
+		if err != nil {
+			t.Fatalf("protocol %d: encode: %v", version, err)
+		}
+		got, err := DefaultRegistry.Decode(StatePlay, Serverbound, version, frame)
+		if err != nil {
+			t.Fatalf("protocol %d: decode: %v", version, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("protocol %d: got %+v, want %+v", version, got, want)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package packet
+
+import (
+	"io"
+
+	"mc-proxy/protocol/types"
+)
+
+// PlayDisconnect kicks the client during play
+type PlayDisconnect struct {
+	Reason types.Chat
+}
+
+func (p *PlayDisconnect) ID() int32 { return 0x1D }
+
+func (p *PlayDisconnect) Encode(w io.Writer) error {
+	return p.EncodeVersion(w, LatestProtocol)
+}
+
+func (p *PlayDisconnect) Decode(r io.Reader) error {
+	return p.DecodeVersion(r, LatestProtocol)
+}
+
+func (p *PlayDisconnect) EncodeVersion(w io.Writer, version int32) error {
+	return WriteChat(p.Reason, version, w)
+}
+
+func (p *PlayDisconnect) DecodeVersion(r io.Reader, version int32) error {
+	var err error
+	p.Reason, err = ReadChat(r, version)
+	return err
+}
+
+// StartConfiguration asks the client to switch back to the configuration state
+type StartConfiguration struct{}
+
+func (p *StartConfiguration) ID() int32 { return 0x70 }
+
+func (p *StartConfiguration) Encode(w io.Writer) error { return nil }
+
+func (p *StartConfiguration) Decode(r io.Reader) error { return nil }
+
+// ConfigurationAcknowledged switches the connection to the configuration state
+type ConfigurationAcknowledged struct{}
+
+func (p *ConfigurationAcknowledged) ID() int32 { return 0x0E }
+
+func (p *ConfigurationAcknowledged) Encode(w io.Writer) error { return nil }
+
+func (p *ConfigurationAcknowledged) Decode(r io.Reader) error { return nil }
+
+func init() {
+	RegisterVersionedPacket(StatePlay, Clientbound, VersionIDs{Protocol1_19_4: 0x1A, Protocol1_20_2: 0x1B, Protocol1_20_5: 0x1D}, func() Packet { return &PlayDisconnect{} })
+	RegisterVersionedPacket(StatePlay, Clientbound, VersionIDs{Protocol1_20_2: 0x65, Protocol1_20_3: 0x67, Protocol1_20_5: 0x69, Protocol1_21_2: 0x70}, func() Packet { return &StartConfiguration{} })
+	RegisterVersionedPacket(StatePlay, Serverbound, VersionIDs{Protocol1_19_4: 0x08, Protocol1_20_2: 0x09, Protocol1_20_5: 0x0A, Protocol1_21_2: 0x0C}, func() Packet { return &ClientInformation{} })
+	RegisterVersionedPacket(StatePlay, Serverbound, VersionIDs{Protocol1_20_2: 0x0B, Protocol1_20_5: 0x0C, Protocol1_21_2: 0x0E}, func() Packet { return &ConfigurationAcknowledged{} })
+}
@@ -0,0 +1,112 @@
+package packet
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"mc-proxy/protocol/types"
+)
+
+// ServerStatus is the server list entry sent in the Status Response
+type ServerStatus struct {
+	Version            StatusVersion  `json:"version"`
+	Players            *StatusPlayers `json:"players,omitempty"`
+	Description        types.Chat     `json:"description"`
+	Favicon            string         `json:"favicon,omitempty"`
+	EnforcesSecureChat bool           `json:"enforcesSecureChat,omitempty"`
+}
+
+type StatusVersion struct {
+	Name     string `json:"name"`
+	Protocol int32  `json:"protocol"`
+}
+
+type StatusPlayers struct {
+	Max    int            `json:"max"`
+	Online int            `json:"online"`
+	Sample []StatusPlayer `json:"sample,omitempty"`
+}
+
+type StatusPlayer struct {
+	Name string `json:"name"`
+	ID   string `json:"id"`
+}
+
+// FaviconFromPNG encodes a 64x64 PNG image as a favicon data URI
+func FaviconFromPNG(png []byte) string {
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(png)
+}
+
+type StatusRequest struct{}
+
+func (p *StatusRequest) ID() int32 { return 0x00 }
+
+func (p *StatusRequest) Encode(w io.Writer) error { return nil }
+
+func (p *StatusRequest) Decode(r io.Reader) error { return nil }
+
+type StatusResponse struct {
+	Status ServerStatus
+}
+
+func (p *StatusResponse) ID() int32 { return 0x00 }
+
+func (p *StatusResponse) Encode(w io.Writer) error {
+	jsonBytes, err := json.Marshal(p.Status)
+	if err != nil {
+		return fmt.Errorf("failed to marshal status: %v", err)
+	}
+	return types.WriteString(types.String{Value: string(jsonBytes)}, w)
+}
+
+func (p *StatusResponse) Decode(r io.Reader) error {
+	str, err := types.ReadString(r)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal([]byte(str.Value), &p.Status); err != nil {
+		return fmt.Errorf("failed to unmarshal status: %v", err)
+	}
+	return nil
+}
+
+type PingRequest struct {
+	Payload types.Long
+}
+
+func (p *PingRequest) ID() int32 { return 0x01 }
+
+func (p *PingRequest) Encode(w io.Writer) error {
+	return types.WriteLong(p.Payload, w)
+}
+
+func (p *PingRequest) Decode(r io.Reader) error {
+	var err error
+	p.Payload, err = types.ReadLong(r)
+	return err
+}
+
+type PongResponse struct {
+	Payload types.Long // echoes the payload of the Ping Request
+}
+
+func (p *PongResponse) ID() int32 { return 0x01 }
+
+func (p *PongResponse) Encode(w io.Writer) error {
+	return types.WriteLong(p.Payload, w)
+}
+
+func (p *PongResponse) Decode(r io.Reader) error {
+	var err error
+	p.Payload, err = types.ReadLong(r)
+	return err
+}
+
+func init() {
+	RegisterPacket(StateStatus, Serverbound, func() Packet { return &StatusRequest{} })
+	RegisterPacket(StateStatus, Clientbound, func() Packet { return &StatusResponse{} })
+	RegisterPacket(StateStatus, Serverbound, func() Packet { return &PingRequest{} })
+	RegisterPacket(StateStatus, Clientbound, func() Packet { return &PongResponse{} })
+}
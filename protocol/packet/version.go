@@ -0,0 +1,115 @@
+package packet
+
+import "sort"
+
+// Protocol versions of the releases supported by this package
+const (
+	Protocol1_19_4 int32 = 762
+	Protocol1_20   int32 = 763 // 1.20 and 1.20.1
+	Protocol1_20_2 int32 = 764
+	Protocol1_20_3 int32 = 765 // 1.20.3 and 1.20.4
+	Protocol1_20_5 int32 = 766 // 1.20.5 and 1.20.6
+	Protocol1_21   int32 = 767 // 1.21 and 1.21.1
+	Protocol1_21_2 int32 = 768 // 1.21.2 and 1.21.3
+	Protocol1_21_4 int32 = 769
+
+	// LatestProtocol is the newest supported protocol version. Packets are
+	// encoded in its format when no version is known.
+	LatestProtocol = Protocol1_21_4
+)
+
+// Version is a game release and the protocol version it speaks
+type Version struct {
+	Name     string
+	Protocol int32
+}
+
+// Versions lists all supported releases, oldest first
+var Versions = []Version{
+	{"1.19.4", Protocol1_19_4},
+	{"1.20", Protocol1_20},
+	{"1.20.1", Protocol1_20},
+	{"1.20.2", Protocol1_20_2},
+	{"1.20.3", Protocol1_20_3},
+	{"1.20.4", Protocol1_20_3},
+	{"1.20.5", Protocol1_20_5},
+	{"1.20.6", Protocol1_20_5},
+	{"1.21", Protocol1_21},
+	{"1.21.1", Protocol1_21},
+	{"1.21.2", Protocol1_21_2},
+	{"1.21.3", Protocol1_21_2},
+	{"1.21.4", Protocol1_21_4},
+}
+
+// VersionByName returns the release with the given name, such as "1.20.4"
+func VersionByName(name string) (Version, bool) {
+	for _, version := range Versions {
+		if version.Name == name {
+			return version, true
+		}
+	}
+	return Version{}, false
+}
+
+// VersionByProtocol returns the newest release speaking the protocol version
+func VersionByProtocol(protocol int32) (Version, bool) {
+	for i := len(Versions) - 1; i >= 0; i-- {
+		if Versions[i].Protocol == protocol {
+			return Versions[i], true
+		}
+	}
+	return Version{}, false
+}
+
+// IsSupported reports whether the protocol version is in the versions table
+func IsSupported(protocol int32) bool {
+	_, ok := VersionByProtocol(protocol)
+	return ok
+}
+
+// Protocols returns the distinct supported protocol versions, oldest first
+func Protocols() []int32 {
+	var protocols []int32
+	for _, version := range Versions {
+		if len(protocols) == 0 || protocols[len(protocols)-1] != version.Protocol {
+			protocols = append(protocols, version.Protocol)
+		}
+	}
+	return protocols
+}
+
+// VersionIDs maps the first protocol version using a packet ID to that ID.
+// A packet does not exist in versions older than the smallest key.
+type VersionIDs map[int32]int32
+
+// Since returns a mapping for a packet that kept its ID since the given version
+func Since(version int32, id int32) VersionIDs {
+	return VersionIDs{version: id}
+}
+
+// lookup returns the ID used by the protocol version
+func (ids VersionIDs) lookup(version int32) (int32, bool) {
+	since := make([]int32, 0, len(ids))
+	for v := range ids {
+		since = append(since, v)
+	}
+	sort.Slice(since, func(i, j int) bool { return since[i] < since[j] })
+
+	// Find the newest mapping that is not newer than the version
+	i := sort.Search(len(since), func(i int) bool { return since[i] > version })
+	if i == 0 {
+		return 0, false
+	}
+	return ids[since[i-1]], true
+}
+
+// normalizeVersion maps AnyVersion and every protocol version missing from
+// the versions table, older or newer, to LatestProtocol. The registry uses
+// the AnyVersion IDs for the same versions, which are those of LatestProtocol,
+// so their packets are encoded and decoded entirely in the newest format.
+func normalizeVersion(version int32) int32 {
+	if !IsSupported(version) {
+		return LatestProtocol
+	}
+	return version
+}
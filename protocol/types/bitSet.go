@@ -0,0 +1,159 @@
+package types
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// BitSet is a set of bits stored in longs, bit i being bit i%64 of long i/64.
+// It is encoded as a VarInt count of longs followed by the longs.
+type BitSet []int64
+
+// Get reports whether bit i is set
+func (b BitSet) Get(i int) bool {
+	if i < 0 || i/64 >= len(b) {
+		return false
+	}
+	return b[i/64]&(1<<(i%64)) != 0
+}
+
+// Set sets or clears bit i, growing the set if needed. Negative indices are
+// ignored, like they read as unset in Get.
+func (b *BitSet) Set(i int, value bool) {
+	if i < 0 {
+		return
+	}
+	if i/64 >= len(*b) {
+		if !value {
+			return
+		}
+		*b = append(*b, make(BitSet, i/64+1-len(*b))...)
+	}
+	if value {
+		(*b)[i/64] |= 1 << (i % 64)
+	} else {
+		(*b)[i/64] &^= 1 << (i % 64)
+	}
+}
+
+// Len returns the index of the highest set bit plus one
+func (b BitSet) Len() int {
+	for i := len(b) - 1; i >= 0; i-- {
+		if b[i] != 0 {
+			for bit := 63; ; bit-- {
+				if b[i]&(1<<bit) != 0 {
+					return i*64 + bit + 1
+				}
+			}
+		}
+	}
+	return 0
+}
+
+// Marshal encodes the set without trailing zero longs, like the game does
+func (b BitSet) Marshal() ([]byte, error) {
+	longs := (b.Len() + 63) / 64
+	buf := make([]byte, VarIntSize(VarInt(longs)), VarIntSize(VarInt(longs))+longs*8)
+	PutVarInt(buf, VarInt(longs))
+	for _, long := range b[:longs] {
+		buf = binary.BigEndian.AppendUint64(buf, uint64(long))
+	}
+	return buf, nil
+}
+
+func (b *BitSet) Unmarshal(data []byte) error {
+	return unmarshalWith(b, data)
+}
+
+func (b *BitSet) Decode(r io.Reader) (err error) {
+	*b, err = ReadBitSet(r)
+	return err
+}
+
+func WriteBitSet(b BitSet, w io.Writer) error {
+	buf, err := b.Marshal()
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(buf)
+	return err
+}
+
+func ReadBitSet(r io.Reader) (BitSet, error) {
+	count, err := readArrayLength(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bit set: %v", err)
+	}
+
+	var b BitSet
+	for i := 0; i < count; i++ {
+		long, err := ReadLong(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read bit set: %v", err)
+		}
+		b = append(b, int64(long))
+	}
+	return b, nil
+}
+
+// FixedBitSet is a set of a known number of bits, encoded without a length
+// as ceil(bits/8) bytes, bit i being bit i%8 of byte i/8
+type FixedBitSet []byte
+
+// NewFixedBitSet returns an empty set of the given number of bits
+func NewFixedBitSet(bits int) FixedBitSet {
+	return make(FixedBitSet, (bits+7)/8)
+}
+
+// Get reports whether bit i is set
+func (b FixedBitSet) Get(i int) bool {
+	if i < 0 || i/8 >= len(b) {
+		return false
+	}
+	return b[i/8]&(1<<(i%8)) != 0
+}
+
+// Set sets or clears bit i, which must be within the set
+func (b FixedBitSet) Set(i int, value bool) {
+	if value {
+		b[i/8] |= 1 << (i % 8)
+	} else {
+		b[i/8] &^= 1 << (i % 8)
+	}
+}
+
+func (b FixedBitSet) Marshal() ([]byte, error) {
+	return append([]byte(nil), b...), nil
+}
+
+// Unmarshal fills the set from data, keeping its size
+func (b *FixedBitSet) Unmarshal(data []byte) error {
+	return unmarshalWith(b, data)
+}
+
+// Decode fills the set, keeping its size. As the size is not encoded, the
+// set must be created with NewFixedBitSet first; decoding into a nil set
+// fails.
+func (b *FixedBitSet) Decode(r io.Reader) error {
+	if *b == nil {
+		return fmt.Errorf("failed to read fixed bit set: size unknown, create the set with NewFixedBitSet")
+	}
+	if _, err := io.ReadFull(r, *b); err != nil {
+		return fmt.Errorf("failed to read fixed bit set: %v", err)
+	}
+	return nil
+}
+
+func WriteFixedBitSet(b FixedBitSet, w io.Writer) error {
+	_, err := w.Write(b)
+	return err
+}
+
+func ReadFixedBitSet(r io.Reader, bits int) (FixedBitSet, error) {
+	b := NewFixedBitSet(bits)
+	if err := b.Decode(r); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
@@ -0,0 +1,102 @@
+package types
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestBitSet(t *testing.T) {
+	var b BitSet
+	b.Set(0, true)
+	b.Set(65, true)
+	b.Set(200, false) // clearing past the end does not grow the set
+	b.Set(-1, true)
+	b.Set(-64, true)
+
+	if !reflect.DeepEqual(b, BitSet{1, 2}) {
+		t.Fatalf("bits = %v, want [1 2]", b)
+	}
+	for i, want := range map[int]bool{0: true, 1: false, 65: true, 64: false, -1: false, 1000: false} {
+		if got := b.Get(i); got != want {
+			t.Errorf("Get(%d) = %v, want %v", i, got, want)
+		}
+	}
+	if b.Len() != 66 {
+		t.Errorf("Len = %d, want 66", b.Len())
+	}
+
+	b.Set(65, false)
+	if b.Len() != 1 {
+		t.Errorf("Len after clearing = %d, want 1", b.Len())
+	}
+}
+
+func TestBitSetWire(t *testing.T) {
+	tests := []struct {
+		name string
+		b    BitSet
+		want []byte
+	}{
+		{"empty", nil, []byte{0}},
+		{"one long", BitSet{-1}, []byte{1, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}},
+		{"trailing zeros trimmed", BitSet{1, 0, 0}, []byte{1, 0, 0, 0, 0, 0, 0, 0, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := tt.b.Marshal()
+			if err != nil || !bytes.Equal(data, tt.want) {
+				t.Errorf("Marshal = % x, %v, want % x", data, err, tt.want)
+			}
+			got, err := ReadBitSet(bytes.NewReader(tt.want))
+			if err != nil || got.Len() != tt.b.Len() || !reflect.DeepEqual(got, tt.b[:len(got)]) {
+				t.Errorf("ReadBitSet = %v, %v, want %v", got, err, tt.b)
+			}
+		})
+	}
+
+	for _, data := range [][]byte{nil, {0xff, 0xff, 0xff, 0xff, 0x0f}, {2, 0, 0, 0, 0, 0, 0, 0, 1}} {
+		var b BitSet
+		if err := b.Unmarshal(data); err == nil {
+			t.Errorf("Unmarshal(% x) = %v, want error", data, b)
+		}
+	}
+}
+
+func TestFixedBitSet(t *testing.T) {
+	b := NewFixedBitSet(12)
+	b.Set(0, true)
+	b.Set(11, true)
+	b.Set(11, false)
+	b.Set(9, true)
+	if want := (FixedBitSet{0x01, 0x02}); !bytes.Equal(b, want) {
+		t.Fatalf("bits = % x, want % x", b, want)
+	}
+	if !b.Get(9) || b.Get(11) || b.Get(-1) || b.Get(16) {
+		t.Errorf("Get returned wrong bits for % x", b)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteFixedBitSet(b, &buf); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadFixedBitSet(&buf, 12)
+	if err != nil || !bytes.Equal(got, b) {
+		t.Errorf("ReadFixedBitSet = % x, %v, want % x", got, err, b)
+	}
+
+	empty := NewFixedBitSet(0)
+	if err := empty.Unmarshal(nil); err != nil {
+		t.Errorf("Unmarshal of a set of zero bits: %v", err)
+	}
+}
+
+func TestFixedBitSetErrors(t *testing.T) {
+	var b FixedBitSet
+	if err := b.Unmarshal([]byte{0xff}); err == nil {
+		t.Error("Unmarshal into a nil set succeeded")
+	}
+	if _, err := ReadFixedBitSet(bytes.NewReader([]byte{1}), 9); err == nil {
+		t.Error("ReadFixedBitSet of truncated data succeeded")
+	}
+}
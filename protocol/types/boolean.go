@@ -1,5 +1,10 @@
 package types
 
+import (
+	"fmt"
+	"io"
+)
+
 type Boolean bool
 
 func (b Boolean) Marshal() ([]byte, error) {
@@ -10,6 +15,36 @@ func (b Boolean) Marshal() ([]byte, error) {
 }
 
 func (b *Boolean) Unmarshal(data []byte) error {
+	if len(data) < 1 {
+		return fmt.Errorf("boolean data too short")
+	}
 	*b = data[0] == 1
 	return nil
 }
+
+func WriteBoolean(b Boolean, w io.Writer) error {
+	buf, err := b.Marshal()
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(buf)
+	return err
+}
+
+func ReadBoolean(r io.Reader) (Boolean, error) {
+	buf := make([]byte, 1)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return false, fmt.Errorf("failed to read boolean: %v", err)
+	}
+
+	var b Boolean
+	if err := b.Unmarshal(buf); err != nil {
+		return false, err
+	}
+	return b, nil
+}
+
+func (b *Boolean) Decode(r io.Reader) (err error) {
+	*b, err = ReadBoolean(r)
+	return err
+}
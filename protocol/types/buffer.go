@@ -0,0 +1,395 @@
+package types
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"sync"
+)
+
+// MaxVarIntSize and MaxVarLongSize are the largest encoded sizes
+const (
+	MaxVarIntSize  = 5
+	MaxVarLongSize = 10
+)
+
+// VarIntSize returns the number of bytes needed to encode v
+func VarIntSize(v VarInt) int {
+	return VarLongSize(VarLong(uint32(v)))
+}
+
+// VarLongSize returns the number of bytes needed to encode v
+func VarLongSize(v VarLong) int {
+	size := 1
+	for value := uint64(v) >> 7; value != 0; value >>= 7 {
+		size++
+	}
+	return size
+}
+
+// PutVarInt encodes v into buf, which must be large enough, and returns the
+// number of bytes written
+func PutVarInt(buf []byte, v VarInt) int {
+	return putVarUint(buf, uint64(uint32(v)))
+}
+
+// PutVarLong encodes v into buf, which must be large enough, and returns the
+// number of bytes written
+func PutVarLong(buf []byte, v VarLong) int {
+	return putVarUint(buf, uint64(v))
+}
+
+func putVarUint(buf []byte, value uint64) int {
+	i := 0
+	for value >= 0x80 {
+		buf[i] = byte(value) | 0x80
+		value >>= 7
+		i++
+	}
+	buf[i] = byte(value)
+	return i + 1
+}
+
+// Buffer is a byte slice that is appended to when writing and consumed from
+// the front when reading. Unlike the Marshal methods and the Read functions,
+// its methods do not allocate once the storage has grown to fit.
+//
+// The zero value is an empty buffer ready to use. Buffers from GetBuffer are
+// pooled and should be returned with PutBuffer once their contents are no
+// longer used.
+type Buffer struct {
+	data []byte
+	off  int // read position
+}
+
+// maxPooledBufferSize keeps rare huge packets from pinning memory in the pool
+const maxPooledBufferSize = 1 << 20
+
+var bufferPool = sync.Pool{
+	New: func() any { return &Buffer{data: make([]byte, 0, 512)} },
+}
+
+// GetBuffer returns an empty buffer from the pool
+func GetBuffer() *Buffer {
+	return bufferPool.Get().(*Buffer)
+}
+
+// PutBuffer resets a buffer and returns it to the pool. Slices obtained from
+// the buffer must not be used afterwards.
+func PutBuffer(b *Buffer) {
+	if cap(b.data) > maxPooledBufferSize {
+		return
+	}
+	b.Reset()
+	bufferPool.Put(b)
+}
+
+// NewBuffer returns a buffer reading data, which it takes ownership of
+func NewBuffer(data []byte) *Buffer {
+	return &Buffer{data: data}
+}
+
+// Bytes returns the unread part of the buffer. It stays valid until the next
+// write or Reset.
+func (b *Buffer) Bytes() []byte {
+	return b.data[b.off:]
+}
+
+// Len returns the number of unread bytes
+func (b *Buffer) Len() int {
+	return len(b.data) - b.off
+}
+
+// Reset empties the buffer, keeping its storage
+func (b *Buffer) Reset() {
+	b.data = b.data[:0]
+	b.off = 0
+}
+
+// Extend appends n bytes to the buffer and returns them for the caller to
+// fill, for example with io.ReadFull
+func (b *Buffer) Extend(n int) []byte {
+	if cap(b.data)-len(b.data) < n {
+		grown := make([]byte, len(b.data), 2*cap(b.data)+n)
+		copy(grown, b.data)
+		b.data = grown
+	}
+	b.data = b.data[:len(b.data)+n]
+	return b.data[len(b.data)-n:]
+}
+
+func (b *Buffer) Write(p []byte) (int, error) {
+	copy(b.Extend(len(p)), p)
+	return len(p), nil
+}
+
+func (b *Buffer) WriteByte(c byte) error {
+	b.Extend(1)[0] = c
+	return nil
+}
+
+func (b *Buffer) Read(p []byte) (int, error) {
+	if b.Len() == 0 {
+		if len(p) == 0 {
+			return 0, nil
+		}
+		return 0, io.EOF
+	}
+	n := copy(p, b.data[b.off:])
+	b.off += n
+	return n, nil
+}
+
+func (b *Buffer) ReadByte() (byte, error) {
+	if b.Len() == 0 {
+		return 0, io.EOF
+	}
+	c := b.data[b.off]
+	b.off++
+	return c, nil
+}
+
+func (b *Buffer) PutBoolean(v Boolean) {
+	if v {
+		b.WriteByte(1)
+	} else {
+		b.WriteByte(0)
+	}
+}
+
+func (b *Buffer) PutByte(v Byte) {
+	b.WriteByte(byte(v))
+}
+
+func (b *Buffer) PutUnsignedByte(v UnsignedByte) {
+	b.WriteByte(byte(v))
+}
+
+func (b *Buffer) PutShort(v Short) {
+	binary.BigEndian.PutUint16(b.Extend(2), uint16(v))
+}
+
+func (b *Buffer) PutUnsignedShort(v UnsignedShort) {
+	binary.BigEndian.PutUint16(b.Extend(2), uint16(v))
+}
+
+func (b *Buffer) PutInt(v Int) {
+	binary.BigEndian.PutUint32(b.Extend(4), uint32(v))
+}
+
+func (b *Buffer) PutLong(v Long) {
+	binary.BigEndian.PutUint64(b.Extend(8), uint64(v))
+}
+
+func (b *Buffer) PutFloat(v Float) {
+	binary.BigEndian.PutUint32(b.Extend(4), math.Float32bits(float32(v)))
+}
+
+func (b *Buffer) PutDouble(v Double) {
+	binary.BigEndian.PutUint64(b.Extend(8), math.Float64bits(float64(v)))
+}
+
+func (b *Buffer) PutVarInt(v VarInt) {
+	PutVarInt(b.Extend(VarIntSize(v)), v)
+}
+
+func (b *Buffer) PutVarLong(v VarLong) {
+	PutVarLong(b.Extend(VarLongSize(v)), v)
+}
+
+// PutString appends a string without checking its length, which callers
+// encoding untrusted strings should do with UTF16Length
+func (b *Buffer) PutString(v String) {
+	b.PutVarInt(VarInt(len(v.Value)))
+	copy(b.Extend(len(v.Value)), v.Value)
+}
+
+// PutIdentifier appends an identifier without validating it
+func (b *Buffer) PutIdentifier(v Identifier) {
+	b.PutString(String{Value: v.String()})
+}
+
+func (b *Buffer) PutByteArray(v ByteArray) {
+	b.PutVarInt(VarInt(len(v)))
+	b.Write(v)
+}
+
+func (b *Buffer) PutUUID(v UUID) {
+	b.PutLong(Long(v.MostSignificantBits))
+	b.PutLong(Long(v.LeastSignificantBits))
+}
+
+func (b *Buffer) PutPosition(v Position) {
+	b.PutLong(Long(uint64(v.X&0x3FFFFFF)<<38 | uint64(v.Z&0x3FFFFFF)<<12 | uint64(v.Y&0xFFF)))
+}
+
+// next consumes n bytes, which share the buffer's storage
+func (b *Buffer) next(n int, what string) ([]byte, error) {
+	if n < 0 || b.Len() < n {
+		return nil, fmt.Errorf("failed to read %s: %v", what, io.ErrUnexpectedEOF)
+	}
+	p := b.data[b.off : b.off+n]
+	b.off += n
+	return p, nil
+}
+
+// GetBytes consumes n bytes without copying them
+func (b *Buffer) GetBytes(n int) ([]byte, error) {
+	return b.next(n, "bytes")
+}
+
+func (b *Buffer) GetBoolean() (Boolean, error) {
+	p, err := b.next(1, "boolean")
+	if err != nil {
+		return false, err
+	}
+	return p[0] == 1, nil
+}
+
+func (b *Buffer) GetByte() (Byte, error) {
+	p, err := b.next(1, "byte")
+	if err != nil {
+		return 0, err
+	}
+	return Byte(p[0]), nil
+}
+
+func (b *Buffer) GetUnsignedByte() (UnsignedByte, error) {
+	p, err := b.next(1, "unsigned byte")
+	if err != nil {
+		return 0, err
+	}
+	return UnsignedByte(p[0]), nil
+}
+
+func (b *Buffer) GetShort() (Short, error) {
+	p, err := b.next(2, "short")
+	if err != nil {
+		return 0, err
+	}
+	return Short(binary.BigEndian.Uint16(p)), nil
+}
+
+func (b *Buffer) GetUnsignedShort() (UnsignedShort, error) {
+	p, err := b.next(2, "unsigned short")
+	if err != nil {
+		return 0, err
+	}
+	return UnsignedShort(binary.BigEndian.Uint16(p)), nil
+}
+
+func (b *Buffer) GetInt() (Int, error) {
+	p, err := b.next(4, "int")
+	if err != nil {
+		return 0, err
+	}
+	return Int(binary.BigEndian.Uint32(p)), nil
+}
+
+func (b *Buffer) GetLong() (Long, error) {
+	p, err := b.next(8, "long")
+	if err != nil {
+		return 0, err
+	}
+	return Long(binary.BigEndian.Uint64(p)), nil
+}
+
+func (b *Buffer) GetFloat() (Float, error) {
+	p, err := b.next(4, "float")
+	if err != nil {
+		return 0, err
+	}
+	return Float(math.Float32frombits(binary.BigEndian.Uint32(p))), nil
+}
+
+func (b *Buffer) GetDouble() (Double, error) {
+	p, err := b.next(8, "double")
+	if err != nil {
+		return 0, err
+	}
+	return Double(math.Float64frombits(binary.BigEndian.Uint64(p))), nil
+}
+
+func (b *Buffer) GetVarInt() (VarInt, error) {
+	value, err := readVarUint(b, MaxVarIntSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read VarInt: %v", err)
+	}
+	return VarInt(value), nil
+}
+
+func (b *Buffer) GetVarLong() (VarLong, error) {
+	value, err := readVarUint(b, MaxVarLongSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read VarLong: %v", err)
+	}
+	return VarLong(value), nil
+}
+
+// GetString consumes a string of at most DefaultMaxStringLength. Only the
+// conversion to a Go string allocates.
+func (b *Buffer) GetString() (String, error) {
+	return b.GetStringMax(DefaultMaxStringLength)
+}
+
+// GetStringMax consumes a string like ReadStringMax
+func (b *Buffer) GetStringMax(max int) (String, error) {
+	length, err := b.GetVarInt()
+	if err != nil {
+		return String{}, fmt.Errorf("failed to read string length: %v", err)
+	}
+	if length < 0 {
+		return String{}, fmt.Errorf("string length is negative")
+	}
+	if int64(length) > int64(max)*3 {
+		return String{}, &StringTooLongError{Length: int(length), Max: max}
+	}
+	p, err := b.next(int(length), "string")
+	if err != nil {
+		return String{}, err
+	}
+	str := String{Value: string(p)}
+	if err := checkString(str.Value, max); err != nil {
+		return String{}, err
+	}
+	return str, nil
+}
+
+func (b *Buffer) GetIdentifier() (Identifier, error) {
+	s, err := b.GetString()
+	if err != nil {
+		return Identifier{}, fmt.Errorf("failed to read identifier: %v", err)
+	}
+	return ParseIdentifier(s.Value)
+}
+
+// GetByteArray consumes a byte array without copying it
+func (b *Buffer) GetByteArray() (ByteArray, error) {
+	length, err := b.GetVarInt()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read byte array length: %v", err)
+	}
+	return b.next(int(length), "byte array")
+}
+
+func (b *Buffer) GetUUID() (UUID, error) {
+	p, err := b.next(16, "UUID")
+	if err != nil {
+		return UUID{}, err
+	}
+	return UUID{
+		MostSignificantBits:  int64(binary.BigEndian.Uint64(p[0:8])),
+		LeastSignificantBits: int64(binary.BigEndian.Uint64(p[8:16])),
+	}, nil
+}
+
+func (b *Buffer) GetPosition() (Position, error) {
+	p, err := b.next(8, "position")
+	if err != nil {
+		return Position{}, err
+	}
+	var position Position
+	err = position.Unmarshal(p)
+	return position, err
+}
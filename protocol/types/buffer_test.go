@@ -0,0 +1,200 @@
+package types
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestBufferRoundTrip(t *testing.T) {
+	b := GetBuffer()
+	defer PutBuffer(b)
+
+	b.PutBoolean(true)
+	b.PutByte(-1)
+	b.PutUnsignedByte(200)
+	b.PutShort(-300)
+	b.PutUnsignedShort(25565)
+	b.PutInt(-70000)
+	b.PutLong(-1 << 40)
+	b.PutFloat(1.5)
+	b.PutDouble(-2.25)
+	b.PutVarInt(-1)
+	b.PutVarLong(-1)
+	b.PutString(String{Value: "héllo"})
+	b.PutIdentifier(MustParseIdentifier("stone"))
+	b.PutByteArray(ByteArray{1, 2})
+	b.PutUUID(UUID{MostSignificantBits: 1, LeastSignificantBits: 2})
+	b.PutPosition(Position{X: -1, Y: 2, Z: -3})
+
+	// The Put methods write the same bytes as the Write functions
+	var want bytes.Buffer
+	writes := []error{
+		WriteBoolean(true, &want),
+		WriteByte(-1, &want),
+		WriteUnsignedByte(200, &want),
+		WriteShort(-300, &want),
+		WriteUnsignedShort(25565, &want),
+		WriteInt(-70000, &want),
+		WriteLong(-1<<40, &want),
+		WriteFloat(1.5, &want),
+		WriteDouble(-2.25, &want),
+		WriteVarInt(-1, &want),
+		WriteVarLong(-1, &want),
+		WriteString(String{Value: "héllo"}, &want),
+		WriteIdentifier(MustParseIdentifier("stone"), &want),
+		WriteByteArray(ByteArray{1, 2}, &want),
+		WriteUUID(UUID{MostSignificantBits: 1, LeastSignificantBits: 2}, &want),
+		WritePosition(Position{X: -1, Y: 2, Z: -3}, &want),
+	}
+	for _, err := range writes {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !bytes.Equal(b.Bytes(), want.Bytes()) {
+		t.Fatalf("Put = % x, want % x", b.Bytes(), want.Bytes())
+	}
+
+	check := func(name string, got, want any, err error) {
+		t.Helper()
+		if err != nil || got != want {
+			t.Errorf("%s = %v, %v, want %v", name, got, err, want)
+		}
+	}
+	v1, err := b.GetBoolean()
+	check("GetBoolean", v1, Boolean(true), err)
+	v2, err := b.GetByte()
+	check("GetByte", v2, Byte(-1), err)
+	v3, err := b.GetUnsignedByte()
+	check("GetUnsignedByte", v3, UnsignedByte(200), err)
+	v4, err := b.GetShort()
+	check("GetShort", v4, Short(-300), err)
+	v5, err := b.GetUnsignedShort()
+	check("GetUnsignedShort", v5, UnsignedShort(25565), err)
+	v6, err := b.GetInt()
+	check("GetInt", v6, Int(-70000), err)
+	v7, err := b.GetLong()
+	check("GetLong", v7, Long(-1<<40), err)
+	v8, err := b.GetFloat()
+	check("GetFloat", v8, Float(1.5), err)
+	v9, err := b.GetDouble()
+	check("GetDouble", v9, Double(-2.25), err)
+	v10, err := b.GetVarInt()
+	check("GetVarInt", v10, VarInt(-1), err)
+	v11, err := b.GetVarLong()
+	check("GetVarLong", v11, VarLong(-1), err)
+	v12, err := b.GetString()
+	check("GetString", v12, String{Value: "héllo"}, err)
+	v13, err := b.GetIdentifier()
+	check("GetIdentifier", v13, MustParseIdentifier("stone"), err)
+	v14, err := b.GetByteArray()
+	if err != nil || !bytes.Equal(v14, []byte{1, 2}) {
+		t.Errorf("GetByteArray = %v, %v", v14, err)
+	}
+	v15, err := b.GetUUID()
+	check("GetUUID", v15, UUID{MostSignificantBits: 1, LeastSignificantBits: 2}, err)
+	v16, err := b.GetPosition()
+	check("GetPosition", v16, Position{X: -1, Y: 2, Z: -3}, err)
+
+	if b.Len() != 0 {
+		t.Errorf("%d bytes left", b.Len())
+	}
+	if _, err := b.GetInt(); err == nil {
+		t.Error("GetInt on an empty buffer succeeded")
+	}
+}
+
+func TestBufferErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		get  func(b *Buffer) error
+	}{
+		{"short long", []byte{1, 2, 3}, func(b *Buffer) error { _, err := b.GetLong(); return err }},
+		{"incomplete varint", []byte{0x80}, func(b *Buffer) error { _, err := b.GetVarInt(); return err }},
+		{"varint too big", []byte{0x80, 0x80, 0x80, 0x80, 0x80, 0x01}, func(b *Buffer) error { _, err := b.GetVarInt(); return err }},
+		{"short string", []byte{0x05, 'a'}, func(b *Buffer) error { _, err := b.GetString(); return err }},
+		{"negative byte array", []byte{0xff, 0xff, 0xff, 0xff, 0x0f}, func(b *Buffer) error { _, err := b.GetByteArray(); return err }},
+		{"invalid identifier", []byte{0x03, 'A', ':', 'b'}, func(b *Buffer) error { _, err := b.GetIdentifier(); return err }},
+		{"too many bytes", []byte{1}, func(b *Buffer) error { _, err := b.GetBytes(2); return err }},
+	}
+	for _, tt := range tests {
+		if err := tt.get(NewBuffer(tt.data)); err == nil {
+			t.Errorf("%s: succeeded", tt.name)
+		}
+	}
+}
+
+// BenchmarkBufferPutGet encodes and decodes the fields of a typical small
+// packet, compared with the Marshal methods and Read functions in
+// BenchmarkMarshalRead. Strings are left out as converting their bytes
+// allocates in both.
+func BenchmarkBufferPutGet(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf := GetBuffer()
+		buf.PutVarInt(0x1a)
+		buf.PutLong(1 << 40)
+		buf.PutBoolean(true)
+		buf.PutPosition(Position{X: 100, Y: 64, Z: -200})
+		buf.PutUUID(UUID{MostSignificantBits: 1, LeastSignificantBits: 2})
+		buf.GetVarInt()
+		buf.GetLong()
+		buf.GetBoolean()
+		buf.GetPosition()
+		buf.GetUUID()
+		PutBuffer(buf)
+	}
+}
+
+func BenchmarkMarshalRead(b *testing.B) {
+	b.ReportAllocs()
+	var out bytes.Buffer
+	r := bytes.NewReader(nil)
+	values := []marshaler{VarInt(0x1a), Long(1 << 40), Boolean(true), Position{X: 100, Y: 64, Z: -200}, UUID{MostSignificantBits: 1, LeastSignificantBits: 2}}
+	for i := 0; i < b.N; i++ {
+		out.Reset()
+		for _, value := range values {
+			data, _ := value.Marshal()
+			out.Write(data)
+		}
+		r.Reset(out.Bytes())
+		ReadVarInt(r)
+		ReadLong(r)
+		ReadBoolean(r)
+		ReadPosition(r)
+		ReadUUID(r)
+	}
+}
+
+func BenchmarkReadVarInt(b *testing.B) {
+	data := []byte{0xdd, 0xc7, 0x01}
+	b.Run("ByteReader", func(b *testing.B) {
+		b.ReportAllocs()
+		r := bytes.NewReader(data)
+		for i := 0; i < b.N; i++ {
+			r.Reset(data)
+			ReadVarInt(r)
+		}
+	})
+	// Without io.ByteReader each value needs a scratch byte on the heap
+	b.Run("Reader", func(b *testing.B) {
+		b.ReportAllocs()
+		inner := bytes.NewReader(data)
+		var r io.Reader = onlyReader{inner}
+		for i := 0; i < b.N; i++ {
+			inner.Reset(data)
+			ReadVarInt(r)
+		}
+	})
+	b.Run("Buffer", func(b *testing.B) {
+		b.ReportAllocs()
+		buf := NewBuffer(nil)
+		for i := 0; i < b.N; i++ {
+			buf.Reset()
+			buf.Write(data)
+			buf.GetVarInt()
+		}
+	})
+}
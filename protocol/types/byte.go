@@ -1,6 +1,9 @@
 package types
 
-import "fmt"
+import (
+	"fmt"
+	"io"
+)
 
 type Byte int8
 
@@ -15,3 +18,30 @@ func (b *Byte) Unmarshal(data []byte) error {
 	*b = Byte(data[0])
 	return nil
 }
+
+func WriteByte(b Byte, w io.Writer) error {
+	buf, err := b.Marshal()
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(buf)
+	return err
+}
+
+func ReadByte(r io.Reader) (Byte, error) {
+	buf := make([]byte, 1)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return 0, fmt.Errorf("failed to read byte: %v", err)
+	}
+
+	var b Byte
+	if err := b.Unmarshal(buf); err != nil {
+		return 0, err
+	}
+	return b, nil
+}
+
+func (b *Byte) Decode(r io.Reader) (err error) {
+	*b, err = ReadByte(r)
+	return err
+}
@@ -0,0 +1,91 @@
+package types
+
+import (
+	"fmt"
+	"io"
+	"math"
+)
+
+// ByteArray is a byte sequence prefixed with its length as a VarInt
+type ByteArray []byte
+
+func (b ByteArray) Marshal() ([]byte, error) {
+	lenBytes, err := VarInt(len(b)).Marshal()
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal byte array length: %v", err)
+	}
+	return append(lenBytes, b...), nil
+}
+
+func (b *ByteArray) Unmarshal(data []byte) error {
+	var length VarInt
+	if err := length.Unmarshal(data); err != nil {
+		return fmt.Errorf("failed to unmarshal byte array length: %v", err)
+	}
+	if length < 0 {
+		return fmt.Errorf("byte array length is negative")
+	}
+
+	// Calculate where the VarInt ends
+	varIntSize := 0
+	for _, b := range data {
+		varIntSize++
+		if b&0x80 == 0 {
+			break
+		}
+	}
+
+	if len(data) < varIntSize+int(length) {
+		return fmt.Errorf("byte array data too short")
+	}
+	*b = append(ByteArray(nil), data[varIntSize:varIntSize+int(length)]...)
+	return nil
+}
+
+func WriteByteArray(b ByteArray, w io.Writer) error {
+	buf, err := b.Marshal()
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(buf)
+	return err
+}
+
+// byteArrayChunkSize is the most memory allocated ahead of the data when
+// reading a byte array
+const byteArrayChunkSize = 4096
+
+func ReadByteArray(r io.Reader) (ByteArray, error) {
+	return ReadByteArrayMax(r, math.MaxInt32)
+}
+
+// ReadByteArrayMax reads a byte array of at most max bytes. Longer arrays are
+// rejected before they are read, and the array is read in chunks so that a
+// bogus length fails at the end of the input instead of allocating it.
+func ReadByteArrayMax(r io.Reader, max int) (ByteArray, error) {
+	length, err := ReadVarInt(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read byte array length: %v", err)
+	}
+	if length < 0 {
+		return nil, fmt.Errorf("byte array length is negative")
+	}
+	if int(length) > max {
+		return nil, fmt.Errorf("byte array is too long: %d > %d", length, max)
+	}
+
+	buf := make([]byte, 0, min(int(length), byteArrayChunkSize))
+	for len(buf) < int(length) {
+		n := min(int(length)-len(buf), byteArrayChunkSize)
+		buf = append(buf, make([]byte, n)...)
+		if _, err := io.ReadFull(r, buf[len(buf)-n:]); err != nil {
+			return nil, fmt.Errorf("failed to read byte array: %v", err)
+		}
+	}
+	return buf, nil
+}
+
+func (b *ByteArray) Decode(r io.Reader) (err error) {
+	*b, err = ReadByteArray(r)
+	return err
+}
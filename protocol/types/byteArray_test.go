@@ -0,0 +1,60 @@
+package types
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestByteArray(t *testing.T) {
+	tests := []struct {
+		name  string
+		value ByteArray
+		want  []byte
+	}{
+		{"empty", ByteArray{}, []byte{0}},
+		{"bytes", ByteArray{1, 2, 3}, []byte{3, 1, 2, 3}},
+		{"multiple chunks", bytes.Repeat([]byte{7}, 10000), append([]byte{0x90, 0x4e}, bytes.Repeat([]byte{7}, 10000)...)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := WriteByteArray(tt.value, &buf); err != nil || !bytes.Equal(buf.Bytes(), tt.want) {
+				t.Fatalf("WriteByteArray = % .20x, %v, want % .20x", buf.Bytes(), err, tt.want)
+			}
+			got, err := ReadByteArray(bytes.NewReader(tt.want))
+			if err != nil || !bytes.Equal(got, tt.value) {
+				t.Errorf("ReadByteArray = % .20x, %v", got, err)
+			}
+			var unmarshaled ByteArray
+			if err := unmarshaled.Unmarshal(tt.want); err != nil || !bytes.Equal(unmarshaled, tt.value) {
+				t.Errorf("Unmarshal = % .20x, %v", unmarshaled, err)
+			}
+		})
+	}
+}
+
+func TestReadByteArrayErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		max  int
+	}{
+		{"missing length", nil, 10},
+		{"negative length", []byte{0xff, 0xff, 0xff, 0xff, 0x0f}, 10},
+		{"truncated", []byte{3, 1, 2}, 10},
+		{"longer than max", []byte{3, 1, 2, 3}, 2},
+		// A huge length must fail on the missing data, not allocate it
+		{"huge length", []byte{0xff, 0xff, 0xff, 0xff, 0x07, 1}, 1 << 31},
+	}
+	for _, tt := range tests {
+		if got, err := ReadByteArrayMax(bytes.NewReader(tt.data), tt.max); err == nil {
+			t.Errorf("%s: ReadByteArrayMax = % x, want error", tt.name, got)
+		}
+	}
+
+	// The length is checked before any of the array is read
+	r := bytes.NewReader([]byte{0xff, 0xff, 0xff, 0xff, 0x07, 1, 2, 3})
+	if _, err := ReadByteArrayMax(r, 256); err == nil || r.Len() != 3 {
+		t.Errorf("ReadByteArrayMax = %v with %d bytes left, want error with 3 left", err, r.Len())
+	}
+}
@@ -3,21 +3,280 @@ package types
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 )
 
+// ChatComponent is a text component. Its content is the first set field of
+// Text, Translate, Score, Selector, Keybind and NBT; a component without
+// content is an empty text component.
 type ChatComponent struct {
-	Text          string          `json:"text,omitempty"`
-	Color         string          `json:"color,omitempty"`
-	Bold          *bool           `json:"bold,omitempty"`
-	Italic        *bool           `json:"italic,omitempty"`
-	Underlined    *bool           `json:"underlined,omitempty"`
-	Strikethrough *bool           `json:"strikethrough,omitempty"`
-	Obfuscated    *bool           `json:"obfuscated,omitempty"`
-	Extra         []ChatComponent `json:"extra,omitempty"`
+	Text string `json:"text,omitempty"`
+
+	// Translatable content
+	Translate string          `json:"translate,omitempty"`
+	With      []ChatComponent `json:"with,omitempty"`
+	Fallback  string          `json:"fallback,omitempty"` // used if the key is unknown
+
+	Score *ScoreContent `json:"score,omitempty"`
+
+	// Entity selector content, Separator also applies to NBT content
+	Selector  string         `json:"selector,omitempty"`
+	Separator *ChatComponent `json:"separator,omitempty"`
+
+	Keybind string `json:"keybind,omitempty"`
+
+	// NBT content: the NBT path and one of Block, Entity and Storage
+	NBT       string `json:"nbt,omitempty"`
+	Interpret *bool  `json:"interpret,omitempty"`
+	Block     string `json:"block,omitempty"`
+	Entity    string `json:"entity,omitempty"`
+	Storage   string `json:"storage,omitempty"`
+	Source    string `json:"source,omitempty"` // block, entity or storage
+
+	// Style
+	Color         string       `json:"color,omitempty"` // named color or #RRGGBB
+	Font          string       `json:"font,omitempty"`
+	Bold          *bool        `json:"bold,omitempty"`
+	Italic        *bool        `json:"italic,omitempty"`
+	Underlined    *bool        `json:"underlined,omitempty"`
+	Strikethrough *bool        `json:"strikethrough,omitempty"`
+	Obfuscated    *bool        `json:"obfuscated,omitempty"`
+	ShadowColor   *ShadowColor `json:"shadow_color,omitempty"`
+	Insertion     string       `json:"insertion,omitempty"` // inserted into chat on shift click
+	ClickEvent    *ClickEvent  `json:"clickEvent,omitempty"`
+	HoverEvent    *HoverEvent  `json:"hoverEvent,omitempty"`
+
+	Extra []ChatComponent `json:"extra,omitempty"`
+}
+
+// ScoreContent displays the score of an entity in an objective
+type ScoreContent struct {
+	Name      string `json:"name"` // entity name or selector
+	Objective string `json:"objective"`
+}
+
+// ShadowColor is the ARGB color of the text shadow
+type ShadowColor uint32
+
+func (c ShadowColor) MarshalJSON() ([]byte, error) {
+	return json.Marshal(int32(c))
+}
+
+// UnmarshalJSON accepts an ARGB integer or an array of RGBA floats
+func (c *ShadowColor) UnmarshalJSON(data []byte) error {
+	var argb int64
+	if err := json.Unmarshal(data, &argb); err == nil {
+		*c = ShadowColor(uint32(argb))
+		return nil
+	}
+
+	var rgba [4]float64
+	if err := json.Unmarshal(data, &rgba); err != nil {
+		return fmt.Errorf("invalid shadow color %s", data)
+	}
+	channel := func(f float64) uint32 { return uint32(f*255+0.5) & 0xFF }
+	*c = ShadowColor(channel(rgba[3])<<24 | channel(rgba[0])<<16 | channel(rgba[1])<<8 | channel(rgba[2]))
+	return nil
+}
+
+// Click event actions
+const (
+	ClickOpenURL         = "open_url"
+	ClickRunCommand      = "run_command"
+	ClickSuggestCommand  = "suggest_command"
+	ClickChangePage      = "change_page"
+	ClickCopyToClipboard = "copy_to_clipboard"
+)
+
+type ClickEvent struct {
+	Action string `json:"action"`
+	Value  string `json:"value"`
+}
+
+// Hover event actions
+const (
+	HoverShowText   = "show_text"
+	HoverShowItem   = "show_item"
+	HoverShowEntity = "show_entity"
+)
+
+// HoverEvent shows a tooltip. The field matching the action is set.
+type HoverEvent struct {
+	Action string
+	Text   *ChatComponent // show_text
+	Item   *HoverItem     // show_item
+	Entity *HoverEntity   // show_entity
+}
+
+type HoverItem struct {
+	ID         string          `json:"id"`
+	Count      int32           `json:"count,omitempty"`
+	Components json.RawMessage `json:"components,omitempty"` // data components, kept as is
+}
+
+type HoverEntity struct {
+	Type string         `json:"type"`
+	ID   UUID           `json:"-"`
+	Name *ChatComponent `json:"name,omitempty"`
+}
+
+// ShowText returns a hover event showing a component
+func ShowText(text ChatComponent) *HoverEvent {
+	return &HoverEvent{Action: HoverShowText, Text: &text}
+}
+
+func (e HoverEvent) MarshalJSON() ([]byte, error) {
+	var contents interface{}
+	switch e.Action {
+	case HoverShowText:
+		contents = e.Text
+	case HoverShowItem:
+		contents = e.Item
+	case HoverShowEntity:
+		contents = e.Entity
+	default:
+		return nil, fmt.Errorf("unknown hover event action %q", e.Action)
+	}
+
+	return json.Marshal(struct {
+		Action   string      `json:"action"`
+		Contents interface{} `json:"contents"`
+	}{e.Action, contents})
+}
+
+// UnmarshalJSON also accepts the value field used before 1.16
+func (e *HoverEvent) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Action   string          `json:"action"`
+		Contents json.RawMessage `json:"contents"`
+		Value    json.RawMessage `json:"value"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if raw.Contents == nil {
+		raw.Contents = raw.Value
+	}
+
+	*e = HoverEvent{Action: raw.Action}
+	switch raw.Action {
+	case HoverShowText:
+		e.Text = &ChatComponent{}
+		return json.Unmarshal(raw.Contents, e.Text)
+	case HoverShowItem:
+		e.Item = &HoverItem{}
+		// The contents may be just the item ID
+		var id string
+		if err := json.Unmarshal(raw.Contents, &id); err == nil {
+			e.Item.ID = id
+			return nil
+		}
+		return json.Unmarshal(raw.Contents, e.Item)
+	case HoverShowEntity:
+		e.Entity = &HoverEntity{}
+		return json.Unmarshal(raw.Contents, e.Entity)
+	default:
+		return fmt.Errorf("unknown hover event action %q", raw.Action)
+	}
+}
+
+func (e HoverEntity) MarshalJSON() ([]byte, error) {
+	type entity HoverEntity
+	return json.Marshal(struct {
+		entity
+		ID string `json:"id"`
+	}{entity(e), e.ID.String()})
+}
+
+// UnmarshalJSON accepts the entity UUID as a string or an array of four ints
+func (e *HoverEntity) UnmarshalJSON(data []byte) error {
+	type entity HoverEntity
+	var raw struct {
+		entity
+		ID json.RawMessage `json:"id"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*e = HoverEntity(raw.entity)
+
+	var id string
+	if err := json.Unmarshal(raw.ID, &id); err == nil {
+		e.ID, err = ParseUUID(id)
+		return err
+	}
+	var ints [4]int32
+	if err := json.Unmarshal(raw.ID, &ints); err != nil {
+		return fmt.Errorf("invalid hover entity id %s", raw.ID)
+	}
+	e.ID = UUIDFromInts(ints)
+	return nil
+}
+
+// hasContent reports whether any content field is set
+func (c *ChatComponent) hasContent() bool {
+	return c.Text != "" || c.Translate != "" || c.Score != nil || c.Selector != "" || c.Keybind != "" || c.NBT != ""
+}
+
+// MarshalJSON writes an empty text for components without content, which
+// the client would reject otherwise
+func (c ChatComponent) MarshalJSON() ([]byte, error) {
+	type component ChatComponent
+	data, err := json.Marshal(component(c))
+	if err != nil || c.hasContent() {
+		return data, err
+	}
+	if string(data) == "{}" {
+		return []byte(`{"text":""}`), nil
+	}
+	return append([]byte(`{"text":"",`), data[1:]...), nil
+}
+
+// UnmarshalJSON also accepts the shorthand forms of a component: a plain
+// string, number or boolean for a text component and an array whose first
+// element is the parent of the remaining elements
+func (c *ChatComponent) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*c = ChatComponent{Text: text}
+		return nil
+	}
+
+	var number json.Number
+	if err := json.Unmarshal(data, &number); err == nil {
+		*c = ChatComponent{Text: number.String()}
+		return nil
+	}
+	var boolean bool
+	if err := json.Unmarshal(data, &boolean); err == nil {
+		*c = ChatComponent{Text: string(data)}
+		return nil
+	}
+
+	var list []ChatComponent
+	if err := json.Unmarshal(data, &list); err == nil {
+		if len(list) == 0 {
+			return fmt.Errorf("chat component array is empty")
+		}
+		*c = list[0]
+		c.Extra = append(c.Extra, list[1:]...)
+		return nil
+	}
+
+	type component ChatComponent
+	return json.Unmarshal(data, (*component)(c))
 }
 
 type Chat ChatComponent
 
+func (c Chat) MarshalJSON() ([]byte, error) {
+	return ChatComponent(c).MarshalJSON()
+}
+
+func (c *Chat) UnmarshalJSON(data []byte) error {
+	return (*ChatComponent)(c).UnmarshalJSON(data)
+}
+
 func (c Chat) Marshal() ([]byte, error) {
 	jsonBytes, err := json.Marshal(c)
 	if err != nil {
@@ -73,3 +332,34 @@ func (c *Chat) Unmarshal(data []byte) error {
 
 	return nil
 }
+
+func WriteChat(c Chat, w io.Writer) error {
+	buf, err := c.Marshal()
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(buf)
+	return err
+}
+
+// MaxChatLength is the maximum length of a JSON text component in UTF-16
+// code units, which is larger than that of other strings
+const MaxChatLength = 262144
+
+func ReadChat(r io.Reader) (Chat, error) {
+	str, err := ReadStringMax(r, MaxChatLength)
+	if err != nil {
+		return Chat{}, fmt.Errorf("failed to read chat: %v", err)
+	}
+
+	var c Chat
+	if err := json.Unmarshal([]byte(str.Value), &c); err != nil {
+		return Chat{}, fmt.Errorf("failed to unmarshal chat json: %v", err)
+	}
+	return c, nil
+}
+
+func (c *Chat) Decode(r io.Reader) (err error) {
+	*c, err = ReadChat(r)
+	return err
+}
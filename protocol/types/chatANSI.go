@@ -0,0 +1,150 @@
+package types
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ANSIColorMode is the color support of a terminal
+type ANSIColorMode int
+
+const (
+	ANSI16        ANSIColorMode = iota // the 16 standard colors
+	ANSI256                            // the xterm 256 color palette
+	ANSITrueColor                      // 24-bit colors
+)
+
+// ansi16Codes are the foreground codes of the named colors, in the order of
+// ChatColors
+var ansi16Codes = [...]int{30, 34, 32, 36, 31, 35, 33, 37, 90, 94, 92, 96, 91, 95, 93, 97}
+
+// ANSIRenderer renders components as text with ANSI escape sequences for
+// terminals. Named colors use the terminal's own palette except in true color
+// mode. Obfuscated text is shown as is.
+type ANSIRenderer struct {
+	Mode       ANSIColorMode
+	Translator Translator // may be nil, translations then show their fallback or key
+}
+
+// Render returns the text of the component with escape sequences, ending with
+// a reset if any style was applied
+func (r ANSIRenderer) Render(c ChatComponent) string {
+	var b strings.Builder
+	var last chatStyle
+
+	walkChat(&c, chatStyle{}, r.Translator, func(text string, style chatStyle) {
+		if text == "" || style == last {
+			b.WriteString(text)
+			return
+		}
+		if last != (chatStyle{}) {
+			b.WriteString("\x1b[0m")
+		}
+		if codes := r.sgrCodes(style); len(codes) > 0 {
+			b.WriteString("\x1b[" + strings.Join(codes, ";") + "m")
+		}
+		last = style
+		b.WriteString(text)
+	})
+
+	if last != (chatStyle{}) {
+		b.WriteString("\x1b[0m")
+	}
+	return b.String()
+}
+
+// ANSI renders the component for a 256 color terminal
+func (c ChatComponent) ANSI() string {
+	return ANSIRenderer{Mode: ANSI256}.Render(c)
+}
+
+// sgrCodes returns the Select Graphic Rendition parameters of a style
+func (r ANSIRenderer) sgrCodes(style chatStyle) []string {
+	var codes []string
+	if style.bold {
+		codes = append(codes, "1")
+	}
+	if style.italic {
+		codes = append(codes, "3")
+	}
+	if style.underlined {
+		codes = append(codes, "4")
+	}
+	if style.strikethrough {
+		codes = append(codes, "9")
+	}
+	if style.color != "" {
+		if color := r.colorCode(style.color); color != "" {
+			codes = append(codes, color)
+		}
+	}
+	return codes
+}
+
+// colorCode returns the foreground parameters of a color in the mode
+func (r ANSIRenderer) colorCode(color string) string {
+	rgb, ok := ParseChatColor(color)
+	if !ok {
+		return ""
+	}
+	_, named := chatColorByName(color)
+
+	switch {
+	case r.Mode == ANSITrueColor:
+		return "38;2;" + strconv.Itoa(int(rgb>>16)) + ";" + strconv.Itoa(int(rgb>>8&0xFF)) + ";" + strconv.Itoa(int(rgb&0xFF))
+	case r.Mode == ANSI256 && !named:
+		return "38;5;" + strconv.Itoa(ansi256Color(rgb))
+	default:
+		return strconv.Itoa(ansi16Codes[nearestChatColor(rgb)])
+	}
+}
+
+// nearestChatColor returns the index of the named color closest to rgb
+func nearestChatColor(rgb uint32) int {
+	best, bestDistance := 0, -1
+	for i, color := range ChatColors {
+		if distance := rgbDistance(rgb, color.RGB); bestDistance < 0 || distance < bestDistance {
+			best, bestDistance = i, distance
+		}
+	}
+	return best
+}
+
+// ansi256Color returns the closest color of the 6x6x6 cube or the gray ramp
+// of the xterm palette
+func ansi256Color(rgb uint32) int {
+	levels := [6]int{0, 95, 135, 175, 215, 255}
+	cubeIndex := func(v int) int {
+		if v < 48 {
+			return 0
+		}
+		if v < 115 {
+			return 1
+		}
+		return (v - 35) / 40
+	}
+
+	r, g, b := int(rgb>>16), int(rgb>>8&0xFF), int(rgb&0xFF)
+	ri, gi, bi := cubeIndex(r), cubeIndex(g), cubeIndex(b)
+	cube := uint32(levels[ri]<<16 | levels[gi]<<8 | levels[bi])
+
+	average := (r + g + b) / 3
+	grayIndex := 23
+	if average < 238 {
+		grayIndex = max(0, (average-3)/10)
+	}
+	grayLevel := uint32(8 + grayIndex*10)
+	gray := grayLevel<<16 | grayLevel<<8 | grayLevel
+
+	if rgbDistance(rgb, gray) < rgbDistance(rgb, cube) {
+		return 232 + grayIndex
+	}
+	return 16 + 36*ri + 6*gi + bi
+}
+
+func rgbDistance(a, b uint32) int {
+	dr := int(a>>16) - int(b>>16)
+	dg := int(a>>8&0xFF) - int(b>>8&0xFF)
+	db := int(a&0xFF) - int(b&0xFF)
+	return dr*dr + dg*dg + db*db
+}
@@ -0,0 +1,55 @@
+package types
+
+import "testing"
+
+func TestANSIRender(t *testing.T) {
+	tests := []struct {
+		name string
+		mode ANSIColorMode
+		c    ChatComponent
+		want string
+	}{
+		{"plain", ANSI256, ChatComponent{Text: "hi"}, "hi"},
+		{"named 16", ANSI16, ChatComponent{Text: "hi", Color: "red"}, "\x1b[91mhi\x1b[0m"},
+		{"named 256", ANSI256, ChatComponent{Text: "hi", Color: "dark_blue"}, "\x1b[34mhi\x1b[0m"},
+		{"named true color", ANSITrueColor, ChatComponent{Text: "hi", Color: "gold"}, "\x1b[38;2;255;170;0mhi\x1b[0m"},
+		{"hex 16", ANSI16, ChatComponent{Text: "hi", Color: "#FE5050"}, "\x1b[91mhi\x1b[0m"},
+		{"hex 256", ANSI256, ChatComponent{Text: "hi", Color: "#FF0000"}, "\x1b[38;5;196mhi\x1b[0m"},
+		{"gray 256", ANSI256, ChatComponent{Text: "hi", Color: "#808080"}, "\x1b[38;5;244mhi\x1b[0m"},
+		{"hex true color", ANSITrueColor, ChatComponent{Text: "hi", Color: "#0A0B0C"}, "\x1b[38;2;10;11;12mhi\x1b[0m"},
+		{
+			"decorations",
+			ANSI16,
+			ChatComponent{Text: "hi", Bold: boolPtr(true), Italic: boolPtr(true), Underlined: boolPtr(true), Strikethrough: boolPtr(true), Obfuscated: boolPtr(true)},
+			"\x1b[1;3;4;9mhi\x1b[0m",
+		},
+		{
+			"style change",
+			ANSI16,
+			ChatComponent{Extra: []ChatComponent{{Text: "a", Color: "green"}, {Text: "b", Color: "green"}, {Text: "c"}}},
+			"\x1b[92mab\x1b[0mc",
+		},
+		{"unknown color", ANSI16, ChatComponent{Text: "hi", Color: "nope", Bold: boolPtr(true)}, "\x1b[1mhi\x1b[0m"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := (ANSIRenderer{Mode: tt.mode}).Render(tt.c); got != tt.want {
+				t.Errorf("Render = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestANSIRenderTranslator(t *testing.T) {
+	c := ChatComponent{Translate: "greet", With: []ChatComponent{{Text: "Steve", Color: "yellow"}}}
+	translator := Translator(func(key string) (string, bool) {
+		return "Hello %s!", key == "greet"
+	})
+
+	if got, want := (ANSIRenderer{Translator: translator}).Render(c), "Hello \x1b[93mSteve\x1b[0m!"; got != want {
+		t.Errorf("Render = %q, want %q", got, want)
+	}
+	if got, want := (ANSIRenderer{}).Render(c), "greet"; got != want {
+		t.Errorf("Render without translator = %q, want %q", got, want)
+	}
+}
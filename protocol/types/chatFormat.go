@@ -0,0 +1,256 @@
+package types
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ChatColor is one of the sixteen named text colors
+type ChatColor struct {
+	Name string
+	Code byte   // legacy formatting code
+	RGB  uint32 // 0xRRGGBB
+}
+
+// ChatColors lists the named colors in the order of their legacy codes
+var ChatColors = []ChatColor{
+	{"black", '0', 0x000000},
+	{"dark_blue", '1', 0x0000AA},
+	{"dark_green", '2', 0x00AA00},
+	{"dark_aqua", '3', 0x00AAAA},
+	{"dark_red", '4', 0xAA0000},
+	{"dark_purple", '5', 0xAA00AA},
+	{"gold", '6', 0xFFAA00},
+	{"gray", '7', 0xAAAAAA},
+	{"dark_gray", '8', 0x555555},
+	{"blue", '9', 0x5555FF},
+	{"green", 'a', 0x55FF55},
+	{"aqua", 'b', 0x55FFFF},
+	{"red", 'c', 0xFF5555},
+	{"light_purple", 'd', 0xFF55FF},
+	{"yellow", 'e', 0xFFFF55},
+	{"white", 'f', 0xFFFFFF},
+}
+
+// chatColorByName returns the named color, also accepting the British
+// spelling of gray
+func chatColorByName(name string) (ChatColor, bool) {
+	name = strings.ReplaceAll(strings.ToLower(name), "grey", "gray")
+	for _, color := range ChatColors {
+		if color.Name == name {
+			return color, true
+		}
+	}
+	return ChatColor{}, false
+}
+
+// ParseChatColor parses a named color or #RRGGBB into its RGB value
+func ParseChatColor(color string) (uint32, bool) {
+	if named, ok := chatColorByName(color); ok {
+		return named.RGB, true
+	}
+	if len(color) != 7 || color[0] != '#' {
+		return 0, false
+	}
+	rgb, err := strconv.ParseUint(color[1:], 16, 32)
+	if err != nil {
+		return 0, false
+	}
+	return uint32(rgb), true
+}
+
+// normalizeChatColor returns the form of a color used in components: the
+// name of a named color or #RRGGBB
+func normalizeChatColor(color string) (string, bool) {
+	if named, ok := chatColorByName(color); ok {
+		return named.Name, true
+	}
+	rgb, ok := ParseChatColor(color)
+	if !ok {
+		return "", false
+	}
+	return hexChatColor(rgb), true
+}
+
+func hexChatColor(rgb uint32) string {
+	return fmt.Sprintf("#%06X", rgb&0xFFFFFF)
+}
+
+// chatStyle is the effective style of a component after inheritance
+type chatStyle struct {
+	color         string
+	bold          bool
+	italic        bool
+	underlined    bool
+	strikethrough bool
+	obfuscated    bool
+}
+
+// inherit applies the style fields set on a child component
+func (s chatStyle) inherit(c *ChatComponent) chatStyle {
+	if c.Color != "" {
+		s.color = c.Color
+	}
+	set := func(field *bool, value *bool) {
+		if value != nil {
+			*field = *value
+		}
+	}
+	set(&s.bold, c.Bold)
+	set(&s.italic, c.Italic)
+	set(&s.underlined, c.Underlined)
+	set(&s.strikethrough, c.Strikethrough)
+	set(&s.obfuscated, c.Obfuscated)
+	return s
+}
+
+// Translator looks up the text of a translation key
+type Translator func(key string) (string, bool)
+
+// resolve returns the format shown for a translatable component: the
+// translation if known, else the fallback, else the key itself
+func (t Translator) resolve(c *ChatComponent) string {
+	if t != nil {
+		if format, ok := t(c.Translate); ok {
+			return format
+		}
+	}
+	if c.Fallback != "" {
+		return c.Fallback
+	}
+	return c.Translate
+}
+
+// walkChat visits the displayed text of a component tree in order with the
+// effective style of each piece. Scores cannot be resolved and show nothing.
+func walkChat(c *ChatComponent, style chatStyle, translator Translator, visit func(text string, style chatStyle)) {
+	style = style.inherit(c)
+
+	switch {
+	case c.Text != "":
+		visit(c.Text, style)
+	case c.Translate != "":
+		formatTranslation(translator.resolve(c), func(literal string) {
+			visit(literal, style)
+		}, func(i int) {
+			if i < len(c.With) {
+				walkChat(&c.With[i], style, translator, visit)
+			}
+		})
+	case c.Selector != "":
+		visit(c.Selector, style)
+	case c.Keybind != "":
+		visit(c.Keybind, style)
+	case c.NBT != "":
+		visit(c.NBT, style)
+	}
+
+	for i := range c.Extra {
+		walkChat(&c.Extra[i], style, translator, visit)
+	}
+}
+
+// formatTranslation splits a translation into literal text and arguments,
+// supporting %s, %1$s and %%. Other specifiers are kept as text.
+func formatTranslation(format string, literal func(string), arg func(int)) {
+	next := 0
+	start := 0
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' || i+1 == len(format) {
+			continue
+		}
+
+		end := i + 1
+		index := -1
+		for end < len(format) && format[end] >= '0' && format[end] <= '9' {
+			end++
+		}
+		if end > i+1 {
+			if end+1 >= len(format) || format[end] != '$' || format[end+1] != 's' {
+				continue
+			}
+			n, err := strconv.Atoi(format[i+1 : end])
+			if err != nil || n == 0 {
+				continue
+			}
+			index = n - 1
+			end += 2
+		} else if format[end] == 's' {
+			index = next
+			next++
+			end++
+		} else if format[end] == '%' {
+			end++
+		} else {
+			continue
+		}
+
+		if i > start {
+			literal(format[start:i])
+		}
+		if index < 0 {
+			literal("%")
+		} else {
+			arg(index)
+		}
+		start = end
+		i = end - 1
+	}
+	if start < len(format) {
+		literal(format[start:])
+	}
+}
+
+// PlainText returns the displayed text of the component without any styling.
+// Translations are shown with their fallback or key.
+func (c ChatComponent) PlainText() string {
+	return c.TranslatedText(nil)
+}
+
+// TranslatedText returns the displayed text of the component, looking up
+// translations with the translator
+func (c ChatComponent) TranslatedText(translator Translator) string {
+	var b strings.Builder
+	walkChat(&c, chatStyle{}, translator, func(text string, _ chatStyle) {
+		b.WriteString(text)
+	})
+	return b.String()
+}
+
+// Translated returns a copy of the component in which translatable
+// components are replaced by text components with the translation and their
+// arguments, keeping the style. Clients can then show text unknown to them,
+// such as messages of the proxy.
+func (c ChatComponent) Translated(translator Translator) ChatComponent {
+	var extra []ChatComponent
+	if c.Translate != "" {
+		formatTranslation(translator.resolve(&c), func(literal string) {
+			extra = append(extra, ChatComponent{Text: literal})
+		}, func(i int) {
+			if i < len(c.With) {
+				extra = append(extra, c.With[i].Translated(translator))
+			}
+		})
+		if len(extra) > 0 && isPlainText(&extra[0]) {
+			c.Text, extra = extra[0].Text, extra[1:]
+		}
+		c.Translate, c.Fallback, c.With = "", "", nil
+	}
+
+	for _, child := range c.Extra {
+		extra = append(extra, child.Translated(translator))
+	}
+	if len(extra) == 0 {
+		extra = nil
+	}
+	c.Extra = extra
+
+	if c.HoverEvent != nil && c.HoverEvent.Text != nil {
+		hover := *c.HoverEvent
+		text := hover.Text.Translated(translator)
+		hover.Text = &text
+		c.HoverEvent = &hover
+	}
+	return c
+}
@@ -0,0 +1,83 @@
+package types
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseChatColor(t *testing.T) {
+	tests := []struct {
+		color string
+		want  uint32
+		ok    bool
+	}{
+		{"red", 0xFF5555, true},
+		{"DARK_GRAY", 0x555555, true},
+		{"dark_grey", 0x555555, true},
+		{"#1a2B3c", 0x1A2B3C, true},
+		{"#12345", 0, false},
+		{"#GGGGGG", 0, false},
+		{"pink", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := ParseChatColor(tt.color)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("ParseChatColor(%q) = %06x, %v, want %06x, %v", tt.color, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestTranslatedText(t *testing.T) {
+	with := []ChatComponent{{Text: "a"}, {Text: "b"}}
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{"%s and %s", "a and b"},
+		{"%2$s before %1$s", "b before a"},
+		{"100%% %s", "100% a"},
+		{"%d %s %", "%d a %"},
+		{"%0$s %3$s", "%0$s "},
+	}
+	for _, tt := range tests {
+		translator := Translator(func(string) (string, bool) { return tt.format, true })
+		c := ChatComponent{Translate: "key", With: with}
+		if got := c.TranslatedText(translator); got != tt.want {
+			t.Errorf("TranslatedText(%q) = %q, want %q", tt.format, got, tt.want)
+		}
+	}
+
+	if got := (ChatComponent{Translate: "key", Fallback: "fb"}).PlainText(); got != "fb" {
+		t.Errorf("PlainText with fallback = %q, want fb", got)
+	}
+	c := ChatComponent{Text: "a", Extra: []ChatComponent{{Keybind: "key.jump"}, {Selector: "@p"}, {Score: &ScoreContent{}}}}
+	if got := c.PlainText(); got != "akey.jump@p" {
+		t.Errorf("PlainText = %q, want akey.jump@p", got)
+	}
+}
+
+func TestTranslated(t *testing.T) {
+	translator := Translator(func(key string) (string, bool) {
+		return map[string]string{"greet": "Hello %s!", "tip": "A tip"}[key], key == "greet" || key == "tip"
+	})
+	c := ChatComponent{
+		Translate:  "greet",
+		Color:      "red",
+		With:       []ChatComponent{{Translate: "tip"}},
+		HoverEvent: ShowText(ChatComponent{Translate: "tip"}),
+		Extra:      []ChatComponent{{Text: "."}},
+	}
+
+	want := ChatComponent{
+		Text:       "Hello ",
+		Color:      "red",
+		HoverEvent: ShowText(ChatComponent{Text: "A tip"}),
+		Extra:      []ChatComponent{{Text: "A tip"}, {Text: "!"}, {Text: "."}},
+	}
+	if got := c.Translated(translator); !reflect.DeepEqual(got, want) {
+		t.Errorf("Translated = %#v, want %#v", got, want)
+	}
+	if c.HoverEvent.Text.Translate != "tip" {
+		t.Error("Translated modified the hover event of the original")
+	}
+}
@@ -0,0 +1,206 @@
+package types
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Characters introducing legacy formatting codes
+const (
+	LegacySection   = '§' // used by the game
+	LegacyAmpersand = '&' // common in configuration files
+)
+
+// ParseLegacy converts text with legacy formatting codes such as "&aHello"
+// into a component. Besides the sixteen colors, k to o and r, it accepts hex
+// colors as &x&r&r&g&g&b&b and &#rrggbb. Like in the game, a color code
+// resets the formatting. Unknown codes are kept as text.
+func ParseLegacy(s string, char rune) ChatComponent {
+	var segments []ChatComponent
+	var style ChatComponent
+	var text strings.Builder
+
+	flush := func() {
+		if text.Len() == 0 {
+			return
+		}
+		segment := style
+		segment.Text = text.String()
+		segments = append(segments, segment)
+		text.Reset()
+	}
+
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r != char || i+size == len(s) {
+			text.WriteString(s[i : i+size])
+			i += size
+			continue
+		}
+
+		code := lowerASCII(s[i+size])
+		next := i + size + 1
+		switch {
+		case code == 'x':
+			// Each hex digit is preceded by the code character
+			var hex strings.Builder
+			pos := next
+			for hex.Len() < 6 {
+				r, size := utf8.DecodeRuneInString(s[pos:])
+				if r != char || pos+size >= len(s) || !isHexDigit(s[pos+size]) {
+					break
+				}
+				hex.WriteByte(s[pos+size])
+				pos += size + 1
+			}
+			if hex.Len() < 6 {
+				text.WriteString(s[i:next])
+				break
+			}
+			flush()
+			color, _ := normalizeChatColor("#" + hex.String())
+			style = ChatComponent{Color: color}
+			next = pos
+		case code == '#':
+			if next+6 > len(s) {
+				text.WriteString(s[i:next])
+				break
+			}
+			color, ok := normalizeChatColor(s[next-1 : next+6])
+			if !ok {
+				text.WriteString(s[i:next])
+				break
+			}
+			flush()
+			style = ChatComponent{Color: color}
+			next += 6
+		case code == 'r':
+			flush()
+			style = ChatComponent{}
+		default:
+			if color, ok := legacyColor(code); ok {
+				flush()
+				style = ChatComponent{Color: color.Name}
+			} else if field := legacyDecoration(&style, code); field != nil {
+				flush()
+				enabled := true
+				*field = &enabled
+			} else {
+				text.WriteString(s[i:next])
+			}
+		}
+		i = next
+	}
+	flush()
+
+	switch len(segments) {
+	case 0:
+		return ChatComponent{}
+	case 1:
+		return segments[0]
+	default:
+		return ChatComponent{Extra: segments}
+	}
+}
+
+// Legacy returns the text of the component with legacy formatting codes
+// introduced by char. Hex colors are written as §x§r§r§g§g§b§b; events,
+// fonts and shadows are lost.
+func (c ChatComponent) Legacy(char rune) string {
+	var b strings.Builder
+	var last chatStyle
+
+	walkChat(&c, chatStyle{}, nil, func(text string, style chatStyle) {
+		if text == "" {
+			return
+		}
+		if style != last {
+			writeLegacyStyle(&b, char, last, style)
+			last = style
+		}
+		b.WriteString(text)
+	})
+	return b.String()
+}
+
+// writeLegacyStyle writes the codes switching from one style to another. As
+// colors reset the formatting, a changed color or a removed decoration
+// requires writing all decorations again.
+func writeLegacyStyle(b *strings.Builder, char rune, from, to chatStyle) {
+	code := func(c byte) {
+		b.WriteRune(char)
+		b.WriteByte(c)
+	}
+
+	reset := from.color != to.color ||
+		from.bold && !to.bold || from.italic && !to.italic || from.underlined && !to.underlined ||
+		from.strikethrough && !to.strikethrough || from.obfuscated && !to.obfuscated
+	if reset {
+		from = chatStyle{color: to.color}
+		if named, ok := chatColorByName(to.color); ok {
+			code(named.Code)
+		} else if rgb, ok := ParseChatColor(to.color); ok {
+			code('x')
+			for _, digit := range []byte(hexChatColor(rgb)[1:]) {
+				code(lowerASCII(digit))
+			}
+		} else {
+			code('r')
+		}
+	}
+
+	decorations := []struct {
+		from, to bool
+		code     byte
+	}{
+		{from.obfuscated, to.obfuscated, 'k'},
+		{from.bold, to.bold, 'l'},
+		{from.strikethrough, to.strikethrough, 'm'},
+		{from.underlined, to.underlined, 'n'},
+		{from.italic, to.italic, 'o'},
+	}
+	for _, decoration := range decorations {
+		if decoration.to && !decoration.from {
+			code(decoration.code)
+		}
+	}
+}
+
+func legacyColor(code byte) (ChatColor, bool) {
+	for _, color := range ChatColors {
+		if color.Code == code {
+			return color, true
+		}
+	}
+	return ChatColor{}, false
+}
+
+// legacyDecoration returns the field of the component set by a formatting code
+func legacyDecoration(c *ChatComponent, code byte) **bool {
+	switch code {
+	case 'k':
+		return &c.Obfuscated
+	case 'l':
+		return &c.Bold
+	case 'm':
+		return &c.Strikethrough
+	case 'n':
+		return &c.Underlined
+	case 'o':
+		return &c.Italic
+	default:
+		return nil
+	}
+}
+
+func lowerASCII(c byte) byte {
+	if c >= 'A' && c <= 'Z' {
+		return c + 'a' - 'A'
+	}
+	return c
+}
+
+func isHexDigit(c byte) bool {
+	c = lowerASCII(c)
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'f'
+}
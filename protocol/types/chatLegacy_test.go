@@ -0,0 +1,85 @@
+package types
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseLegacy(t *testing.T) {
+	bold := boolPtr(true)
+	tests := []struct {
+		input string
+		want  ChatComponent
+	}{
+		{"", ChatComponent{}},
+		{"plain", ChatComponent{Text: "plain"}},
+		{"&aHello", ChatComponent{Text: "Hello", Color: "green"}},
+		{"&A&lHi", ChatComponent{Text: "Hi", Color: "green", Bold: bold}},
+		{
+			"&lA&cB",
+			ChatComponent{Extra: []ChatComponent{{Text: "A", Bold: bold}, {Text: "B", Color: "red"}}},
+		},
+		{"&x&f&f&0&0&0&0red", ChatComponent{Text: "red", Color: "#FF0000"}},
+		{"&#00ff00green", ChatComponent{Text: "green", Color: "#00FF00"}},
+		{"&cA&rB", ChatComponent{Extra: []ChatComponent{{Text: "A", Color: "red"}, {Text: "B"}}}},
+		{"&zA", ChatComponent{Text: "&zA"}},
+		{"trailing&", ChatComponent{Text: "trailing&"}},
+		{
+			"&x&f&fshort",
+			ChatComponent{Extra: []ChatComponent{{Text: "&x"}, {Text: "short", Color: "white"}}},
+		},
+		{"&#12345", ChatComponent{Text: "&#12345"}},
+		{"&#ggggggX", ChatComponent{Text: "&#ggggggX"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got := ParseLegacy(tt.input, LegacyAmpersand)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseLegacy = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	if got := ParseLegacy("§6gold", LegacySection); got.Color != "gold" || got.Text != "gold" {
+		t.Errorf("ParseLegacy with § = %+v", got)
+	}
+}
+
+func TestLegacy(t *testing.T) {
+	tests := []struct {
+		name string
+		c    ChatComponent
+		want string
+	}{
+		{"plain", ChatComponent{Text: "hi"}, "hi"},
+		{"named color", ChatComponent{Text: "hi", Color: "red"}, "&chi"},
+		{"hex color", ChatComponent{Text: "hi", Color: "#A0B1C2"}, "&x&a&0&b&1&c&2hi"},
+		{
+			"inherited decorations",
+			ChatComponent{Bold: boolPtr(true), Extra: []ChatComponent{{Text: "a"}, {Text: "b", Italic: boolPtr(true)}}},
+			"&la&ob",
+		},
+		{
+			"removed decoration resets",
+			ChatComponent{Color: "red", Extra: []ChatComponent{{Text: "a", Bold: boolPtr(true)}, {Text: "b"}}},
+			"&c&la&cb",
+		},
+		{"color removed", ChatComponent{Extra: []ChatComponent{{Text: "a", Color: "red"}, {Text: "b"}}}, "&ca&rb"},
+		{"translation", ChatComponent{Translate: "%s!", With: []ChatComponent{{Text: "x", Color: "gold"}}}, "&6x&r!"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.c.Legacy(LegacyAmpersand); got != tt.want {
+				t.Errorf("Legacy = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLegacyRoundTrip(t *testing.T) {
+	for _, input := range []string{"plain", "&aHello &lWorld", "&x&1&2&3&4&5&6hex&rplain", "&c&ka&c&nb"} {
+		if got := ParseLegacy(input, LegacyAmpersand).Legacy(LegacyAmpersand); got != input {
+			t.Errorf("round trip of %q = %q", input, got)
+		}
+	}
+}
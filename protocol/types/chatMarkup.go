@@ -0,0 +1,640 @@
+package types
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ParseMarkup converts MiniMessage-style markup into a component. Supported
+// tags are colors (<red>, <#ff0000>, <color:red>), decorations (<bold>, <b>,
+// <!italic>), <reset>, <newline>, <click:action:value>, <hover:action:...>,
+// <insert:text>, <font:name>, <gradient:color:color...>, <rainbow> and the
+// content tags <key>, <lang>, <lang_or>, <selector>, <score> and <nbt>.
+// Arguments containing colons or '>' can be quoted with ' or ". Closing tags
+// close the innermost tag of that name and the ones opened after it; </>
+// closes the innermost tag. Unknown tags are kept as text, and \< escapes
+// a '<'.
+func ParseMarkup(s string) ChatComponent {
+	p := &markupParser{stack: []*markupFrame{{}}}
+	for i := 0; i < len(s); {
+		if s[i] == '\\' && i+1 < len(s) && (s[i+1] == '<' || s[i+1] == '\\') {
+			p.text.WriteByte(s[i+1])
+			i += 2
+			continue
+		}
+		if s[i] == '<' {
+			if end := markupTagEnd(s, i+1); end > 0 && p.tag(s[i+1:end]) {
+				i = end + 1
+				continue
+			}
+		}
+		p.text.WriteByte(s[i])
+		i++
+	}
+
+	p.flush()
+	p.closeTo(1)
+	root := p.stack[0].finish()
+	if len(root.Extra) == 1 && reflect.DeepEqual(root, ChatComponent{Extra: root.Extra}) {
+		return root.Extra[0]
+	}
+	return root
+}
+
+type markupFrame struct {
+	name      string
+	component ChatComponent
+	gradient  []uint32 // colors of a gradient or rainbow tag
+	rainbow   bool
+}
+
+// finish applies color transitions and folds leading plain text into the
+// component itself
+func (f *markupFrame) finish() ChatComponent {
+	c := f.component
+	if f.rainbow || len(f.gradient) > 0 {
+		total := countGradientRunes(&c)
+		index := 0
+		applyGradient(&c, func() string {
+			var rgb uint32
+			if f.rainbow {
+				rgb = rainbowColor(float64(index) / float64(total))
+			} else {
+				rgb = gradientColor(f.gradient, index, total)
+			}
+			index++
+			return hexChatColor(rgb)
+		})
+	}
+
+	if !c.hasContent() && len(c.Extra) > 0 && isPlainText(&c.Extra[0]) {
+		c.Text = c.Extra[0].Text
+		c.Extra = c.Extra[1:]
+		if len(c.Extra) == 0 {
+			c.Extra = nil
+		}
+	}
+	return c
+}
+
+type markupParser struct {
+	stack []*markupFrame
+	text  strings.Builder
+}
+
+func (p *markupParser) top() *markupFrame {
+	return p.stack[len(p.stack)-1]
+}
+
+func (p *markupParser) append(c ChatComponent) {
+	top := p.top()
+	top.component.Extra = append(top.component.Extra, c)
+}
+
+// flush appends pending text to the innermost tag
+func (p *markupParser) flush() {
+	if p.text.Len() > 0 {
+		p.append(ChatComponent{Text: p.text.String()})
+		p.text.Reset()
+	}
+}
+
+func (p *markupParser) open(frame *markupFrame) {
+	p.flush()
+	p.stack = append(p.stack, frame)
+}
+
+// closeTo closes tags until n remain open
+func (p *markupParser) closeTo(n int) {
+	for len(p.stack) > n {
+		frame := p.top()
+		p.stack = p.stack[:len(p.stack)-1]
+		c := frame.finish()
+		if reflect.DeepEqual(c, ChatComponent{Extra: c.Extra}) {
+			// Tags without style like gradients only add their children
+			for _, child := range c.Extra {
+				p.append(child)
+			}
+			continue
+		}
+		p.append(c)
+	}
+}
+
+// tag handles the contents of a tag and reports whether it was recognized
+func (p *markupParser) tag(inner string) bool {
+	args := splitMarkupArgs(inner)
+	name := strings.ToLower(args[0])
+
+	if strings.HasPrefix(name, "/") {
+		name = strings.TrimPrefix(strings.TrimPrefix(name, "/"), "!")
+		for i := len(p.stack) - 1; i > 0; i-- {
+			if name == "" || p.stack[i].name == name {
+				p.flush()
+				p.closeTo(i)
+				return true
+			}
+		}
+		return false
+	}
+
+	switch name {
+	case "reset":
+		p.flush()
+		p.closeTo(1)
+		return true
+	case "newline", "br":
+		p.text.WriteByte('\n')
+		return true
+	}
+
+	if content, ok := markupContent(name, args); ok {
+		p.flush()
+		p.append(content)
+		return true
+	}
+
+	frame, ok := markupStyle(name, args)
+	if !ok {
+		return false
+	}
+	p.open(frame)
+	return true
+}
+
+// markupStyle returns the frame opened by a style tag
+func markupStyle(name string, args []string) (*markupFrame, bool) {
+	frame := &markupFrame{name: strings.TrimPrefix(name, "!")}
+	c := &frame.component
+	rest := strings.Join(args[1:], ":")
+
+	if color, ok := normalizeChatColor(name); ok && len(args) == 1 {
+		c.Color = color
+		return frame, true
+	}
+
+	switch name {
+	case "color", "colour", "c":
+		color, ok := normalizeChatColor(rest)
+		if !ok {
+			return nil, false
+		}
+		c.Color = color
+	case "insert":
+		c.Insertion = rest
+	case "font":
+		c.Font = rest
+	case "click":
+		if len(args) < 3 {
+			return nil, false
+		}
+		switch action := strings.ToLower(args[1]); action {
+		case ClickOpenURL, ClickRunCommand, ClickSuggestCommand, ClickChangePage, ClickCopyToClipboard:
+			c.ClickEvent = &ClickEvent{Action: action, Value: strings.Join(args[2:], ":")}
+		default:
+			return nil, false
+		}
+	case "hover":
+		event, ok := markupHover(args)
+		if !ok {
+			return nil, false
+		}
+		c.HoverEvent = event
+	case "gradient":
+		for _, arg := range args[1:] {
+			if rgb, ok := ParseChatColor(arg); ok {
+				frame.gradient = append(frame.gradient, rgb)
+			} else if _, err := strconv.ParseFloat(arg, 64); err != nil {
+				return nil, false
+			}
+		}
+		switch len(frame.gradient) {
+		case 0:
+			frame.gradient = []uint32{0xFFFFFF, 0x000000}
+		case 1:
+			frame.gradient = append(frame.gradient, frame.gradient[0])
+		}
+	case "rainbow":
+		frame.rainbow = true
+	default:
+		field := markupDecoration(c, frame.name)
+		if field == nil {
+			return nil, false
+		}
+		enabled := !strings.HasPrefix(name, "!") && !(len(args) > 1 && strings.EqualFold(args[1], "false"))
+		*field = &enabled
+	}
+	return frame, true
+}
+
+func markupHover(args []string) (*HoverEvent, bool) {
+	if len(args) < 3 {
+		return nil, false
+	}
+	switch action := strings.ToLower(args[1]); action {
+	case HoverShowText:
+		return ShowText(ParseMarkup(strings.Join(args[2:], ":"))), true
+	case HoverShowItem:
+		item := &HoverItem{ID: args[2]}
+		if len(args) > 3 {
+			if count, err := strconv.ParseInt(args[3], 10, 32); err == nil {
+				item.Count = int32(count)
+			} else {
+				// The ID was not quoted
+				item.ID = args[2] + ":" + args[3]
+				if len(args) > 4 {
+					count, _ := strconv.ParseInt(args[4], 10, 32)
+					item.Count = int32(count)
+				}
+			}
+		}
+		return &HoverEvent{Action: action, Item: item}, true
+	case HoverShowEntity:
+		if len(args) < 4 {
+			return nil, false
+		}
+		id, err := ParseUUID(args[3])
+		if err != nil {
+			return nil, false
+		}
+		entity := &HoverEntity{Type: args[2], ID: id}
+		if len(args) > 4 {
+			name := ParseMarkup(strings.Join(args[4:], ":"))
+			entity.Name = &name
+		}
+		return &HoverEvent{Action: action, Entity: entity}, true
+	default:
+		return nil, false
+	}
+}
+
+// markupContent returns the component of a content tag
+func markupContent(name string, args []string) (ChatComponent, bool) {
+	switch name {
+	case "key":
+		if len(args) < 2 {
+			return ChatComponent{}, false
+		}
+		return ChatComponent{Keybind: strings.Join(args[1:], ":")}, true
+	case "lang", "tr", "translate", "lang_or", "tr_or", "translate_or":
+		if len(args) < 2 {
+			return ChatComponent{}, false
+		}
+		c := ChatComponent{Translate: args[1]}
+		with := args[2:]
+		if strings.HasSuffix(name, "_or") {
+			if len(with) == 0 {
+				return ChatComponent{}, false
+			}
+			c.Fallback, with = with[0], with[1:]
+		}
+		for _, arg := range with {
+			c.With = append(c.With, ParseMarkup(arg))
+		}
+		return c, true
+	case "selector", "sel":
+		if len(args) < 2 {
+			return ChatComponent{}, false
+		}
+		c := ChatComponent{Selector: args[1]}
+		if len(args) > 2 {
+			separator := ParseMarkup(args[2])
+			c.Separator = &separator
+		}
+		return c, true
+	case "score":
+		if len(args) != 3 {
+			return ChatComponent{}, false
+		}
+		return ChatComponent{Score: &ScoreContent{Name: args[1], Objective: args[2]}}, true
+	case "nbt", "data":
+		if len(args) < 4 {
+			return ChatComponent{}, false
+		}
+		c := ChatComponent{NBT: args[3], Source: strings.ToLower(args[1])}
+		switch c.Source {
+		case "block":
+			c.Block = args[2]
+		case "entity":
+			c.Entity = args[2]
+		case "storage":
+			c.Storage = args[2]
+		default:
+			return ChatComponent{}, false
+		}
+		for _, arg := range args[4:] {
+			if arg == "interpret" {
+				interpret := true
+				c.Interpret = &interpret
+			} else {
+				separator := ParseMarkup(arg)
+				c.Separator = &separator
+			}
+		}
+		return c, true
+	default:
+		return ChatComponent{}, false
+	}
+}
+
+func markupDecoration(c *ChatComponent, name string) **bool {
+	switch name {
+	case "bold", "b":
+		return &c.Bold
+	case "italic", "i", "em":
+		return &c.Italic
+	case "underlined", "u":
+		return &c.Underlined
+	case "strikethrough", "st":
+		return &c.Strikethrough
+	case "obfuscated", "obf":
+		return &c.Obfuscated
+	default:
+		return nil
+	}
+}
+
+// markupTagEnd returns the index of the '>' closing a tag that starts at
+// start, or -1 if the '<' does not begin a tag
+func markupTagEnd(s string, start int) int {
+	var quote byte
+	for i := start; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && i+1 < len(s) {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case (c == '\'' || c == '"') && s[i-1] == ':':
+			quote = c
+		case c == '>':
+			if i == start {
+				return -1
+			}
+			return i
+		case c == '<':
+			return -1
+		}
+	}
+	return -1
+}
+
+// splitMarkupArgs splits the contents of a tag at colons outside quotes and
+// removes the quotes
+func splitMarkupArgs(inner string) []string {
+	var args []string
+	var arg strings.Builder
+	var quote byte
+	for i := 0; i < len(inner); i++ {
+		c := inner[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && i+1 < len(inner) && (inner[i+1] == quote || inner[i+1] == '\\') {
+				i++
+				arg.WriteByte(inner[i])
+			} else if c == quote {
+				quote = 0
+			} else {
+				arg.WriteByte(c)
+			}
+		case (c == '\'' || c == '"') && arg.Len() == 0:
+			quote = c
+		case c == ':':
+			args = append(args, arg.String())
+			arg.Reset()
+		default:
+			arg.WriteByte(c)
+		}
+	}
+	return append(args, arg.String())
+}
+
+func isPlainText(c *ChatComponent) bool {
+	return reflect.DeepEqual(*c, ChatComponent{Text: c.Text})
+}
+
+// countGradientRunes counts the characters colored by a gradient. Components
+// with their own color keep it.
+func countGradientRunes(c *ChatComponent) int {
+	if c.Color != "" {
+		return 0
+	}
+	n := utf8.RuneCountInString(c.Text)
+	for i := range c.Extra {
+		n += countGradientRunes(&c.Extra[i])
+	}
+	return n
+}
+
+// applyGradient splits the text of the components into characters colored
+// by next
+func applyGradient(c *ChatComponent, next func() string) {
+	if c.Color != "" {
+		return
+	}
+
+	var chars []ChatComponent
+	for _, r := range c.Text {
+		chars = append(chars, ChatComponent{Text: string(r), Color: next()})
+	}
+	for i := range c.Extra {
+		applyGradient(&c.Extra[i], next)
+	}
+	if len(chars) > 0 {
+		c.Text = ""
+		c.Extra = append(chars, c.Extra...)
+	}
+}
+
+// gradientColor interpolates the color of character i out of total
+func gradientColor(colors []uint32, i, total int) uint32 {
+	if total <= 1 {
+		return colors[0]
+	}
+	position := float64(i) / float64(total-1) * float64(len(colors)-1)
+	segment := int(position)
+	if segment >= len(colors)-1 {
+		return colors[len(colors)-1]
+	}
+	t := position - float64(segment)
+	from, to := colors[segment], colors[segment+1]
+
+	var rgb uint32
+	for shift := 16; shift >= 0; shift -= 8 {
+		a := float64(from >> shift & 0xFF)
+		b := float64(to >> shift & 0xFF)
+		rgb |= uint32(math.Round(a+(b-a)*t)) << shift
+	}
+	return rgb
+}
+
+// rainbowColor returns the fully saturated color at a hue in [0, 1)
+func rainbowColor(hue float64) uint32 {
+	h := hue * 6
+	x := 1 - math.Abs(math.Mod(h, 2)-1)
+	var r, g, b float64
+	switch int(h) % 6 {
+	case 0:
+		r, g = 1, x
+	case 1:
+		r, g = x, 1
+	case 2:
+		g, b = 1, x
+	case 3:
+		g, b = x, 1
+	case 4:
+		r, b = x, 1
+	default:
+		r, b = 1, x
+	}
+	channel := func(v float64) uint32 { return uint32(math.Round(v * 255)) }
+	return channel(r)<<16 | channel(g)<<8 | channel(b)
+}
+
+// Markup returns the component as MiniMessage-style markup understood by
+// ParseMarkup
+func (c ChatComponent) Markup() string {
+	var b strings.Builder
+	writeMarkup(&b, &c)
+	return b.String()
+}
+
+func writeMarkup(b *strings.Builder, c *ChatComponent) {
+	var closers []string
+	open := func(name string, args ...string) {
+		b.WriteByte('<')
+		b.WriteString(name)
+		for _, arg := range args {
+			b.WriteByte(':')
+			b.WriteString(quoteMarkupArg(arg))
+		}
+		b.WriteByte('>')
+		closers = append(closers, strings.TrimPrefix(name, "!"))
+	}
+
+	if c.Color != "" {
+		if color, ok := normalizeChatColor(c.Color); ok {
+			open(strings.ToLower(color))
+		}
+	}
+	decorations := []struct {
+		name  string
+		value *bool
+	}{
+		{"bold", c.Bold},
+		{"italic", c.Italic},
+		{"underlined", c.Underlined},
+		{"strikethrough", c.Strikethrough},
+		{"obfuscated", c.Obfuscated},
+	}
+	for _, decoration := range decorations {
+		if decoration.value == nil {
+			continue
+		}
+		if *decoration.value {
+			open(decoration.name)
+		} else {
+			open("!" + decoration.name)
+		}
+	}
+	if c.Font != "" {
+		open("font", c.Font)
+	}
+	if c.Insertion != "" {
+		open("insert", c.Insertion)
+	}
+	if c.ClickEvent != nil {
+		open("click", c.ClickEvent.Action, c.ClickEvent.Value)
+	}
+	if e := c.HoverEvent; e != nil {
+		switch {
+		case e.Text != nil:
+			open("hover", e.Action, e.Text.Markup())
+		case e.Item != nil:
+			open("hover", e.Action, e.Item.ID, strconv.Itoa(int(e.Item.Count)))
+		case e.Entity != nil && e.Entity.Name != nil:
+			open("hover", e.Action, e.Entity.Type, e.Entity.ID.String(), e.Entity.Name.Markup())
+		case e.Entity != nil:
+			open("hover", e.Action, e.Entity.Type, e.Entity.ID.String())
+		}
+	}
+
+	writeMarkupContent(b, c)
+	for i := range c.Extra {
+		writeMarkup(b, &c.Extra[i])
+	}
+
+	for i := len(closers) - 1; i >= 0; i-- {
+		fmt.Fprintf(b, "</%s>", closers[i])
+	}
+}
+
+func writeMarkupContent(b *strings.Builder, c *ChatComponent) {
+	tag := func(name string, args ...string) {
+		b.WriteByte('<')
+		b.WriteString(name)
+		for _, arg := range args {
+			b.WriteByte(':')
+			b.WriteString(quoteMarkupArg(arg))
+		}
+		b.WriteByte('>')
+	}
+
+	switch {
+	case c.Text != "":
+		b.WriteString(escapeMarkup(c.Text))
+	case c.Translate != "":
+		name, args := "lang", []string{c.Translate}
+		if c.Fallback != "" {
+			name, args = "lang_or", append(args, c.Fallback)
+		}
+		for i := range c.With {
+			args = append(args, c.With[i].Markup())
+		}
+		tag(name, args...)
+	case c.Score != nil:
+		tag("score", c.Score.Name, c.Score.Objective)
+	case c.Selector != "":
+		if c.Separator != nil {
+			tag("selector", c.Selector, c.Separator.Markup())
+		} else {
+			tag("selector", c.Selector)
+		}
+	case c.Keybind != "":
+		tag("key", c.Keybind)
+	case c.NBT != "":
+		source, id := "storage", c.Storage
+		if c.Block != "" {
+			source, id = "block", c.Block
+		} else if c.Entity != "" {
+			source, id = "entity", c.Entity
+		}
+		args := []string{source, id, c.NBT}
+		if c.Separator != nil {
+			args = append(args, c.Separator.Markup())
+		}
+		if c.Interpret != nil && *c.Interpret {
+			args = append(args, "interpret")
+		}
+		tag("nbt", args...)
+	}
+}
+
+// escapeMarkup escapes text so it is not read as tags
+func escapeMarkup(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `<`, `\<`).Replace(s)
+}
+
+// quoteMarkupArg quotes a tag argument if it contains special characters
+func quoteMarkupArg(arg string) string {
+	if arg != "" && !strings.ContainsAny(arg, `:<>'"\`) {
+		return arg
+	}
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(arg) + "'"
+}
@@ -0,0 +1,114 @@
+package types
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseMarkup(t *testing.T) {
+	tests := []struct {
+		input string
+		want  ChatComponent
+	}{
+		{"plain", ChatComponent{Text: "plain"}},
+		{"<red>hi", ChatComponent{Text: "hi", Color: "red"}},
+		{"<#ff0000>hi", ChatComponent{Text: "hi", Color: "#FF0000"}},
+		{"<color:grey>hi", ChatComponent{Text: "hi", Color: "gray"}},
+		{"<b>a</b>b", ChatComponent{Extra: []ChatComponent{{Text: "a", Bold: boolPtr(true)}, {Text: "b"}}}},
+		{"<!italic>a", ChatComponent{Text: "a", Italic: boolPtr(false)}},
+		{"<bold:false>a", ChatComponent{Text: "a", Bold: boolPtr(false)}},
+		{"<red>a<reset>b", ChatComponent{Extra: []ChatComponent{{Text: "a", Color: "red"}, {Text: "b"}}}},
+		{"a<newline>b", ChatComponent{Text: "a\nb"}},
+		{
+			"<click:run_command:'/say hi'>x",
+			ChatComponent{Text: "x", ClickEvent: &ClickEvent{Action: ClickRunCommand, Value: "/say hi"}},
+		},
+		{
+			"<hover:show_text:'<red>tip'>x",
+			ChatComponent{Text: "x", HoverEvent: ShowText(ChatComponent{Text: "tip", Color: "red"})},
+		},
+		{
+			"<hover:show_item:minecraft:stone:2>x",
+			ChatComponent{Text: "x", HoverEvent: &HoverEvent{Action: HoverShowItem, Item: &HoverItem{ID: "minecraft:stone", Count: 2}}},
+		},
+		{"<insert:text><font:uniform>x", ChatComponent{Insertion: "text", Extra: []ChatComponent{{Text: "x", Font: "uniform"}}}},
+		{"<key:key.jump>", ChatComponent{Keybind: "key.jump"}},
+		{
+			"<lang_or:a.b:Fallback:'<red>x'>",
+			ChatComponent{Translate: "a.b", Fallback: "Fallback", With: []ChatComponent{{Text: "x", Color: "red"}}},
+		},
+		{"<selector:@p>", ChatComponent{Selector: "@p"}},
+		{"<score:@p:kills>", ChatComponent{Score: &ScoreContent{Name: "@p", Objective: "kills"}}},
+		{
+			"<nbt:storage:'ns:id':path:interpret>",
+			ChatComponent{NBT: "path", Source: "storage", Storage: "ns:id", Interpret: boolPtr(true)},
+		},
+		{`\<red> <unknown> <score:x>`, ChatComponent{Text: "<red> <unknown> <score:x>"}},
+		{"<red>a<b>b</red>c", ChatComponent{Extra: []ChatComponent{
+			{Color: "red", Text: "a", Extra: []ChatComponent{{Text: "b", Bold: boolPtr(true)}}},
+			{Text: "c"},
+		}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got := ParseMarkup(tt.input)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseMarkup = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseMarkupGradient(t *testing.T) {
+	got := ParseMarkup("<gradient:#000000:#ffffff>abc")
+	var colors []string
+	for _, child := range got.Extra {
+		colors = append(colors, child.Color)
+	}
+	if got.PlainText() != "abc" || len(colors) != 3 || colors[0] != "#000000" || colors[2] != "#FFFFFF" {
+		t.Errorf("gradient = %+v", got)
+	}
+
+	rainbow := ParseMarkup("<rainbow>ab")
+	if rainbow.PlainText() != "ab" || len(rainbow.Extra) != 2 || rainbow.Extra[0].Color == rainbow.Extra[1].Color {
+		t.Errorf("rainbow = %+v", rainbow)
+	}
+}
+
+func TestMarkupRoundTrip(t *testing.T) {
+	tests := []ChatComponent{
+		{Text: "plain <with> tag\\"},
+		{Text: "a", Color: "#12AB34"},
+		{Text: "x", Italic: boolPtr(false)},
+		{Text: "x", Font: "minecraft:uniform"},
+		{Text: "x", ClickEvent: &ClickEvent{Action: ClickOpenURL, Value: "https://example.com"}},
+		{Text: "x", HoverEvent: ShowText(ChatComponent{Text: "tip", Color: "red"})},
+		{Text: "x", HoverEvent: &HoverEvent{Action: HoverShowItem, Item: &HoverItem{ID: "minecraft:stone", Count: 3}}},
+		{Text: "x", HoverEvent: &HoverEvent{Action: HoverShowEntity, Entity: &HoverEntity{
+			Type: "minecraft:pig",
+			ID:   UUID{MostSignificantBits: 1, LeastSignificantBits: 2},
+			Name: &ChatComponent{Text: "Pig"},
+		}}},
+		{Translate: "a.b", Fallback: "fb", With: []ChatComponent{{Text: "x"}}},
+		{Selector: "@a", Separator: &ChatComponent{Text: ", "}},
+		{Score: &ScoreContent{Name: "@p", Objective: "kills"}},
+		{Keybind: "key.jump"},
+		{NBT: "path", Source: "entity", Entity: "@s", Interpret: boolPtr(true)},
+		{Color: "red", Text: "a", Extra: []ChatComponent{{Text: "b", Underlined: boolPtr(true)}}},
+	}
+	for _, want := range tests {
+		markup := want.Markup()
+		if got := ParseMarkup(markup); !reflect.DeepEqual(got, want) {
+			t.Errorf("round trip through %q = %+v, want %+v", markup, got, want)
+		}
+	}
+
+	// Each style tag opens a child component, so several tags nest the text
+	// but keep the displayed style
+	nested := ChatComponent{Text: "a", Color: "#12AB34", Bold: boolPtr(true), Insertion: "ins"}
+	markup := nested.Markup()
+	got := ParseMarkup(markup)
+	if got.Markup() != markup || got.Legacy(LegacyAmpersand) != nested.Legacy(LegacyAmpersand) {
+		t.Errorf("round trip through %q = %q", markup, got.Markup())
+	}
+}
@@ -0,0 +1,228 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// chatBooleans are the component keys holding booleans, which NBT stores as
+// bytes
+var chatBooleans = map[string]bool{
+	"bold":          true,
+	"italic":        true,
+	"underlined":    true,
+	"strikethrough": true,
+	"obfuscated":    true,
+	"interpret":     true,
+}
+
+// ToNBT converts the component to the NBT form sent since 1.20.3. A plain
+// text component becomes a string tag.
+func (c ChatComponent) ToNBT() (NBTValue, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	value, err := jsonToNBT(decoder)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert chat to NBT: %v", err)
+	}
+
+	if compound, ok := value.(*NBTCompound); ok && compound.Len() == 1 {
+		if text, ok := compound.Get("text"); ok {
+			return text, nil
+		}
+	}
+	return value, nil
+}
+
+// ChatComponentFromNBT converts the NBT form of a component. Like in JSON, a
+// string is a text component and a list is a parent followed by its extras.
+func ChatComponentFromNBT(value NBTValue) (ChatComponent, error) {
+	var buf bytes.Buffer
+	if err := nbtToJSON(&buf, value, ""); err != nil {
+		return ChatComponent{}, fmt.Errorf("failed to convert chat from NBT: %v", err)
+	}
+
+	var c ChatComponent
+	if err := json.Unmarshal(buf.Bytes(), &c); err != nil {
+		return ChatComponent{}, fmt.Errorf("failed to unmarshal chat: %v", err)
+	}
+	return c, nil
+}
+
+// WriteNBTChat writes a component as network NBT, as in packets since 1.20.3
+func WriteNBTChat(c Chat, w io.Writer) error {
+	value, err := ChatComponent(c).ToNBT()
+	if err != nil {
+		return err
+	}
+	return WriteNetworkNBT(value, w)
+}
+
+// ReadNBTChat reads a component sent as network NBT
+func ReadNBTChat(r io.Reader) (Chat, error) {
+	value, err := ReadNetworkNBT(r)
+	if err != nil {
+		return Chat{}, fmt.Errorf("failed to read chat: %v", err)
+	}
+	c, err := ChatComponentFromNBT(value)
+	return Chat(c), err
+}
+
+// jsonToNBT converts the next JSON value, keeping the order of object keys
+func jsonToNBT(decoder *json.Decoder) (NBTValue, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := token.(type) {
+	case string:
+		return t, nil
+	case bool:
+		if t {
+			return int8(1), nil
+		}
+		return int8(0), nil
+	case json.Number:
+		if n, err := strconv.ParseInt(t.String(), 10, 32); err == nil {
+			return int32(n), nil
+		}
+		if n, err := strconv.ParseInt(t.String(), 10, 64); err == nil {
+			return n, nil
+		}
+		return t.Float64()
+	case json.Delim:
+		if t == '{' {
+			compound := &NBTCompound{}
+			for decoder.More() {
+				key, err := decoder.Token()
+				if err != nil {
+					return nil, err
+				}
+				value, err := jsonToNBT(decoder)
+				if err != nil {
+					return nil, err
+				}
+				compound.Set(key.(string), value)
+			}
+			_, err := decoder.Token() // }
+			return compound, err
+		}
+
+		var values []NBTValue
+		for decoder.More() {
+			value, err := jsonToNBT(decoder)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+		if _, err := decoder.Token(); err != nil { // ]
+			return nil, err
+		}
+		return nbtListOf(values), nil
+	default:
+		return nil, fmt.Errorf("null has no NBT representation")
+	}
+}
+
+// nbtListOf builds a list tag. Like vanilla, elements of mixed types are
+// wrapped in compounds with an empty key.
+func nbtListOf(values []NBTValue) *NBTList {
+	list := &NBTList{Values: values}
+	for i, value := range values {
+		tagType, _ := nbtTagOf(value)
+		if i == 0 {
+			list.ElemType = tagType
+		} else if tagType != list.ElemType {
+			list.ElemType = TagEnd
+			break
+		}
+	}
+	if list.ElemType != TagEnd || len(values) == 0 {
+		return list
+	}
+
+	wrapped := &NBTList{ElemType: TagCompound}
+	for _, value := range values {
+		if compound, ok := value.(*NBTCompound); ok {
+			if _, wrappedAlready := compound.Get(""); !wrappedAlready {
+				wrapped.Values = append(wrapped.Values, compound)
+				continue
+			}
+		}
+		wrapped.Values = append(wrapped.Values, &NBTCompound{Entries: []NBTEntry{{Name: "", Value: value}}})
+	}
+	return wrapped
+}
+
+func nbtToJSON(buf *bytes.Buffer, value NBTValue, key string) error {
+	var data []byte
+	var err error
+
+	switch v := value.(type) {
+	case int8:
+		if chatBooleans[key] {
+			data, err = json.Marshal(v != 0)
+		} else {
+			data, err = json.Marshal(v)
+		}
+	case bool, int16, int32, int64, float32, float64, string, []int32, []int64:
+		data, err = json.Marshal(v)
+	case []byte:
+		ints := make([]int8, len(v))
+		for i, b := range v {
+			ints[i] = int8(b)
+		}
+		data, err = json.Marshal(ints)
+	case *NBTList:
+		buf.WriteByte('[')
+		for i, elem := range v.Values {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			// Unwrap elements of mixed lists
+			if compound, ok := elem.(*NBTCompound); ok && compound.Len() == 1 {
+				if inner, ok := compound.Get(""); ok {
+					elem = inner
+				}
+			}
+			if err := nbtToJSON(buf, elem, ""); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+		return nil
+	case *NBTCompound:
+		buf.WriteByte('{')
+		for i, entry := range v.Entries {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			name, _ := json.Marshal(entry.Name)
+			buf.Write(name)
+			buf.WriteByte(':')
+			if err := nbtToJSON(buf, entry.Value, entry.Name); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+		return nil
+	default:
+		return fmt.Errorf("unsupported NBT value type %T", value)
+	}
+
+	if err != nil {
+		return err
+	}
+	buf.Write(data)
+	return nil
+}
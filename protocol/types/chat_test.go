@@ -0,0 +1,145 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func TestChatComponentUnmarshalJSON(t *testing.T) {
+	entity := UUID{MostSignificantBits: 0x0123456789abcdef, LeastSignificantBits: -2}
+	tests := []struct {
+		name  string
+		input string
+		want  ChatComponent
+	}{
+		{"string", `"hi"`, ChatComponent{Text: "hi"}},
+		{"number", `1.5`, ChatComponent{Text: "1.5"}},
+		{"boolean", `true`, ChatComponent{Text: "true"}},
+		{"array", `["a",{"text":"b","bold":true}]`, ChatComponent{Text: "a", Extra: []ChatComponent{{Text: "b", Bold: boolPtr(true)}}}},
+		{
+			"translate",
+			`{"translate":"chat.type.text","with":["Steve",{"text":"hi"}],"fallback":"%s: %s"}`,
+			ChatComponent{Translate: "chat.type.text", With: []ChatComponent{{Text: "Steve"}, {Text: "hi"}}, Fallback: "%s: %s"},
+		},
+		{"score", `{"score":{"name":"@p","objective":"kills"}}`, ChatComponent{Score: &ScoreContent{Name: "@p", Objective: "kills"}}},
+		{"selector", `{"selector":"@a","separator":{"text":"|"}}`, ChatComponent{Selector: "@a", Separator: &ChatComponent{Text: "|"}}},
+		{"keybind", `{"keybind":"key.jump"}`, ChatComponent{Keybind: "key.jump"}},
+		{
+			"nbt",
+			`{"nbt":"Items[0]","interpret":false,"block":"1 2 3","source":"block"}`,
+			ChatComponent{NBT: "Items[0]", Interpret: boolPtr(false), Block: "1 2 3", Source: "block"},
+		},
+		{"shadow color int", `{"text":"","shadow_color":-16777216}`, ChatComponent{ShadowColor: ptrTo(ShadowColor(0xFF000000))}},
+		{"shadow color floats", `{"text":"","shadow_color":[1,0,0,1]}`, ChatComponent{ShadowColor: ptrTo(ShadowColor(0xFFFF0000))}},
+		{
+			"legacy hover value",
+			`{"text":"","hoverEvent":{"action":"show_text","value":"tip"}}`,
+			ChatComponent{HoverEvent: ShowText(ChatComponent{Text: "tip"})},
+		},
+		{
+			"hover item id",
+			`{"text":"","hoverEvent":{"action":"show_item","contents":"minecraft:stone"}}`,
+			ChatComponent{HoverEvent: &HoverEvent{Action: HoverShowItem, Item: &HoverItem{ID: "minecraft:stone"}}},
+		},
+		{
+			"hover entity int array",
+			`{"text":"","hoverEvent":{"action":"show_entity","contents":{"type":"minecraft:pig","id":[19088743,-1985229329,-1,-2]}}}`,
+			ChatComponent{HoverEvent: &HoverEvent{Action: HoverShowEntity, Entity: &HoverEntity{Type: "minecraft:pig", ID: entity}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got ChatComponent
+			if err := json.Unmarshal([]byte(tt.input), &got); err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Unmarshal = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func ptrTo[T any](v T) *T {
+	return &v
+}
+
+func TestChatComponentUnmarshalJSONErrors(t *testing.T) {
+	tests := []string{
+		`[]`,
+		`{"text":"","hoverEvent":{"action":"explode","contents":""}}`,
+		`{"text":"","hoverEvent":{"action":"show_entity","contents":{"type":"pig","id":"not a uuid"}}}`,
+		`{"text":"","shadow_color":"red"}`,
+		`{"text":`,
+	}
+	for _, input := range tests {
+		var c ChatComponent
+		if err := json.Unmarshal([]byte(input), &c); err == nil {
+			t.Errorf("Unmarshal(%s) = %+v, want error", input, c)
+		}
+	}
+}
+
+func TestChatComponentMarshalJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		c    ChatComponent
+		want string
+	}{
+		{"empty", ChatComponent{}, `{"text":""}`},
+		{"style only", ChatComponent{Color: "red"}, `{"text":"","color":"red"}`},
+		{"text", ChatComponent{Text: "hi", Bold: boolPtr(false)}, `{"text":"hi","bold":false}`},
+		{
+			"click",
+			ChatComponent{Text: "x", ClickEvent: &ClickEvent{Action: ClickOpenURL, Value: "https://example.com"}},
+			`{"text":"x","clickEvent":{"action":"open_url","value":"https://example.com"}}`,
+		},
+		{"hover", ChatComponent{Text: "x", HoverEvent: ShowText(ChatComponent{Text: "t"})}, `{"text":"x","hoverEvent":{"action":"show_text","contents":{"text":"t"}}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := json.Marshal(tt.c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Marshal = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := json.Marshal(ChatComponent{HoverEvent: &HoverEvent{Action: "explode"}}); err == nil {
+		t.Error("Marshal of an unknown hover action succeeded")
+	}
+}
+
+func TestChatWire(t *testing.T) {
+	want := Chat{
+		Text:  "hello",
+		Color: "#FF0000",
+		HoverEvent: &HoverEvent{Action: HoverShowEntity, Entity: &HoverEntity{
+			Type: "minecraft:pig",
+			ID:   UUID{MostSignificantBits: 1, LeastSignificantBits: 2},
+			Name: &ChatComponent{Text: "Pig"},
+		}},
+		Extra: []ChatComponent{{Translate: "key", With: []ChatComponent{{Keybind: "key.jump"}}}},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteChat(want, &buf); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadChat(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("round trip = %+v, want %+v", got, want)
+	}
+}
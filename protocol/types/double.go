@@ -3,6 +3,7 @@ package types
 import (
 	"encoding/binary"
 	"fmt"
+	"io"
 	"math"
 )
 
@@ -21,3 +22,30 @@ func (d *Double) Unmarshal(data []byte) error {
 	*d = Double(math.Float64frombits(binary.BigEndian.Uint64(data)))
 	return nil
 }
+
+func WriteDouble(d Double, w io.Writer) error {
+	buf, err := d.Marshal()
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(buf)
+	return err
+}
+
+func ReadDouble(r io.Reader) (Double, error) {
+	buf := make([]byte, 8)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return 0, fmt.Errorf("failed to read double: %v", err)
+	}
+
+	var d Double
+	if err := d.Unmarshal(buf); err != nil {
+		return 0, err
+	}
+	return d, nil
+}
+
+func (d *Double) Decode(r io.Reader) (err error) {
+	*d, err = ReadDouble(r)
+	return err
+}
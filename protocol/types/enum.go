@@ -0,0 +1,110 @@
+package types
+
+import (
+	"fmt"
+	"io"
+)
+
+// Enumeration is implemented by enum types, whose values range from 0 to
+// EnumCount()-1. EnumCount is called on the zero value.
+type Enumeration interface {
+	~int32
+	EnumCount() int
+}
+
+// enumCount returns the number of values of an enum type
+func enumCount[E Enumeration]() int {
+	var zero E
+	return zero.EnumCount()
+}
+
+// Enum is an enum value encoded as a VarInt. Values outside the enum are
+// rejected.
+type Enum[E Enumeration] struct {
+	Value E
+}
+
+func (e Enum[E]) Marshal() ([]byte, error) {
+	if e.Value < 0 || int(e.Value) >= enumCount[E]() {
+		return nil, fmt.Errorf("invalid %T value %d", e.Value, e.Value)
+	}
+	return VarInt(e.Value).Marshal()
+}
+
+func (e *Enum[E]) Unmarshal(data []byte) error {
+	return unmarshalWith(e, data)
+}
+
+func (e *Enum[E]) Decode(r io.Reader) error {
+	value, err := ReadVarInt(r)
+	if err != nil {
+		return err
+	}
+	if value < 0 || int(value) >= enumCount[E]() {
+		return fmt.Errorf("invalid %T value %d", e.Value, value)
+	}
+	e.Value = E(value)
+	return nil
+}
+
+// EnumSet is a set of values of an enum type, encoded as a fixed bit set with
+// a bit for each value
+type EnumSet[E Enumeration] struct {
+	bits FixedBitSet
+}
+
+// NewEnumSet returns a set of the given values
+func NewEnumSet[E Enumeration](values ...E) EnumSet[E] {
+	var s EnumSet[E]
+	for _, value := range values {
+		s.Add(value)
+	}
+	return s
+}
+
+// Has reports whether the set contains value
+func (s EnumSet[E]) Has(value E) bool {
+	return s.bits.Get(int(value))
+}
+
+// Add adds value, which must be a valid value of the enum
+func (s *EnumSet[E]) Add(value E) {
+	if s.bits == nil {
+		s.bits = NewFixedBitSet(enumCount[E]())
+	}
+	s.bits.Set(int(value), true)
+}
+
+// Remove removes value from the set
+func (s *EnumSet[E]) Remove(value E) {
+	if s.Has(value) {
+		s.bits.Set(int(value), false)
+	}
+}
+
+// Values returns the values in the set in ascending order
+func (s EnumSet[E]) Values() []E {
+	var values []E
+	for i := 0; i < enumCount[E](); i++ {
+		if s.bits.Get(i) {
+			values = append(values, E(i))
+		}
+	}
+	return values
+}
+
+func (s EnumSet[E]) Marshal() ([]byte, error) {
+	if s.bits == nil {
+		return NewFixedBitSet(enumCount[E]()), nil
+	}
+	return s.bits.Marshal()
+}
+
+func (s *EnumSet[E]) Unmarshal(data []byte) error {
+	return unmarshalWith(s, data)
+}
+
+func (s *EnumSet[E]) Decode(r io.Reader) (err error) {
+	s.bits, err = ReadFixedBitSet(r, enumCount[E]())
+	return err
+}
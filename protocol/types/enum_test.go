@@ -0,0 +1,76 @@
+package types
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+type testColor int32
+
+const (
+	testRed testColor = iota
+	testGreen
+	testBlue
+	testColorCount
+)
+
+func (testColor) EnumCount() int { return int(testColorCount) }
+
+func TestEnum(t *testing.T) {
+	data, err := Enum[testColor]{Value: testBlue}.Marshal()
+	if err != nil || !bytes.Equal(data, []byte{2}) {
+		t.Errorf("Marshal = % x, %v", data, err)
+	}
+	var got Enum[testColor]
+	if err := got.Unmarshal(data); err != nil || got.Value != testBlue {
+		t.Errorf("Unmarshal = %v, %v", got.Value, err)
+	}
+
+	for _, value := range []testColor{-1, testColorCount} {
+		if _, err := (Enum[testColor]{Value: value}).Marshal(); err == nil {
+			t.Errorf("Marshal of %d succeeded", value)
+		}
+	}
+	for _, data := range [][]byte{nil, {3}, {0xff, 0xff, 0xff, 0xff, 0x0f}} {
+		if err := got.Unmarshal(data); err == nil {
+			t.Errorf("Unmarshal(% x) = %v, want error", data, got.Value)
+		}
+	}
+}
+
+func TestEnumSet(t *testing.T) {
+	s := NewEnumSet(testBlue, testRed)
+	if !s.Has(testRed) || s.Has(testGreen) || !s.Has(testBlue) {
+		t.Errorf("Has returned wrong values for %v", s.Values())
+	}
+	s.Remove(testRed)
+	s.Remove(testGreen)
+	if got := s.Values(); !reflect.DeepEqual(got, []testColor{testBlue}) {
+		t.Errorf("Values = %v", got)
+	}
+
+	tests := []struct {
+		name string
+		s    EnumSet[testColor]
+		want []byte
+	}{
+		{"zero value", EnumSet[testColor]{}, []byte{0}},
+		{"values", NewEnumSet(testGreen, testBlue), []byte{0x06}},
+	}
+	for _, tt := range tests {
+		data, err := tt.s.Marshal()
+		if err != nil || !bytes.Equal(data, tt.want) {
+			t.Errorf("%s: Marshal = % x, %v, want % x", tt.name, data, err, tt.want)
+		}
+		var got EnumSet[testColor]
+		if err := got.Unmarshal(tt.want); err != nil || !reflect.DeepEqual(got.Values(), tt.s.Values()) {
+			t.Errorf("%s: Unmarshal = %v, %v", tt.name, got.Values(), err)
+		}
+	}
+
+	var empty EnumSet[testColor]
+	if err := empty.Unmarshal(nil); err == nil {
+		t.Error("Unmarshal of missing data succeeded")
+	}
+}
@@ -3,6 +3,7 @@ package types
 import (
 	"encoding/binary"
 	"fmt"
+	"io"
 	"math"
 )
 
@@ -21,3 +22,30 @@ func (f *Float) Unmarshal(data []byte) error {
 	*f = Float(math.Float32frombits(binary.BigEndian.Uint32(data)))
 	return nil
 }
+
+func WriteFloat(f Float, w io.Writer) error {
+	buf, err := f.Marshal()
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(buf)
+	return err
+}
+
+func ReadFloat(r io.Reader) (Float, error) {
+	buf := make([]byte, 4)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return 0, fmt.Errorf("failed to read float: %v", err)
+	}
+
+	var f Float
+	if err := f.Unmarshal(buf); err != nil {
+		return 0, err
+	}
+	return f, nil
+}
+
+func (f *Float) Decode(r io.Reader) (err error) {
+	*f, err = ReadFloat(r)
+	return err
+}
@@ -0,0 +1,54 @@
+package types
+
+import (
+	"fmt"
+	"io"
+)
+
+// IDOr is either the ID of a registry entry or an inline value. It is encoded
+// as a VarInt that is 0 before an inline value and the ID plus one otherwise.
+// PT is always *T, as in IDOr[String, *String].
+type IDOr[T marshaler, PT element[T]] struct {
+	ID     VarInt // used unless Inline is set
+	Value  T
+	Inline bool
+}
+
+func (o IDOr[T, PT]) Marshal() ([]byte, error) {
+	if !o.Inline {
+		if o.ID < 0 {
+			return nil, fmt.Errorf("registry ID %d is negative", o.ID)
+		}
+		return (o.ID + 1).Marshal()
+	}
+	data, err := o.Value.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{0}, data...), nil
+}
+
+func (o *IDOr[T, PT]) Unmarshal(data []byte) error {
+	return unmarshalWith(o, data)
+}
+
+func (o *IDOr[T, PT]) Decode(r io.Reader) error {
+	id, err := ReadVarInt(r)
+	if err != nil {
+		return fmt.Errorf("failed to read registry ID: %v", err)
+	}
+	if id < 0 {
+		return fmt.Errorf("registry ID %d is negative", id-1)
+	}
+	if id > 0 {
+		*o = IDOr[T, PT]{ID: id - 1}
+		return nil
+	}
+
+	var value T
+	if err := PT(&value).Decode(r); err != nil {
+		return err
+	}
+	*o = IDOr[T, PT]{Value: value, Inline: true}
+	return nil
+}
@@ -0,0 +1,52 @@
+package types
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestIDOr(t *testing.T) {
+	tests := []struct {
+		name  string
+		value IDOr[String, *String]
+		want  []byte
+	}{
+		{"id zero", IDOr[String, *String]{ID: 0}, []byte{1}},
+		{"id", IDOr[String, *String]{ID: 127}, []byte{0x80, 0x01}},
+		{"inline", IDOr[String, *String]{Value: String{Value: "x"}, Inline: true}, []byte{0, 1, 'x'}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := tt.value.Marshal()
+			if err != nil || !bytes.Equal(data, tt.want) {
+				t.Errorf("Marshal = % x, %v, want % x", data, err, tt.want)
+			}
+
+			got := IDOr[String, *String]{ID: 5, Value: String{Value: "stale"}}
+			if err := got.Unmarshal(tt.want); err != nil || got != tt.value {
+				t.Errorf("Unmarshal = %+v, %v, want %+v", got, err, tt.value)
+			}
+		})
+	}
+}
+
+func TestIDOrErrors(t *testing.T) {
+	if _, err := (IDOr[String, *String]{ID: -1}).Marshal(); err == nil {
+		t.Error("Marshal of a negative ID succeeded")
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"negative", []byte{0xff, 0xff, 0xff, 0xff, 0x0f}},
+		{"missing inline value", []byte{0}},
+	}
+	for _, tt := range tests {
+		var o IDOr[String, *String]
+		if err := o.Unmarshal(tt.data); err == nil {
+			t.Errorf("%s: Unmarshal = %+v, want error", tt.name, o)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package types
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// DefaultNamespace is the namespace of identifiers written without one
+const DefaultNamespace = "minecraft"
+
+// Identifier is a namespaced key such as minecraft:brand, also known as a
+// resource location. An empty namespace stands for DefaultNamespace.
+type Identifier struct {
+	Namespace string
+	Path      string
+}
+
+// NewIdentifier returns the identifier namespace:path after validating both
+// parts
+func NewIdentifier(namespace, path string) (Identifier, error) {
+	id := Identifier{Namespace: namespace, Path: path}
+	if err := id.Validate(); err != nil {
+		return Identifier{}, err
+	}
+	return id, nil
+}
+
+// ParseIdentifier parses namespace:path. Without a namespace, as in brand or
+// :brand, the default namespace is used.
+func ParseIdentifier(s string) (Identifier, error) {
+	namespace, path, found := strings.Cut(s, ":")
+	if !found {
+		namespace, path = "", s
+	}
+	if namespace == "" {
+		namespace = DefaultNamespace
+	}
+	return NewIdentifier(namespace, path)
+}
+
+// MustParseIdentifier is like ParseIdentifier but panics on invalid
+// identifiers. It is meant for constants.
+func MustParseIdentifier(s string) Identifier {
+	id, err := ParseIdentifier(s)
+	if err != nil {
+		panic(err)
+	}
+	return id
+}
+
+// Validate checks that the namespace only contains a-z, 0-9, _, - and . and
+// that the path may additionally contain /
+func (id Identifier) Validate() error {
+	if id.Namespace != "" && !IsValidNamespace(id.Namespace) {
+		return fmt.Errorf("invalid identifier %q: namespace contains characters other than a-z0-9_.-", id.String())
+	}
+	if !IsValidPath(id.Path) {
+		return fmt.Errorf("invalid identifier %q: path contains characters other than a-z0-9_.-/", id.String())
+	}
+	return nil
+}
+
+// IsValidNamespace reports whether s is a non-empty valid namespace
+func IsValidNamespace(s string) bool {
+	return s != "" && isIdentifierText(s, false)
+}
+
+// IsValidPath reports whether s is a valid path, which may be empty
+func IsValidPath(s string) bool {
+	return isIdentifierText(s, true)
+}
+
+func isIdentifierText(s string, slash bool) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '_' || c == '-' || c == '.' || slash && c == '/') {
+			return false
+		}
+	}
+	return true
+}
+
+// String returns namespace:path, always including the namespace
+func (id Identifier) String() string {
+	namespace := id.Namespace
+	if namespace == "" {
+		namespace = DefaultNamespace
+	}
+	return namespace + ":" + id.Path
+}
+
+// ShortString returns the identifier without the default namespace, as
+// written by the game in commands
+func (id Identifier) ShortString() string {
+	if id.Namespace == "" || id.Namespace == DefaultNamespace {
+		return id.Path
+	}
+	return id.String()
+}
+
+// Equal reports whether two identifiers are the same, treating an empty
+// namespace as the default namespace
+func (id Identifier) Equal(other Identifier) bool {
+	return id.String() == other.String()
+}
+
+func (id Identifier) MarshalText() ([]byte, error) {
+	if err := id.Validate(); err != nil {
+		return nil, err
+	}
+	return []byte(id.String()), nil
+}
+
+func (id *Identifier) UnmarshalText(text []byte) error {
+	parsed, err := ParseIdentifier(string(text))
+	if err != nil {
+		return err
+	}
+	*id = parsed
+	return nil
+}
+
+func (id Identifier) Marshal() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := WriteIdentifier(id, &buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (id *Identifier) Unmarshal(data []byte) error {
+	return unmarshalWith(id, data)
+}
+
+func WriteIdentifier(id Identifier, w io.Writer) error {
+	if err := id.Validate(); err != nil {
+		return err
+	}
+	return WriteString(String{Value: id.String()}, w)
+}
+
+func ReadIdentifier(r io.Reader) (Identifier, error) {
+	s, err := ReadString(r)
+	if err != nil {
+		return Identifier{}, fmt.Errorf("failed to read identifier: %v", err)
+	}
+	return ParseIdentifier(s.Value)
+}
+
+func (id *Identifier) Decode(r io.Reader) (err error) {
+	*id, err = ReadIdentifier(r)
+	return err
+}
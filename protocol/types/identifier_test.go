@@ -0,0 +1,128 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestParseIdentifier(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Identifier
+		short string
+	}{
+		{"minecraft:brand", Identifier{"minecraft", "brand"}, "brand"},
+		{"brand", Identifier{"minecraft", "brand"}, "brand"},
+		{":brand", Identifier{"minecraft", "brand"}, "brand"},
+		{"my_mod:items/sword.v2", Identifier{"my_mod", "items/sword.v2"}, "my_mod:items/sword.v2"},
+		{"a-b.c:", Identifier{"a-b.c", ""}, "a-b.c:"},
+	}
+	for _, tt := range tests {
+		got, err := ParseIdentifier(tt.input)
+		if err != nil {
+			t.Errorf("ParseIdentifier(%q): %v", tt.input, err)
+			continue
+		}
+		if got != tt.want || got.ShortString() != tt.short {
+			t.Errorf("ParseIdentifier(%q) = %v, short %q, want %v, %q", tt.input, got, got.ShortString(), tt.want, tt.short)
+		}
+	}
+}
+
+func TestParseIdentifierErrors(t *testing.T) {
+	for _, input := range []string{"Minecraft:brand", "minecraft:Brand", "my/mod:x", "a:b:c", "a b", "é"} {
+		if id, err := ParseIdentifier(input); err == nil {
+			t.Errorf("ParseIdentifier(%q) = %v, want error", input, id)
+		}
+	}
+	if _, err := NewIdentifier("ns", "UPPER"); err == nil {
+		t.Error("NewIdentifier with an invalid path succeeded")
+	}
+	if err := (Identifier{Path: "ok"}).Validate(); err != nil {
+		t.Errorf("Validate with the default namespace: %v", err)
+	}
+}
+
+func TestIdentifierEqual(t *testing.T) {
+	if !(Identifier{Path: "x"}).Equal(Identifier{Namespace: "minecraft", Path: "x"}) {
+		t.Error("empty namespace is not equal to the default namespace")
+	}
+	if (Identifier{Namespace: "a", Path: "x"}).Equal(Identifier{Namespace: "b", Path: "x"}) {
+		t.Error("identifiers of different namespaces are equal")
+	}
+}
+
+func TestIdentifierText(t *testing.T) {
+	type config struct {
+		Channel Identifier `json:"channel"`
+	}
+	data, err := json.Marshal(config{Identifier{Path: "brand"}})
+	if err != nil || string(data) != `{"channel":"minecraft:brand"}` {
+		t.Errorf("json.Marshal = %s, %v", data, err)
+	}
+
+	var got config
+	if err := json.Unmarshal([]byte(`{"channel":"mod:x"}`), &got); err != nil || got.Channel != (Identifier{"mod", "x"}) {
+		t.Errorf("json.Unmarshal = %v, %v", got.Channel, err)
+	}
+	if err := json.Unmarshal([]byte(`{"channel":"Bad"}`), &got); err == nil {
+		t.Error("json.Unmarshal of an invalid identifier succeeded")
+	}
+	if _, err := json.Marshal(config{Identifier{Path: "Bad"}}); err == nil {
+		t.Error("json.Marshal of an invalid identifier succeeded")
+	}
+}
+
+func TestIdentifierWire(t *testing.T) {
+	id := Identifier{Namespace: "mod", Path: "channel"}
+	want := append([]byte{11}, "mod:channel"...)
+
+	data, err := id.Marshal()
+	if err != nil || !bytes.Equal(data, want) {
+		t.Errorf("Marshal = % x, %v, want % x", data, err, want)
+	}
+	var buf bytes.Buffer
+	if err := WriteIdentifier(id, &buf); err != nil || !bytes.Equal(buf.Bytes(), want) {
+		t.Errorf("WriteIdentifier = % x, %v, want % x", buf.Bytes(), err, want)
+	}
+
+	var got Identifier
+	if err := got.Unmarshal(want); err != nil || got != id {
+		t.Errorf("Unmarshal = %v, %v, want %v", got, err, id)
+	}
+	if got, err := ReadIdentifier(bytes.NewReader(want)); err != nil || got != id {
+		t.Errorf("ReadIdentifier = %v, %v, want %v", got, err, id)
+	}
+
+	if _, err := (Identifier{Path: "Bad"}).Marshal(); err == nil {
+		t.Error("Marshal of an invalid identifier succeeded")
+	}
+}
+
+func TestIdentifierWireErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"negative length", []byte{0xff, 0xff, 0xff, 0xff, 0x0f, 'a'}},
+		{"truncated", []byte{0x05, 'a'}},
+		{"invalid", append([]byte{0x03}, "A:b"...)},
+		{"invalid UTF-8", []byte{0x01, 0xff}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var id Identifier
+			if err := id.Unmarshal(tt.data); err == nil {
+				t.Errorf("Unmarshal = %v, want error", id)
+			}
+			if id, err := ReadIdentifier(bytes.NewReader(tt.data)); err == nil {
+				t.Errorf("ReadIdentifier = %v, want error", id)
+			}
+			if id, err := NewBuffer(tt.data).GetIdentifier(); err == nil {
+				t.Errorf("GetIdentifier = %v, want error", id)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package types
 import (
 	"encoding/binary"
 	"fmt"
+	"io"
 )
 
 type Int int32
@@ -20,3 +21,30 @@ func (i *Int) Unmarshal(data []byte) error {
 	*i = Int(binary.BigEndian.Uint32(data))
 	return nil
 }
+
+func WriteInt(i Int, w io.Writer) error {
+	buf, err := i.Marshal()
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(buf)
+	return err
+}
+
+func ReadInt(r io.Reader) (Int, error) {
+	buf := make([]byte, 4)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return 0, fmt.Errorf("failed to read int: %v", err)
+	}
+
+	var i Int
+	if err := i.Unmarshal(buf); err != nil {
+		return 0, err
+	}
+	return i, nil
+}
+
+func (i *Int) Decode(r io.Reader) (err error) {
+	*i, err = ReadInt(r)
+	return err
+}
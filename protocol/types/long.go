@@ -3,6 +3,7 @@ package types
 import (
 	"encoding/binary"
 	"fmt"
+	"io"
 )
 
 type Long int64
@@ -20,3 +21,30 @@ func (l *Long) Unmarshal(data []byte) error {
 	*l = Long(binary.BigEndian.Uint64(data))
 	return nil
 }
+
+func WriteLong(l Long, w io.Writer) error {
+	buf, err := l.Marshal()
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(buf)
+	return err
+}
+
+func ReadLong(r io.Reader) (Long, error) {
+	buf := make([]byte, 8)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return 0, fmt.Errorf("failed to read long: %v", err)
+	}
+
+	var l Long
+	if err := l.Unmarshal(buf); err != nil {
+		return 0, err
+	}
+	return l, nil
+}
+
+func (l *Long) Decode(r io.Reader) (err error) {
+	*l, err = ReadLong(r)
+	return err
+}
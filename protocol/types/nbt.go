@@ -1,12 +1,11 @@
 package types
 
 import (
-	"bufio"
 	"bytes"
-	"compress/gzip"
 	"encoding/binary"
 	"fmt"
 	"io"
+	"unicode/utf16"
 )
 
 type NBTTag byte
@@ -27,224 +26,346 @@ const (
 	TagLongArray NBTTag = 12
 )
 
+var nbtTagNames = [...]string{
+	"TAG_End", "TAG_Byte", "TAG_Short", "TAG_Int", "TAG_Long", "TAG_Float", "TAG_Double",
+	"TAG_Byte_Array", "TAG_String", "TAG_List", "TAG_Compound", "TAG_Int_Array", "TAG_Long_Array",
+}
+
+func (t NBTTag) String() string {
+	if int(t) < len(nbtTagNames) {
+		return nbtTagNames[t]
+	}
+	return fmt.Sprintf("NBTTag(%d)", byte(t))
+}
+
+// NBTValue is the payload of a tag. Decoded values have these Go types:
+//
+//	TAG_Byte        int8 (byte and bool are accepted when encoding)
+//	TAG_Short       int16
+//	TAG_Int         int32
+//	TAG_Long        int64
+//	TAG_Float       float32
+//	TAG_Double      float64
+//	TAG_Byte_Array  []byte
+//	TAG_String      string
+//	TAG_List        *NBTList
+//	TAG_Compound    *NBTCompound
+//	TAG_Int_Array   []int32
+//	TAG_Long_Array  []int64
 type NBTValue interface{}
 
-type NBTCompound struct {
+// NBTEntry is a named tag inside a compound
+type NBTEntry struct {
 	Name  string
-	Value map[string]NBTValue
+	Value NBTValue
 }
 
+// NBTCompound is a compound tag that keeps its entries in order. Name is the
+// name of a root compound and is ignored for nested compounds.
+type NBTCompound struct {
+	Name    string
+	Entries []NBTEntry
+}
+
+// Get returns the value of the entry with the given name
+func (c *NBTCompound) Get(name string) (NBTValue, bool) {
+	for _, entry := range c.Entries {
+		if entry.Name == name {
+			return entry.Value, true
+		}
+	}
+	return nil, false
+}
+
+// Set replaces the value of an entry, or appends it if there is none
+func (c *NBTCompound) Set(name string, value NBTValue) {
+	for i := range c.Entries {
+		if c.Entries[i].Name == name {
+			c.Entries[i].Value = value
+			return
+		}
+	}
+	c.Entries = append(c.Entries, NBTEntry{Name: name, Value: value})
+}
+
+// Delete removes the entry with the given name
+func (c *NBTCompound) Delete(name string) {
+	for i := range c.Entries {
+		if c.Entries[i].Name == name {
+			c.Entries = append(c.Entries[:i], c.Entries[i+1:]...)
+			return
+		}
+	}
+}
+
+// Len returns the number of entries
+func (c *NBTCompound) Len() int {
+	return len(c.Entries)
+}
+
+// NBTList is a list tag. All values have the payload type ElemType, which is
+// TagEnd for lists that have always been empty.
+type NBTList struct {
+	ElemType NBTTag
+	Values   []NBTValue
+}
+
+// NBT is a gzip compressed NBT file with a named root compound, like level.dat
 type NBT struct {
 	Root *NBTCompound
 }
 
 func (n NBT) Marshal() ([]byte, error) {
-	var buf bytes.Buffer
-	gzipWriter := gzip.NewWriter(&buf)
-
-	if err := n.writeTag(gzipWriter, TagCompound, n.Root); err != nil {
-		gzipWriter.Close()
-		return nil, err
+	root := n.Root
+	if root == nil {
+		root = &NBTCompound{}
 	}
 
-	if err := gzipWriter.Close(); err != nil {
+	var buf bytes.Buffer
+	if err := NewNBTEncoder(&buf, FileNBT).Encode(root.Name, root); err != nil {
 		return nil, err
 	}
-
 	return buf.Bytes(), nil
 }
 
+// Unmarshal decodes a file bounded by FileNBTLimits
 func (n *NBT) Unmarshal(data []byte) error {
-	gzipReader, err := gzip.NewReader(bytes.NewReader(data))
-	if err != nil {
-		return err
-	}
-	defer gzipReader.Close()
-
-	reader := bufio.NewReader(gzipReader)
+	return n.UnmarshalWithLimits(data, FileNBTLimits)
+}
 
-	tagType, err := reader.ReadByte()
+// UnmarshalWithLimits decodes a file bounded by the given limits
+func (n *NBT) UnmarshalWithLimits(data []byte, limits NBTLimits) error {
+	decoder := NewNBTDecoder(bytes.NewReader(data), FileNBT)
+	decoder.SetLimits(limits)
+	name, value, err := decoder.Decode()
 	if err != nil {
 		return err
 	}
 
-	if NBTTag(tagType) != TagCompound {
-		return fmt.Errorf("expected root compound tag, got %d", tagType)
+	root, ok := value.(*NBTCompound)
+	if !ok {
+		return fmt.Errorf("expected root compound tag, got %T", value)
 	}
+	root.Name = name
+	n.Root = root
+	return nil
+}
 
-	compound, err := n.readCompound(reader)
-	if err != nil {
-		return err
+// nbtTagOf returns the tag type a value is encoded as
+func nbtTagOf(value NBTValue) (NBTTag, error) {
+	switch value.(type) {
+	case int8, byte, bool:
+		return TagByte, nil
+	case int16:
+		return TagShort, nil
+	case int32:
+		return TagInt, nil
+	case int64:
+		return TagLong, nil
+	case float32:
+		return TagFloat, nil
+	case float64:
+		return TagDouble, nil
+	case []byte:
+		return TagByteArray, nil
+	case string:
+		return TagString, nil
+	case *NBTList:
+		return TagList, nil
+	case *NBTCompound:
+		return TagCompound, nil
+	case []int32:
+		return TagIntArray, nil
+	case []int64:
+		return TagLongArray, nil
+	default:
+		return TagEnd, fmt.Errorf("unsupported NBT value type %T", value)
 	}
-
-	n.Root = compound
-	return nil
 }
 
-func (n NBT) writeTag(w io.Writer, tagType NBTTag, value interface{}) error {
+func writeNBTPayload(w io.Writer, tagType NBTTag, value NBTValue) error {
 	switch tagType {
-	case TagEnd:
-		return binary.Write(w, binary.BigEndian, byte(0))
 	case TagByte:
-		return binary.Write(w, binary.BigEndian, value.(byte))
-	case TagShort:
-		return binary.Write(w, binary.BigEndian, value.(int16))
-	case TagInt:
-		return binary.Write(w, binary.BigEndian, value.(int32))
-	case TagLong:
-		return binary.Write(w, binary.BigEndian, value.(int64))
-	case TagFloat:
-		return binary.Write(w, binary.BigEndian, value.(float32))
-	case TagDouble:
-		return binary.Write(w, binary.BigEndian, value.(float64))
+		switch v := value.(type) {
+		case int8:
+			return writeNBTByte(w, byte(v))
+		case byte:
+			return writeNBTByte(w, v)
+		case bool:
+			if v {
+				return writeNBTByte(w, 1)
+			}
+			return writeNBTByte(w, 0)
+		}
+		return nbtMismatch(value, tagType)
+	case TagShort, TagInt, TagLong, TagFloat, TagDouble, TagByteArray, TagIntArray, TagLongArray:
+		if tag, err := nbtTagOf(value); err != nil || tag != tagType {
+			return nbtMismatch(value, tagType)
+		}
+		if tagType == TagByteArray || tagType == TagIntArray || tagType == TagLongArray {
+			length := int64(nbtArrayLen(value))
+			if length > 1<<31-1 {
+				return fmt.Errorf("%s is too long", tagType)
+			}
+			if err := binary.Write(w, binary.BigEndian, int32(length)); err != nil {
+				return err
+			}
+		}
+		return binary.Write(w, binary.BigEndian, value)
 	case TagString:
-		str := value.(string)
-		if err := binary.Write(w, binary.BigEndian, int16(len(str))); err != nil {
+		str, ok := value.(string)
+		if !ok {
+			return nbtMismatch(value, tagType)
+		}
+		return writeNBTString(w, str)
+	case TagList:
+		list, ok := value.(*NBTList)
+		if !ok {
+			return nbtMismatch(value, tagType)
+		}
+		if list == nil {
+			list = &NBTList{}
+		}
+		if len(list.Values) > 0 && list.ElemType == TagEnd {
+			return fmt.Errorf("non-empty list has no element type")
+		}
+		if err := writeNBTByte(w, byte(list.ElemType)); err != nil {
 			return err
 		}
-		_, err := w.Write([]byte(str))
-		return err
-	case TagCompound:
-		compound := value.(*NBTCompound)
-		if err := n.writeString(w, compound.Name); err != nil {
+		if err := binary.Write(w, binary.BigEndian, int32(len(list.Values))); err != nil {
 			return err
 		}
-		for name, val := range compound.Value {
-			if err := n.writeNamedTag(w, name, val); err != nil {
+		for _, v := range list.Values {
+			if err := writeNBTPayload(w, list.ElemType, v); err != nil {
 				return err
 			}
 		}
-		return binary.Write(w, binary.BigEndian, byte(TagEnd))
+		return nil
+	case TagCompound:
+		compound, ok := value.(*NBTCompound)
+		if !ok {
+			return nbtMismatch(value, tagType)
+		}
+		if compound != nil {
+			for _, entry := range compound.Entries {
+				if err := writeNBTNamedTag(w, entry.Name, entry.Value); err != nil {
+					return err
+				}
+			}
+		}
+		return writeNBTByte(w, byte(TagEnd))
 	default:
-		return fmt.Errorf("unsupported tag type: %d", tagType)
+		return fmt.Errorf("unsupported tag type: %s", tagType)
 	}
 }
 
-func (n NBT) writeString(w io.Writer, s string) error {
-	if err := binary.Write(w, binary.BigEndian, int16(len(s))); err != nil {
+func writeNBTNamedTag(w io.Writer, name string, value NBTValue) error {
+	tagType, err := nbtTagOf(value)
+	if err != nil {
+		return fmt.Errorf("%s: %v", name, err)
+	}
+	if err := writeNBTByte(w, byte(tagType)); err != nil {
 		return err
 	}
-	_, err := w.Write([]byte(s))
-	return err
+	if err := writeNBTString(w, name); err != nil {
+		return err
+	}
+	if err := writeNBTPayload(w, tagType, value); err != nil {
+		return fmt.Errorf("%s: %v", name, err)
+	}
+	return nil
 }
 
-func (n NBT) writeNamedTag(w io.Writer, name string, value interface{}) error {
-	var tagType NBTTag
-	switch value.(type) {
-	case byte:
-		tagType = TagByte
-	case int16:
-		tagType = TagShort
-	case int32:
-		tagType = TagInt
-	case int64:
-		tagType = TagLong
-	case float32:
-		tagType = TagFloat
-	case float64:
-		tagType = TagDouble
-	case string:
-		tagType = TagString
-	case *NBTCompound:
-		tagType = TagCompound
-	default:
-		return fmt.Errorf("unsupported value type for tag")
-	}
+func writeNBTByte(w io.Writer, b byte) error {
+	_, err := w.Write([]byte{b})
+	return err
+}
 
-	if err := binary.Write(w, binary.BigEndian, byte(tagType)); err != nil {
-		return err
+// writeNBTString writes a string in the modified UTF-8 encoding of Java
+func writeNBTString(w io.Writer, s string) error {
+	buf := encodeModifiedUTF8(s)
+	if len(buf) > 0xFFFF {
+		return fmt.Errorf("NBT string is too long: %d bytes", len(buf))
 	}
-
-	if err := n.writeString(w, name); err != nil {
+	if err := binary.Write(w, binary.BigEndian, uint16(len(buf))); err != nil {
 		return err
 	}
-
-	return n.writeTag(w, tagType, value)
+	_, err := w.Write(buf)
+	return err
 }
 
-func (n *NBT) readCompound(r io.Reader) (*NBTCompound, error) {
-	name, err := n.readString(r)
-	if err != nil {
-		return nil, err
+// encodeModifiedUTF8 encodes a string like Java's DataOutput.writeUTF: NUL
+// takes two bytes and supplementary characters are written as surrogate pairs
+func encodeModifiedUTF8(s string) []byte {
+	buf := make([]byte, 0, len(s))
+	for _, c := range s {
+		if c >= 0x10000 {
+			high, low := utf16.EncodeRune(c)
+			buf = appendModifiedUTF8(buf, high)
+			buf = appendModifiedUTF8(buf, low)
+			continue
+		}
+		buf = appendModifiedUTF8(buf, c)
 	}
+	return buf
+}
 
-	compound := &NBTCompound{
-		Name:  name,
-		Value: make(map[string]NBTValue),
+func appendModifiedUTF8(buf []byte, c rune) []byte {
+	switch {
+	case c != 0 && c < 0x80:
+		return append(buf, byte(c))
+	case c < 0x800:
+		return append(buf, byte(0xC0|c>>6), byte(0x80|c&0x3F))
+	default:
+		return append(buf, byte(0xE0|c>>12), byte(0x80|(c>>6)&0x3F), byte(0x80|c&0x3F))
 	}
+}
 
-	reader := bufio.NewReader(r)
-	for {
-		tagType, err := reader.ReadByte()
-		if err != nil {
-			return nil, err
-		}
-
-		if NBTTag(tagType) == TagEnd {
+func decodeModifiedUTF8(buf []byte) (string, error) {
+	ascii := true
+	for _, b := range buf {
+		if b == 0 || b >= 0x80 {
+			ascii = false
 			break
 		}
-
-		name, err := n.readString(r)
-		if err != nil {
-			return nil, err
-		}
-
-		value, err := n.readTag(r, NBTTag(tagType))
-		if err != nil {
-			return nil, err
-		}
-
-		compound.Value[name] = value
 	}
-
-	return compound, nil
-}
-
-func (n *NBT) readString(r io.Reader) (string, error) {
-	var length int16
-	if err := binary.Read(r, binary.BigEndian, &length); err != nil {
-		return "", err
+	if ascii {
+		return string(buf), nil
 	}
 
-	bytes := make([]byte, length)
-	if _, err := io.ReadFull(r, bytes); err != nil {
-		return "", err
+	units := make([]uint16, 0, len(buf))
+	for i := 0; i < len(buf); {
+		b := buf[i]
+		switch {
+		case b != 0 && b < 0x80:
+			units = append(units, uint16(b))
+			i++
+		case b&0xE0 == 0xC0 && i+1 < len(buf) && buf[i+1]&0xC0 == 0x80:
+			units = append(units, uint16(b&0x1F)<<6|uint16(buf[i+1]&0x3F))
+			i += 2
+		case b&0xF0 == 0xE0 && i+2 < len(buf) && buf[i+1]&0xC0 == 0x80 && buf[i+2]&0xC0 == 0x80:
+			units = append(units, uint16(b&0x0F)<<12|uint16(buf[i+1]&0x3F)<<6|uint16(buf[i+2]&0x3F))
+			i += 3
+		default:
+			return "", fmt.Errorf("%w: malformed modified UTF-8 string", ErrMalformedNBT)
+		}
 	}
+	return string(utf16.Decode(units)), nil
+}
 
-	return string(bytes), nil
+func nbtMismatch(value NBTValue, tagType NBTTag) error {
+	return fmt.Errorf("cannot encode %T as %s", value, tagType)
 }
 
-func (n *NBT) readTag(r io.Reader, tagType NBTTag) (interface{}, error) {
-	switch tagType {
-	case TagByte:
-		var v byte
-		err := binary.Read(r, binary.BigEndian, &v)
-		return v, err
-	case TagShort:
-		var v int16
-		err := binary.Read(r, binary.BigEndian, &v)
-		return v, err
-	case TagInt:
-		var v int32
-		err := binary.Read(r, binary.BigEndian, &v)
-		return v, err
-	case TagLong:
-		var v int64
-		err := binary.Read(r, binary.BigEndian, &v)
-		return v, err
-	case TagFloat:
-		var v float32
-		err := binary.Read(r, binary.BigEndian, &v)
-		return v, err
-	case TagDouble:
-		var v float64
-		err := binary.Read(r, binary.BigEndian, &v)
-		return v, err
-	case TagString:
-		return n.readString(r)
-	case TagCompound:
-		return n.readCompound(r)
-	default:
-		return nil, fmt.Errorf("unsupported tag type: %d", tagType)
+// nbtArrayLen returns the length of an NBT array value
+func nbtArrayLen(value NBTValue) int {
+	switch v := value.(type) {
+	case []byte:
+		return len(v)
+	case []int32:
+		return len(v)
+	case []int64:
+		return len(v)
 	}
+	return 0
 }
@@ -0,0 +1,181 @@
+package types
+
+import (
+	"bufio"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+)
+
+// NBTCompression is the compression of an NBT stream
+type NBTCompression int
+
+const (
+	NBTUncompressed NBTCompression = iota
+	NBTGzip
+	NBTZlib
+)
+
+func (c NBTCompression) String() string {
+	switch c {
+	case NBTUncompressed:
+		return "uncompressed"
+	case NBTGzip:
+		return "gzip"
+	case NBTZlib:
+		return "zlib"
+	default:
+		return fmt.Sprintf("NBTCompression(%d)", int(c))
+	}
+}
+
+// NBTOptions selects how NBT is encoded
+type NBTOptions struct {
+	Compression NBTCompression
+	Nameless    bool // the root tag has no name, as in network NBT since 1.20.2
+}
+
+var (
+	// FileNBT is the encoding of NBT files like level.dat
+	FileNBT = NBTOptions{Compression: NBTGzip}
+	// NetworkNBT is the encoding of NBT in packets since 1.20.2
+	NetworkNBT = NBTOptions{Nameless: true}
+	// LegacyNetworkNBT is the encoding of NBT in packets before 1.20.2
+	LegacyNetworkNBT = NBTOptions{}
+)
+
+// NBTEncoder writes root tags to a stream
+type NBTEncoder struct {
+	w    io.Writer
+	opts NBTOptions
+}
+
+func NewNBTEncoder(w io.Writer, opts NBTOptions) *NBTEncoder {
+	return &NBTEncoder{w: w, opts: opts}
+}
+
+// Encode writes a root tag of any type. The name is ignored for nameless
+// roots. A nil value is written as TAG_End, which packets use for "no NBT".
+// Compressed roots are written as separate compressed streams.
+func (e *NBTEncoder) Encode(name string, value NBTValue) error {
+	w := e.w
+	var closer io.Closer
+	switch e.opts.Compression {
+	case NBTUncompressed:
+	case NBTGzip:
+		gzipWriter := gzip.NewWriter(w)
+		w, closer = gzipWriter, gzipWriter
+	case NBTZlib:
+		zlibWriter := zlib.NewWriter(w)
+		w, closer = zlibWriter, zlibWriter
+	default:
+		return fmt.Errorf("unknown NBT compression %s", e.opts.Compression)
+	}
+
+	if err := e.encodeRoot(w, name, value); err != nil {
+		return err
+	}
+	if closer != nil {
+		return closer.Close()
+	}
+	return nil
+}
+
+func (e *NBTEncoder) encodeRoot(w io.Writer, name string, value NBTValue) error {
+	if value == nil {
+		return writeNBTByte(w, byte(TagEnd))
+	}
+
+	tagType, err := nbtTagOf(value)
+	if err != nil {
+		return err
+	}
+	if err := writeNBTByte(w, byte(tagType)); err != nil {
+		return err
+	}
+	if !e.opts.Nameless {
+		if err := writeNBTString(w, name); err != nil {
+			return err
+		}
+	}
+	return writeNBTPayload(w, tagType, value)
+}
+
+// NBTDecoder reads root tags from a stream. Uncompressed tags are read
+// without buffering, so the stream can continue with other data like the
+// rest of a packet. Compressed streams are buffered unless r is an
+// io.ByteReader. Decoding is bounded by DefaultNBTLimits unless changed.
+type NBTDecoder struct {
+	r      io.Reader
+	opts   NBTOptions
+	limits NBTLimits
+}
+
+func NewNBTDecoder(r io.Reader, opts NBTOptions) *NBTDecoder {
+	if _, ok := r.(io.ByteReader); !ok && opts.Compression != NBTUncompressed {
+		// Let the decompressors stop exactly at the end of each root
+		r = bufio.NewReader(r)
+	}
+	return &NBTDecoder{r: r, opts: opts, limits: DefaultNBTLimits}
+}
+
+// SetLimits sets the limits applied to each root tag
+func (d *NBTDecoder) SetLimits(limits NBTLimits) {
+	d.limits = limits
+}
+
+// Decode reads a root tag of any type. A TAG_End root decodes to a nil value.
+func (d *NBTDecoder) Decode() (name string, value NBTValue, err error) {
+	r := d.r
+	switch d.opts.Compression {
+	case NBTUncompressed:
+	case NBTGzip:
+		gzipReader, err := gzip.NewReader(r)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to read gzip header: %v", err)
+		}
+		defer gzipReader.Close()
+		gzipReader.Multistream(false)
+		r = gzipReader
+	case NBTZlib:
+		zlibReader, err := zlib.NewReader(r)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to read zlib header: %v", err)
+		}
+		defer zlibReader.Close()
+		r = zlibReader
+	default:
+		return "", nil, fmt.Errorf("unknown NBT compression %s", d.opts.Compression)
+	}
+
+	reader := newNBTReader(r, d.limits)
+	tagType, err := reader.readTagType()
+	if err != nil {
+		return "", nil, err
+	}
+	if tagType == TagEnd {
+		return "", nil, nil
+	}
+
+	if !d.opts.Nameless {
+		if name, err = reader.readString(); err != nil {
+			return "", nil, err
+		}
+	}
+	if value, err = reader.readPayload(tagType); err != nil {
+		return "", nil, err
+	}
+	return name, value, nil
+}
+
+// WriteNetworkNBT writes a nameless root tag as sent in packets since 1.20.2
+func WriteNetworkNBT(value NBTValue, w io.Writer) error {
+	return NewNBTEncoder(w, NetworkNBT).Encode("", value)
+}
+
+// ReadNetworkNBT reads a nameless root tag as sent in packets since 1.20.2
+func ReadNetworkNBT(r io.Reader) (NBTValue, error) {
+	_, value, err := NewNBTDecoder(r, NetworkNBT).Decode()
+	return value, err
+}
@@ -0,0 +1,101 @@
+package types
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestNBTEncodingRoundTrip(t *testing.T) {
+	options := []struct {
+		name string
+		opts NBTOptions
+	}{
+		{"file", FileNBT},
+		{"network", NetworkNBT},
+		{"legacy network", LegacyNetworkNBT},
+		{"zlib", NBTOptions{Compression: NBTZlib}},
+		{"nameless gzip", NBTOptions{Compression: NBTGzip, Nameless: true}},
+	}
+	// The decoder returns the name of the root separately
+	compound := allNBTTypes()
+	compound.Name = ""
+	values := []NBTValue{compound, "text", int32(7), &NBTList{ElemType: TagByte, Values: []NBTValue{int8(1)}}, nil}
+
+	for _, tt := range options {
+		t.Run(tt.name, func(t *testing.T) {
+			// Several roots in one stream, followed by other data
+			var buf bytes.Buffer
+			encoder := NewNBTEncoder(&buf, tt.opts)
+			for _, value := range values {
+				if err := encoder.Encode("name", value); err != nil {
+					t.Fatalf("Encode(%v): %v", value, err)
+				}
+			}
+			buf.WriteByte(0x42)
+
+			decoder := NewNBTDecoder(&buf, tt.opts)
+			for _, want := range values {
+				wantName := "name"
+				if tt.opts.Nameless || want == nil {
+					wantName = ""
+				}
+				name, got, err := decoder.Decode()
+				if err != nil {
+					t.Fatalf("Decode: %v", err)
+				}
+				if name != wantName || !reflect.DeepEqual(got, want) {
+					t.Errorf("Decode = %q, %v, want %q, %v", name, got, wantName, want)
+				}
+			}
+			if rest, _ := buf.ReadByte(); rest != 0x42 {
+				t.Errorf("decoder read past the last root")
+			}
+		})
+	}
+}
+
+func TestNetworkNBTFormat(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteNetworkNBT("hi", &buf); err != nil {
+		t.Fatal(err)
+	}
+	if want := []byte{0x08, 0x00, 0x02, 'h', 'i'}; !bytes.Equal(buf.Bytes(), want) {
+		t.Errorf("WriteNetworkNBT = % x, want % x", buf.Bytes(), want)
+	}
+
+	buf.Reset()
+	if err := NewNBTEncoder(&buf, LegacyNetworkNBT).Encode("n", "hi"); err != nil {
+		t.Fatal(err)
+	}
+	if want := []byte{0x08, 0x00, 0x01, 'n', 0x00, 0x02, 'h', 'i'}; !bytes.Equal(buf.Bytes(), want) {
+		t.Errorf("legacy network NBT = % x, want % x", buf.Bytes(), want)
+	}
+}
+
+func TestNBTDecodeErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		opts NBTOptions
+		want error
+	}{
+		{"unknown tag", []byte{0x0d}, NetworkNBT, ErrMalformedNBT},
+		{"negative array length", []byte{0x07, 0xff, 0xff, 0xff, 0xff}, NetworkNBT, ErrMalformedNBT},
+		{"truncated", []byte{0x03, 0x00}, NetworkNBT, nil},
+		{"not gzip", []byte{0x0a, 0x00, 0x00, 0x00}, FileNBT, nil},
+		{"unknown compression", []byte{0x00}, NBTOptions{Compression: 7}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, value, err := NewNBTDecoder(bytes.NewReader(tt.data), tt.opts).Decode()
+			if err == nil {
+				t.Fatalf("Decode = %v, want error", value)
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("Decode error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
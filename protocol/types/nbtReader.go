@@ -0,0 +1,410 @@
+package types
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// NBTLimits bounds the resources spent decoding one root tag, so untrusted
+// NBT cannot exhaust memory or the stack. Zero fields are unlimited.
+type NBTLimits struct {
+	MaxDepth    int   // nesting depth of lists and compounds
+	MaxBytes    int64 // bytes read, after decompression
+	MaxElements int   // number of tags, not counting array elements
+}
+
+// DefaultNBTLimits matches the limits vanilla applies to NBT in packets
+var DefaultNBTLimits = NBTLimits{
+	MaxDepth:    512,
+	MaxBytes:    2 << 20,
+	MaxElements: 1 << 18,
+}
+
+// FileNBTLimits only bounds the depth, like vanilla does for trusted files
+// such as level.dat and structures, which may be large
+var FileNBTLimits = NBTLimits{
+	MaxDepth: 512,
+}
+
+var (
+	ErrMalformedNBT       = errors.New("malformed NBT")
+	ErrNBTTooDeep         = errors.New("NBT is nested too deeply")
+	ErrNBTTooLarge        = errors.New("NBT exceeds the size limit")
+	ErrNBTTooManyElements = errors.New("NBT has too many elements")
+)
+
+// NBTDecodeError reports where decoding NBT failed. Err wraps one of the
+// errors above or the error of the underlying reader.
+type NBTDecodeError struct {
+	Offset int64 // bytes read before the error, after decompression
+	Err    error
+}
+
+func (e *NBTDecodeError) Error() string {
+	return fmt.Sprintf("failed to decode NBT at offset %d: %v", e.Offset, e.Err)
+}
+
+func (e *NBTDecodeError) Unwrap() error {
+	return e.Err
+}
+
+// nbtReader decodes a single root tag while enforcing limits
+type nbtReader struct {
+	r        io.Reader
+	limits   NBTLimits
+	offset   int64
+	elements int
+	depth    int
+	scratch  [8]byte
+}
+
+func newNBTReader(r io.Reader, limits NBTLimits) *nbtReader {
+	return &nbtReader{r: r, limits: limits}
+}
+
+// wrap annotates an error with the current offset
+func (d *nbtReader) wrap(err error) error {
+	var decodeErr *NBTDecodeError
+	if err == nil || errors.As(err, &decodeErr) {
+		return err
+	}
+	return &NBTDecodeError{Offset: d.offset, Err: err}
+}
+
+func (d *nbtReader) read(buf []byte) error {
+	if d.limits.MaxBytes > 0 && d.offset+int64(len(buf)) > d.limits.MaxBytes {
+		return d.wrap(ErrNBTTooLarge)
+	}
+	n, err := io.ReadFull(d.r, buf)
+	if err == io.EOF && d.offset > 0 {
+		err = io.ErrUnexpectedEOF
+	}
+	d.offset += int64(n)
+	return d.wrap(err)
+}
+
+func (d *nbtReader) readUint16() (uint16, error) {
+	if err := d.read(d.scratch[:2]); err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint16(d.scratch[:2]), nil
+}
+
+func (d *nbtReader) readUint32() (uint32, error) {
+	if err := d.read(d.scratch[:4]); err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint32(d.scratch[:4]), nil
+}
+
+func (d *nbtReader) readUint64() (uint64, error) {
+	if err := d.read(d.scratch[:8]); err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(d.scratch[:8]), nil
+}
+
+func (d *nbtReader) readTagType() (NBTTag, error) {
+	if err := d.read(d.scratch[:1]); err != nil {
+		return 0, err
+	}
+	tagType := NBTTag(d.scratch[0])
+	if tagType > TagLongArray {
+		return 0, d.wrap(fmt.Errorf("%w: unknown tag type %d", ErrMalformedNBT, tagType))
+	}
+	return tagType, nil
+}
+
+func (d *nbtReader) readString() (string, error) {
+	length, err := d.readUint16()
+	if err != nil {
+		return "", err
+	}
+	buf := make([]byte, length)
+	if err := d.read(buf); err != nil {
+		return "", err
+	}
+	s, err := decodeModifiedUTF8(buf)
+	return s, d.wrap(err)
+}
+
+// readLength reads the length of a list or array
+func (d *nbtReader) readLength() (int, error) {
+	length, err := d.readUint32()
+	if err != nil {
+		return 0, err
+	}
+	if int32(length) < 0 {
+		return 0, d.wrap(fmt.Errorf("%w: negative length %d", ErrMalformedNBT, int32(length)))
+	}
+	return int(length), nil
+}
+
+// addElements accounts for tags about to be decoded
+func (d *nbtReader) addElements(n int) error {
+	d.elements += n
+	if d.limits.MaxElements > 0 && d.elements > d.limits.MaxElements {
+		return d.wrap(ErrNBTTooManyElements)
+	}
+	return nil
+}
+
+func (d *nbtReader) enter() error {
+	d.depth++
+	if d.limits.MaxDepth > 0 && d.depth > d.limits.MaxDepth {
+		return d.wrap(ErrNBTTooDeep)
+	}
+	return nil
+}
+
+func (d *nbtReader) leave() {
+	d.depth--
+}
+
+func (d *nbtReader) readPayload(tagType NBTTag) (NBTValue, error) {
+	if err := d.addElements(1); err != nil {
+		return nil, err
+	}
+
+	switch tagType {
+	case TagByte:
+		if err := d.read(d.scratch[:1]); err != nil {
+			return nil, err
+		}
+		return int8(d.scratch[0]), nil
+	case TagShort:
+		v, err := d.readUint16()
+		return int16(v), err
+	case TagInt:
+		v, err := d.readUint32()
+		return int32(v), err
+	case TagLong:
+		v, err := d.readUint64()
+		return int64(v), err
+	case TagFloat:
+		v, err := d.readUint32()
+		return math.Float32frombits(v), err
+	case TagDouble:
+		v, err := d.readUint64()
+		return math.Float64frombits(v), err
+	case TagByteArray:
+		return d.readArrayBytes(1)
+	case TagString:
+		return d.readString()
+	case TagList:
+		return d.readList()
+	case TagCompound:
+		return d.readCompound()
+	case TagIntArray:
+		raw, err := d.readArrayBytes(4)
+		if err != nil {
+			return nil, err
+		}
+		values := make([]int32, len(raw)/4)
+		for i := range values {
+			values[i] = int32(binary.BigEndian.Uint32(raw[i*4:]))
+		}
+		return values, nil
+	case TagLongArray:
+		raw, err := d.readArrayBytes(8)
+		if err != nil {
+			return nil, err
+		}
+		values := make([]int64, len(raw)/8)
+		for i := range values {
+			values[i] = int64(binary.BigEndian.Uint64(raw[i*8:]))
+		}
+		return values, nil
+	default:
+		return nil, d.wrap(fmt.Errorf("%w: unexpected %s", ErrMalformedNBT, tagType))
+	}
+}
+
+// readArrayBytes reads the encoded elements of an array. Without a byte
+// limit they are read in chunks, so a bogus length fails at the end of the
+// input instead of allocating.
+func (d *nbtReader) readArrayBytes(elementSize int) ([]byte, error) {
+	const chunkSize = 64 << 10
+
+	length, err := d.readLength()
+	if err != nil {
+		return nil, err
+	}
+	size := int64(length) * int64(elementSize)
+	if d.limits.MaxBytes > 0 && d.offset+size > d.limits.MaxBytes {
+		return nil, d.wrap(ErrNBTTooLarge)
+	}
+
+	buf := make([]byte, 0, min(size, chunkSize))
+	for int64(len(buf)) < size {
+		n := min(size-int64(len(buf)), chunkSize)
+		buf = append(buf, make([]byte, n)...)
+		if err := d.read(buf[int64(len(buf))-n:]); err != nil {
+			return nil, err
+		}
+	}
+	return buf, nil
+}
+
+func (d *nbtReader) readListHeader() (NBTTag, int, error) {
+	elemType, err := d.readTagType()
+	if err != nil {
+		return 0, 0, err
+	}
+	length, err := d.readLength()
+	if err != nil {
+		return 0, 0, err
+	}
+	if length > 0 && elemType == TagEnd {
+		return 0, 0, d.wrap(fmt.Errorf("%w: non-empty list has no element type", ErrMalformedNBT))
+	}
+	if d.limits.MaxElements > 0 && length > d.limits.MaxElements-d.elements {
+		return 0, 0, d.wrap(ErrNBTTooManyElements)
+	}
+	return elemType, length, nil
+}
+
+func (d *nbtReader) readList() (*NBTList, error) {
+	if err := d.enter(); err != nil {
+		return nil, err
+	}
+	defer d.leave()
+
+	elemType, length, err := d.readListHeader()
+	if err != nil {
+		return nil, err
+	}
+
+	list := &NBTList{ElemType: elemType}
+	for i := 0; i < length; i++ {
+		value, err := d.readPayload(elemType)
+		if err != nil {
+			return nil, err
+		}
+		list.Values = append(list.Values, value)
+	}
+	return list, nil
+}
+
+func (d *nbtReader) readCompound() (*NBTCompound, error) {
+	if err := d.enter(); err != nil {
+		return nil, err
+	}
+	defer d.leave()
+
+	compound := &NBTCompound{}
+	for {
+		tagType, err := d.readTagType()
+		if err != nil {
+			return nil, err
+		}
+		if tagType == TagEnd {
+			return compound, nil
+		}
+
+		name, err := d.readString()
+		if err != nil {
+			return nil, err
+		}
+		value, err := d.readPayload(tagType)
+		if err != nil {
+			return nil, err
+		}
+		compound.Entries = append(compound.Entries, NBTEntry{Name: name, Value: value})
+	}
+}
+
+// skipPayload reads a payload like readPayload without keeping it
+func (d *nbtReader) skipPayload(tagType NBTTag) error {
+	if err := d.addElements(1); err != nil {
+		return err
+	}
+
+	switch tagType {
+	case TagByte:
+		return d.skip(1)
+	case TagShort:
+		return d.skip(2)
+	case TagInt, TagFloat:
+		return d.skip(4)
+	case TagLong, TagDouble:
+		return d.skip(8)
+	case TagByteArray, TagIntArray, TagLongArray:
+		length, err := d.readLength()
+		if err != nil {
+			return err
+		}
+		elementSize := int64(1)
+		switch tagType {
+		case TagIntArray:
+			elementSize = 4
+		case TagLongArray:
+			elementSize = 8
+		}
+		return d.skip(int64(length) * elementSize)
+	case TagString:
+		length, err := d.readUint16()
+		if err != nil {
+			return err
+		}
+		return d.skip(int64(length))
+	case TagList:
+		if err := d.enter(); err != nil {
+			return err
+		}
+		defer d.leave()
+
+		elemType, length, err := d.readListHeader()
+		if err != nil {
+			return err
+		}
+		for i := 0; i < length; i++ {
+			if err := d.skipPayload(elemType); err != nil {
+				return err
+			}
+		}
+		return nil
+	case TagCompound:
+		if err := d.enter(); err != nil {
+			return err
+		}
+		defer d.leave()
+
+		for {
+			elemType, err := d.readTagType()
+			if err != nil {
+				return err
+			}
+			if elemType == TagEnd {
+				return nil
+			}
+			length, err := d.readUint16()
+			if err != nil {
+				return err
+			}
+			if err := d.skip(int64(length)); err != nil {
+				return err
+			}
+			if err := d.skipPayload(elemType); err != nil {
+				return err
+			}
+		}
+	default:
+		return d.wrap(fmt.Errorf("%w: unexpected %s", ErrMalformedNBT, tagType))
+	}
+}
+
+func (d *nbtReader) skip(n int64) error {
+	if d.limits.MaxBytes > 0 && d.offset+n > d.limits.MaxBytes {
+		return d.wrap(ErrNBTTooLarge)
+	}
+	copied, err := io.CopyN(io.Discard, d.r, n)
+	d.offset += copied
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return d.wrap(err)
+}
@@ -0,0 +1,158 @@
+package types
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNBTFileLimits(t *testing.T) {
+	values := make([]NBTValue, DefaultNBTLimits.MaxElements+1)
+	for i := range values {
+		values[i] = int32(i)
+	}
+	file := NBT{Root: &NBTCompound{Name: "Data", Entries: []NBTEntry{
+		{Name: "values", Value: &NBTList{ElemType: TagInt, Values: values}},
+	}}}
+	data, err := file.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded NBT
+	if err := decoded.Unmarshal(data); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if decoded.Root.Name != "Data" {
+		t.Errorf("root name = %q, want Data", decoded.Root.Name)
+	}
+
+	err = decoded.UnmarshalWithLimits(data, DefaultNBTLimits)
+	if !errors.Is(err, ErrNBTTooManyElements) {
+		t.Errorf("UnmarshalWithLimits(DefaultNBTLimits) = %v, want ErrNBTTooManyElements", err)
+	}
+}
+
+// allNBTTypes has an entry of every tag type, not in alphabetical order
+func allNBTTypes() *NBTCompound {
+	return &NBTCompound{Name: "root", Entries: []NBTEntry{
+		{Name: "long", Value: int64(-1 << 40)},
+		{Name: "byte", Value: int8(-1)},
+		{Name: "short", Value: int16(300)},
+		{Name: "int", Value: int32(-70000)},
+		{Name: "float", Value: float32(1.5)},
+		{Name: "double", Value: float64(-2.25)},
+		{Name: "bytes", Value: []byte{1, 2, 255}},
+		{Name: "string", Value: "héllo \x00 🎉"},
+		{Name: "list", Value: &NBTList{ElemType: TagString, Values: []NBTValue{"a", "b"}}},
+		{Name: "empty list", Value: &NBTList{}},
+		{Name: "lists", Value: &NBTList{ElemType: TagList, Values: []NBTValue{
+			&NBTList{ElemType: TagInt, Values: []NBTValue{int32(1)}},
+			&NBTList{},
+		}}},
+		{Name: "compound", Value: &NBTCompound{Entries: []NBTEntry{{Name: "z", Value: int8(1)}, {Name: "a", Value: int8(2)}}}},
+		{Name: "ints", Value: []int32{1, -1}},
+		{Name: "longs", Value: []int64{1 << 40}},
+	}}
+}
+
+func TestNBTRoundTrip(t *testing.T) {
+	want := allNBTTypes()
+	data, err := NBT{Root: want}.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got NBT
+	if err := got.Unmarshal(data); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got.Root, want) {
+		t.Errorf("round trip = %v, want %v", got.Root, want)
+	}
+}
+
+func TestNBTEncodeAliases(t *testing.T) {
+	// byte and bool encode as TAG_Byte and decode as int8
+	tests := []struct {
+		value NBTValue
+		want  int8
+	}{
+		{byte(200), -56},
+		{true, 1},
+		{false, 0},
+	}
+	for _, tt := range tests {
+		raw, err := NewRawNBT(tt.value)
+		if err != nil {
+			t.Fatalf("NewRawNBT(%v): %v", tt.value, err)
+		}
+		got, err := raw.Value()
+		if err != nil || got != tt.want {
+			t.Errorf("%T %v decoded as %#v, %v, want %d", tt.value, tt.value, got, err, tt.want)
+		}
+	}
+}
+
+func TestNBTEncodeErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		value NBTValue
+	}{
+		{"unsupported type", int(1)},
+		{"list element mismatch", &NBTList{ElemType: TagInt, Values: []NBTValue{int32(1), "x"}}},
+		{"list without element type", &NBTList{Values: []NBTValue{int32(1)}}},
+		{"unsupported entry", &NBTCompound{Entries: []NBTEntry{{Name: "x", Value: uint32(1)}}}},
+		{"string too long", strings.Repeat("a", 0x10000)},
+	}
+	for _, tt := range tests {
+		if _, err := NewRawNBT(tt.value); err == nil {
+			t.Errorf("%s: encoding succeeded", tt.name)
+		}
+	}
+}
+
+func TestNBTCompound(t *testing.T) {
+	var c NBTCompound
+	c.Set("b", int32(1))
+	c.Set("a", int32(2))
+	c.Set("b", int32(3))
+	if got := c.Entries; !reflect.DeepEqual(got, []NBTEntry{{"b", int32(3)}, {"a", int32(2)}}) {
+		t.Errorf("entries = %v", got)
+	}
+	if v, ok := c.Get("a"); !ok || v != int32(2) {
+		t.Errorf("Get(a) = %v, %v", v, ok)
+	}
+	c.Delete("b")
+	if _, ok := c.Get("b"); ok || c.Len() != 1 {
+		t.Errorf("b still present after Delete, %d entries", c.Len())
+	}
+}
+
+func TestModifiedUTF8(t *testing.T) {
+	tests := []struct {
+		s    string
+		want []byte
+	}{
+		{"abc", []byte("abc")},
+		{"\x00", []byte{0xC0, 0x80}},
+		{"é", []byte{0xC3, 0xA9}},
+		{"🎉", []byte{0xED, 0xA0, 0xBC, 0xED, 0xBE, 0x89}},
+	}
+	for _, tt := range tests {
+		got := encodeModifiedUTF8(tt.s)
+		if !bytes.Equal(got, tt.want) {
+			t.Errorf("encodeModifiedUTF8(%q) = % x, want % x", tt.s, got, tt.want)
+		}
+		decoded, err := decodeModifiedUTF8(got)
+		if err != nil || decoded != tt.s {
+			t.Errorf("decodeModifiedUTF8(% x) = %q, %v, want %q", got, decoded, err, tt.s)
+		}
+	}
+
+	if _, err := decodeModifiedUTF8([]byte{0xC3}); !errors.Is(err, ErrMalformedNBT) {
+		t.Errorf("truncated sequence: %v, want ErrMalformedNBT", err)
+	}
+}
@@ -0,0 +1,56 @@
+package types
+
+import (
+	"fmt"
+	"io"
+)
+
+// Optional is a value prefixed with a boolean telling whether it is present.
+// PT is always *T, as in Optional[String, *String].
+type Optional[T marshaler, PT element[T]] struct {
+	Value   T
+	Present bool
+}
+
+// Some returns a present optional value
+func Some[T marshaler, PT element[T]](value T) Optional[T, PT] {
+	return Optional[T, PT]{Value: value, Present: true}
+}
+
+// Get returns the value and whether it is present
+func (o Optional[T, PT]) Get() (T, bool) {
+	return o.Value, o.Present
+}
+
+func (o Optional[T, PT]) Marshal() ([]byte, error) {
+	if !o.Present {
+		return []byte{0}, nil
+	}
+	data, err := o.Value.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{1}, data...), nil
+}
+
+func (o *Optional[T, PT]) Unmarshal(data []byte) error {
+	return unmarshalWith(o, data)
+}
+
+func (o *Optional[T, PT]) Decode(r io.Reader) error {
+	present, err := ReadBoolean(r)
+	if err != nil {
+		return fmt.Errorf("failed to read optional presence: %v", err)
+	}
+	if !present {
+		*o = Optional[T, PT]{}
+		return nil
+	}
+
+	var value T
+	if err := PT(&value).Decode(r); err != nil {
+		return err
+	}
+	*o = Some[T, PT](value)
+	return nil
+}
@@ -0,0 +1,62 @@
+package types
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestOptional(t *testing.T) {
+	tests := []struct {
+		name  string
+		value Optional[VarInt, *VarInt]
+		want  []byte
+	}{
+		{"absent", Optional[VarInt, *VarInt]{}, []byte{0}},
+		{"present", Some[VarInt, *VarInt](300), []byte{1, 0xac, 0x02}},
+		{"present zero", Some[VarInt, *VarInt](0), []byte{1, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := tt.value.Marshal()
+			if err != nil || !bytes.Equal(data, tt.want) {
+				t.Errorf("Marshal = % x, %v, want % x", data, err, tt.want)
+			}
+
+			got := Some[VarInt, *VarInt](7)
+			if err := got.Unmarshal(tt.want); err != nil || got != tt.value {
+				t.Errorf("Unmarshal = %+v, %v, want %+v", got, err, tt.value)
+			}
+			if value, present := got.Get(); value != tt.value.Value || present != tt.value.Present {
+				t.Errorf("Get = %v, %v", value, present)
+			}
+		})
+	}
+}
+
+func TestOptionalErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"missing value", []byte{1}},
+		{"invalid value", []byte{1, 0x80}},
+	}
+	for _, tt := range tests {
+		var o Optional[VarInt, *VarInt]
+		if err := o.Unmarshal(tt.data); err == nil {
+			t.Errorf("%s: Unmarshal = %+v, want error", tt.name, o)
+		}
+	}
+
+	// The size of a fixed bit set is not encoded, so an optional one cannot
+	// be decoded
+	var bits Optional[FixedBitSet, *FixedBitSet]
+	if err := bits.Unmarshal([]byte{1, 0xff}); err == nil {
+		t.Errorf("Unmarshal of an optional fixed bit set = %+v, want error", bits)
+	}
+
+	if _, err := Some[String, *String](String{Value: "\xff"}).Marshal(); err == nil {
+		t.Error("Marshal of an invalid string succeeded")
+	}
+}
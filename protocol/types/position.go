@@ -2,6 +2,7 @@ package types
 
 import (
 	"fmt"
+	"io"
 )
 
 type Position struct {
@@ -53,3 +54,30 @@ func (p *Position) Unmarshal(data []byte) error {
 
 	return nil
 }
+
+func WritePosition(p Position, w io.Writer) error {
+	buf, err := p.Marshal()
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(buf)
+	return err
+}
+
+func ReadPosition(r io.Reader) (Position, error) {
+	buf := make([]byte, 8)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return Position{}, fmt.Errorf("failed to read position: %v", err)
+	}
+
+	var p Position
+	if err := p.Unmarshal(buf); err != nil {
+		return Position{}, err
+	}
+	return p, nil
+}
+
+func (p *Position) Decode(r io.Reader) (err error) {
+	*p, err = ReadPosition(r)
+	return err
+}
@@ -0,0 +1,60 @@
+package types
+
+import (
+	"fmt"
+	"io"
+)
+
+// PrefixedArray is a sequence of values prefixed with its length as a VarInt.
+// PT is always *T, as in PrefixedArray[VarInt, *VarInt].
+type PrefixedArray[T marshaler, PT element[T]] []T
+
+func (a PrefixedArray[T, PT]) Marshal() ([]byte, error) {
+	buf, err := VarInt(len(a)).Marshal()
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal array length: %v", err)
+	}
+	for _, element := range a {
+		data, err := element.Marshal()
+		if err != nil {
+			return nil, err
+		}
+		buf = append(buf, data...)
+	}
+	return buf, nil
+}
+
+func (a *PrefixedArray[T, PT]) Unmarshal(data []byte) error {
+	return unmarshalWith(a, data)
+}
+
+func (a *PrefixedArray[T, PT]) Decode(r io.Reader) error {
+	count, err := readArrayLength(r)
+	if err != nil {
+		return err
+	}
+
+	// Grow the slice while reading so a bogus count cannot allocate much
+	var elements PrefixedArray[T, PT]
+	for i := 0; i < count; i++ {
+		var element T
+		if err := PT(&element).Decode(r); err != nil {
+			return err
+		}
+		elements = append(elements, element)
+	}
+	*a = elements
+	return nil
+}
+
+// readArrayLength reads the VarInt length of an array
+func readArrayLength(r io.Reader) (int, error) {
+	length, err := ReadVarInt(r)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read array length: %v", err)
+	}
+	if length < 0 {
+		return 0, fmt.Errorf("array length is negative")
+	}
+	return int(length), nil
+}
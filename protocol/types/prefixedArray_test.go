@@ -0,0 +1,61 @@
+package types
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestPrefixedArray(t *testing.T) {
+	tests := []struct {
+		name  string
+		value PrefixedArray[String, *String]
+		want  []byte
+	}{
+		{"empty", PrefixedArray[String, *String]{}, []byte{0}},
+		{"strings", PrefixedArray[String, *String]{{Value: "a"}, {Value: "bc"}}, []byte{2, 1, 'a', 2, 'b', 'c'}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := tt.value.Marshal()
+			if err != nil || !bytes.Equal(data, tt.want) {
+				t.Errorf("Marshal = % x, %v, want % x", data, err, tt.want)
+			}
+
+			var got PrefixedArray[String, *String]
+			if err := got.Unmarshal(tt.want); err != nil || len(got) != len(tt.value) || len(got) > 0 && !reflect.DeepEqual(got, tt.value) {
+				t.Errorf("Unmarshal = %v, %v, want %v", got, err, tt.value)
+			}
+		})
+	}
+
+	// Arrays nest with other composite types
+	nested := PrefixedArray[Optional[VarInt, *VarInt], *Optional[VarInt, *VarInt]]{Some[VarInt, *VarInt](1), {}}
+	data, err := nested.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got PrefixedArray[Optional[VarInt, *VarInt], *Optional[VarInt, *VarInt]]
+	if err := got.Unmarshal(data); err != nil || !reflect.DeepEqual(got, nested) {
+		t.Errorf("nested round trip = %v, %v, want %v", got, err, nested)
+	}
+}
+
+func TestPrefixedArrayErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"negative length", []byte{0xff, 0xff, 0xff, 0xff, 0x0f}},
+		{"missing elements", []byte{0x02, 0x01, 'a'}},
+		// A huge count must fail on the missing data, not allocate it
+		{"huge length", []byte{0xff, 0xff, 0xff, 0xff, 0x07}},
+	}
+	for _, tt := range tests {
+		var a PrefixedArray[String, *String]
+		if err := a.Unmarshal(tt.data); err == nil {
+			t.Errorf("%s: Unmarshal = %v, want error", tt.name, a)
+		}
+	}
+}
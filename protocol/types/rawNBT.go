@@ -0,0 +1,75 @@
+package types
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// RawNBT holds a network NBT value in its encoded form: the tag type of the
+// nameless root followed by its payload
+type RawNBT []byte
+
+// NewRawNBT encodes a value as network NBT
+func NewRawNBT(value NBTValue) (RawNBT, error) {
+	var buf bytes.Buffer
+	if err := WriteNetworkNBT(value, &buf); err != nil {
+		return nil, err
+	}
+	return RawNBT(buf.Bytes()), nil
+}
+
+// Value decodes the NBT value
+func (n RawNBT) Value() (NBTValue, error) {
+	return ReadNetworkNBT(bytes.NewReader(n))
+}
+
+func (n RawNBT) Marshal() ([]byte, error) {
+	if len(n) == 0 {
+		return nil, fmt.Errorf("raw NBT is empty")
+	}
+	return append([]byte(nil), n...), nil
+}
+
+func (n *RawNBT) Unmarshal(data []byte) error {
+	raw, err := ReadRawNBT(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	*n = raw
+	return nil
+}
+
+func WriteRawNBT(n RawNBT, w io.Writer) error {
+	buf, err := n.Marshal()
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(buf)
+	return err
+}
+
+// ReadRawNBT reads a single network NBT value without decoding it
+func ReadRawNBT(r io.Reader) (RawNBT, error) {
+	var buf bytes.Buffer
+	tee := io.TeeReader(r, &buf)
+
+	reader := newNBTReader(tee, DefaultNBTLimits)
+	tagType, err := reader.readTagType()
+	if err != nil {
+		return nil, err
+	}
+	if tagType == TagEnd {
+		// A lone TAG_End stands for "no NBT"
+		return RawNBT{byte(TagEnd)}, nil
+	}
+	if err := reader.skipPayload(tagType); err != nil {
+		return nil, err
+	}
+	return RawNBT(buf.Bytes()), nil
+}
+
+func (n *RawNBT) Decode(r io.Reader) (err error) {
+	*n, err = ReadRawNBT(r)
+	return err
+}
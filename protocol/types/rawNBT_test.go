@@ -0,0 +1,60 @@
+package types
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestReadRawNBT(t *testing.T) {
+	compound, err := NewRawNBT(&NBTCompound{Entries: []NBTEntry{{Name: "a", Value: int32(1)}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		data    []byte
+		want    RawNBT
+		wantErr bool
+	}{
+		{"no NBT", []byte{0x00, 0x42}, RawNBT{0x00}, false},
+		{"compound", append(append([]byte(nil), compound...), 0x42), compound, false},
+		{"string", []byte{0x08, 0x00, 0x02, 'h', 'i', 0x42}, RawNBT{0x08, 0x00, 0x02, 'h', 'i'}, false},
+		{"unknown tag", []byte{0x0d}, nil, true},
+		{"truncated", []byte{0x08, 0x00, 0x05, 'h'}, nil, true},
+		{"empty", nil, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := bytes.NewReader(tt.data)
+			got, err := ReadRawNBT(r)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ReadRawNBT = %v, want error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ReadRawNBT: %v", err)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("ReadRawNBT = %v, want %v", got, tt.want)
+			}
+			// The byte after the value must be left unread
+			if r.Len() != 1 {
+				t.Errorf("%d bytes left unread, want 1", r.Len())
+			}
+		})
+	}
+}
+
+func TestRawNBTNoValue(t *testing.T) {
+	raw, err := NewRawNBT(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	value, err := raw.Value()
+	if err != nil || value != nil {
+		t.Errorf("Value() = %v, %v, want nil, nil", value, err)
+	}
+}
@@ -3,6 +3,7 @@ package types
 import (
 	"encoding/binary"
 	"fmt"
+	"io"
 )
 
 type Short int16
@@ -20,3 +21,30 @@ func (s *Short) Unmarshal(data []byte) error {
 	*s = Short(binary.BigEndian.Uint16(data))
 	return nil
 }
+
+func WriteShort(s Short, w io.Writer) error {
+	buf, err := s.Marshal()
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(buf)
+	return err
+}
+
+func ReadShort(r io.Reader) (Short, error) {
+	buf := make([]byte, 2)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return 0, fmt.Errorf("failed to read short: %v", err)
+	}
+
+	var s Short
+	if err := s.Unmarshal(buf); err != nil {
+		return 0, err
+	}
+	return s, nil
+}
+
+func (s *Short) Decode(r io.Reader) (err error) {
+	*s, err = ReadShort(r)
+	return err
+}
@@ -0,0 +1,584 @@
+package types
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// SNBTSyntaxError reports malformed stringified NBT
+type SNBTSyntaxError struct {
+	Offset int // byte offset in the input
+	Msg    string
+}
+
+func (e *SNBTSyntaxError) Error() string {
+	return fmt.Sprintf("invalid SNBT at offset %d: %s", e.Offset, e.Msg)
+}
+
+// Patterns of typed numbers, as accepted by the vanilla parser
+var (
+	snbtDoubleNoSuffix = regexp.MustCompile(`(?i)^[-+]?(?:[0-9]+[.]|[0-9]*[.][0-9]+)(?:e[-+]?[0-9]+)?$`)
+	snbtDouble         = regexp.MustCompile(`(?i)^[-+]?(?:[0-9]+[.]?|[0-9]*[.][0-9]+)(?:e[-+]?[0-9]+)?d$`)
+	snbtFloat          = regexp.MustCompile(`(?i)^[-+]?(?:[0-9]+[.]?|[0-9]*[.][0-9]+)(?:e[-+]?[0-9]+)?f$`)
+	snbtByte           = regexp.MustCompile(`(?i)^[-+]?(?:0|[1-9][0-9]*)b$`)
+	snbtShort          = regexp.MustCompile(`(?i)^[-+]?(?:0|[1-9][0-9]*)s$`)
+	snbtInt            = regexp.MustCompile(`^[-+]?(?:0|[1-9][0-9]*)$`)
+	snbtLong           = regexp.MustCompile(`(?i)^[-+]?(?:0|[1-9][0-9]*)l$`)
+)
+
+// ParseSNBT parses stringified NBT such as {Count:1b,id:"minecraft:stone"}.
+// Values have the Go types documented on NBTValue. Lists and compounds may be
+// nested up to the depth of DefaultNBTLimits.
+func ParseSNBT(s string) (NBTValue, error) {
+	p := &snbtParser{input: s}
+	value, err := p.readValue()
+	if err != nil {
+		return nil, err
+	}
+	p.skipWhitespace()
+	if p.pos < len(p.input) {
+		return nil, p.errorf("trailing data")
+	}
+	return value, nil
+}
+
+type snbtParser struct {
+	input string
+	pos   int
+	depth int // of lists and compounds
+}
+
+func (p *snbtParser) errorf(format string, args ...interface{}) error {
+	return &SNBTSyntaxError{Offset: p.pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *snbtParser) skipWhitespace() {
+	for p.pos < len(p.input) && strings.IndexByte(" \t\r\n", p.input[p.pos]) >= 0 {
+		p.pos++
+	}
+}
+
+func (p *snbtParser) peek() byte {
+	if p.pos < len(p.input) {
+		return p.input[p.pos]
+	}
+	return 0
+}
+
+func (p *snbtParser) expect(c byte) error {
+	p.skipWhitespace()
+	if p.peek() != c {
+		return p.errorf("expected '%c'", c)
+	}
+	p.pos++
+	return nil
+}
+
+func (p *snbtParser) readValue() (NBTValue, error) {
+	p.skipWhitespace()
+	switch c := p.peek(); {
+	case c == 0:
+		return nil, p.errorf("expected value")
+	case c == '{':
+		if err := p.enter(); err != nil {
+			return nil, err
+		}
+		defer p.leave()
+		return p.readCompound()
+	case c == '[':
+		if len(p.input) > p.pos+2 && p.input[p.pos+2] == ';' && strings.IndexByte("BIL", p.input[p.pos+1]) >= 0 {
+			return p.readArray()
+		}
+		if err := p.enter(); err != nil {
+			return nil, err
+		}
+		defer p.leave()
+		return p.readList()
+	case c == '"' || c == '\'':
+		return p.readQuoted()
+	default:
+		start := p.pos
+		token := p.readUnquoted()
+		if token == "" {
+			p.pos = start
+			return nil, p.errorf("expected value")
+		}
+		return parseSNBTPrimitive(token), nil
+	}
+}
+
+func (p *snbtParser) enter() error {
+	p.depth++
+	if max := DefaultNBTLimits.MaxDepth; max > 0 && p.depth > max {
+		return p.errorf("nested more than %d levels deep", max)
+	}
+	return nil
+}
+
+func (p *snbtParser) leave() {
+	p.depth--
+}
+
+func (p *snbtParser) readCompound() (*NBTCompound, error) {
+	p.pos++ // {
+	compound := &NBTCompound{}
+
+	p.skipWhitespace()
+	if p.peek() == '}' {
+		p.pos++
+		return compound, nil
+	}
+	for {
+		key, err := p.readKey()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(':'); err != nil {
+			return nil, err
+		}
+		value, err := p.readValue()
+		if err != nil {
+			return nil, err
+		}
+		compound.Set(key, value)
+
+		p.skipWhitespace()
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case '}':
+			p.pos++
+			return compound, nil
+		default:
+			return nil, p.errorf("expected ',' or '}'")
+		}
+	}
+}
+
+func (p *snbtParser) readKey() (string, error) {
+	p.skipWhitespace()
+	if c := p.peek(); c == '"' || c == '\'' {
+		return p.readQuoted()
+	}
+	key := p.readUnquoted()
+	if key == "" {
+		return "", p.errorf("expected key")
+	}
+	return key, nil
+}
+
+func (p *snbtParser) readList() (*NBTList, error) {
+	p.pos++ // [
+	list := &NBTList{}
+
+	p.skipWhitespace()
+	if p.peek() == ']' {
+		p.pos++
+		return list, nil
+	}
+	for {
+		start := p.pos
+		value, err := p.readValue()
+		if err != nil {
+			return nil, err
+		}
+		tagType, _ := nbtTagOf(value)
+		if list.ElemType == TagEnd {
+			list.ElemType = tagType
+		} else if tagType != list.ElemType {
+			p.pos = start
+			return nil, p.errorf("cannot insert %s into list of %s", tagType, list.ElemType)
+		}
+		list.Values = append(list.Values, value)
+
+		p.skipWhitespace()
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case ']':
+			p.pos++
+			return list, nil
+		default:
+			return nil, p.errorf("expected ',' or ']'")
+		}
+	}
+}
+
+func (p *snbtParser) readArray() (NBTValue, error) {
+	kind := p.input[p.pos+1]
+	p.pos += 3 // [X;
+
+	var elemType NBTTag
+	var values []NBTValue
+	switch kind {
+	case 'B':
+		elemType = TagByte
+	case 'I':
+		elemType = TagInt
+	default:
+		elemType = TagLong
+	}
+
+	p.skipWhitespace()
+	if p.peek() == ']' {
+		p.pos++
+	} else {
+		for {
+			start := p.pos
+			value, err := p.readValue()
+			if err != nil {
+				return nil, err
+			}
+			if tagType, _ := nbtTagOf(value); tagType != elemType {
+				p.pos = start
+				return nil, p.errorf("cannot insert %s into %c array", tagType, kind)
+			}
+			values = append(values, value)
+
+			p.skipWhitespace()
+			if p.peek() == ']' {
+				p.pos++
+				break
+			}
+			if p.peek() != ',' {
+				return nil, p.errorf("expected ',' or ']'")
+			}
+			p.pos++
+		}
+	}
+
+	switch kind {
+	case 'B':
+		array := make([]byte, len(values))
+		for i, v := range values {
+			array[i] = byte(v.(int8))
+		}
+		return array, nil
+	case 'I':
+		array := make([]int32, len(values))
+		for i, v := range values {
+			array[i] = v.(int32)
+		}
+		return array, nil
+	default:
+		array := make([]int64, len(values))
+		for i, v := range values {
+			array[i] = v.(int64)
+		}
+		return array, nil
+	}
+}
+
+func (p *snbtParser) readQuoted() (string, error) {
+	quote := p.input[p.pos]
+	p.pos++
+
+	var sb strings.Builder
+	for p.pos < len(p.input) {
+		c := p.input[p.pos]
+		p.pos++
+		switch c {
+		case quote:
+			return sb.String(), nil
+		case '\\':
+			if p.pos >= len(p.input) {
+				return "", p.errorf("unterminated escape")
+			}
+			escaped := p.input[p.pos]
+			p.pos++
+			switch escaped {
+			case '\\', '"', '\'':
+				sb.WriteByte(escaped)
+			case 'n':
+				sb.WriteByte('\n')
+			case 't':
+				sb.WriteByte('\t')
+			case 'r':
+				sb.WriteByte('\r')
+			default:
+				p.pos -= 2
+				return "", p.errorf("invalid escape sequence \\%c", escaped)
+			}
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return "", p.errorf("unterminated string")
+}
+
+func (p *snbtParser) readUnquoted() string {
+	start := p.pos
+	for p.pos < len(p.input) && isSNBTUnquotedChar(p.input[p.pos]) {
+		p.pos++
+	}
+	return p.input[start:p.pos]
+}
+
+func isSNBTUnquotedChar(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' ||
+		c == '_' || c == '-' || c == '.' || c == '+'
+}
+
+// parseSNBTPrimitive types an unquoted token. Like vanilla, numbers that
+// are out of range are kept as strings.
+func parseSNBTPrimitive(token string) NBTValue {
+	switch {
+	case snbtFloat.MatchString(token):
+		if v, err := strconv.ParseFloat(token[:len(token)-1], 32); err == nil {
+			return float32(v)
+		}
+	case snbtByte.MatchString(token):
+		if v, err := strconv.ParseInt(token[:len(token)-1], 10, 8); err == nil {
+			return int8(v)
+		}
+	case snbtLong.MatchString(token):
+		if v, err := strconv.ParseInt(token[:len(token)-1], 10, 64); err == nil {
+			return v
+		}
+	case snbtShort.MatchString(token):
+		if v, err := strconv.ParseInt(token[:len(token)-1], 10, 16); err == nil {
+			return int16(v)
+		}
+	case snbtInt.MatchString(token):
+		if v, err := strconv.ParseInt(token, 10, 32); err == nil {
+			return int32(v)
+		}
+	case snbtDouble.MatchString(token):
+		if v, err := strconv.ParseFloat(token[:len(token)-1], 64); err == nil {
+			return v
+		}
+	case snbtDoubleNoSuffix.MatchString(token):
+		if v, err := strconv.ParseFloat(token, 64); err == nil {
+			return v
+		}
+	case strings.EqualFold(token, "true"):
+		return int8(1)
+	case strings.EqualFold(token, "false"):
+		return int8(0)
+	}
+	return token
+}
+
+// FormatSNBT formats a value as compact stringified NBT
+func FormatSNBT(value NBTValue) (string, error) {
+	f := &snbtFormatter{}
+	if err := f.writeValue(value, 0); err != nil {
+		return "", err
+	}
+	return f.sb.String(), nil
+}
+
+// FormatSNBTIndent formats a value as stringified NBT, putting each entry of
+// a compound and each element of a list of compounds or lists on its own
+// line, indented by indent per nesting level
+func FormatSNBTIndent(value NBTValue, indent string) (string, error) {
+	f := &snbtFormatter{indent: indent, pretty: true}
+	if err := f.writeValue(value, 0); err != nil {
+		return "", err
+	}
+	return f.sb.String(), nil
+}
+
+func (c *NBTCompound) String() string {
+	s, err := FormatSNBT(c)
+	if err != nil {
+		return fmt.Sprintf("<invalid NBT: %v>", err)
+	}
+	return s
+}
+
+func (l *NBTList) String() string {
+	s, err := FormatSNBT(l)
+	if err != nil {
+		return fmt.Sprintf("<invalid NBT: %v>", err)
+	}
+	return s
+}
+
+type snbtFormatter struct {
+	sb     strings.Builder
+	indent string
+	pretty bool
+}
+
+func (f *snbtFormatter) newline(depth int) {
+	f.sb.WriteByte('\n')
+	f.sb.WriteString(strings.Repeat(f.indent, depth))
+}
+
+func (f *snbtFormatter) separator() {
+	f.sb.WriteByte(',')
+	if f.pretty {
+		f.sb.WriteByte(' ')
+	}
+}
+
+func (f *snbtFormatter) writeValue(value NBTValue, depth int) error {
+	switch v := value.(type) {
+	case int8:
+		f.sb.WriteString(strconv.FormatInt(int64(v), 10) + "b")
+	case byte:
+		f.sb.WriteString(strconv.FormatInt(int64(int8(v)), 10) + "b")
+	case bool:
+		if v {
+			f.sb.WriteString("1b")
+		} else {
+			f.sb.WriteString("0b")
+		}
+	case int16:
+		f.sb.WriteString(strconv.FormatInt(int64(v), 10) + "s")
+	case int32:
+		f.sb.WriteString(strconv.FormatInt(int64(v), 10))
+	case int64:
+		f.sb.WriteString(strconv.FormatInt(v, 10) + "L")
+	case float32:
+		f.sb.WriteString(formatSNBTFloat(float64(v), 32) + "f")
+	case float64:
+		f.sb.WriteString(formatSNBTFloat(v, 64) + "d")
+	case string:
+		f.sb.WriteString(quoteSNBT(v))
+	case []byte:
+		f.sb.WriteString("[B;")
+		for i, b := range v {
+			if i > 0 {
+				f.separator()
+			}
+			f.sb.WriteString(strconv.FormatInt(int64(int8(b)), 10) + "B")
+		}
+		f.sb.WriteByte(']')
+	case []int32:
+		f.sb.WriteString("[I;")
+		for i, n := range v {
+			if i > 0 {
+				f.separator()
+			}
+			f.sb.WriteString(strconv.FormatInt(int64(n), 10))
+		}
+		f.sb.WriteByte(']')
+	case []int64:
+		f.sb.WriteString("[L;")
+		for i, n := range v {
+			if i > 0 {
+				f.separator()
+			}
+			f.sb.WriteString(strconv.FormatInt(n, 10) + "L")
+		}
+		f.sb.WriteByte(']')
+	case *NBTList:
+		return f.writeList(v, depth)
+	case *NBTCompound:
+		return f.writeCompound(v, depth)
+	default:
+		return fmt.Errorf("unsupported NBT value type %T", value)
+	}
+	return nil
+}
+
+func (f *snbtFormatter) writeList(list *NBTList, depth int) error {
+	if list == nil || len(list.Values) == 0 {
+		f.sb.WriteString("[]")
+		return nil
+	}
+
+	multiline := f.pretty && (list.ElemType == TagCompound || list.ElemType == TagList)
+	f.sb.WriteByte('[')
+	for i, v := range list.Values {
+		if tagType, err := nbtTagOf(v); err != nil || tagType != list.ElemType {
+			return nbtMismatch(v, list.ElemType)
+		}
+		if i > 0 {
+			f.sb.WriteByte(',')
+			if !multiline && f.pretty {
+				f.sb.WriteByte(' ')
+			}
+		}
+		if multiline {
+			f.newline(depth + 1)
+		}
+		if err := f.writeValue(v, depth+1); err != nil {
+			return err
+		}
+	}
+	if multiline {
+		f.newline(depth)
+	}
+	f.sb.WriteByte(']')
+	return nil
+}
+
+func (f *snbtFormatter) writeCompound(compound *NBTCompound, depth int) error {
+	if compound == nil || len(compound.Entries) == 0 {
+		f.sb.WriteString("{}")
+		return nil
+	}
+
+	f.sb.WriteByte('{')
+	for i, entry := range compound.Entries {
+		if i > 0 {
+			f.sb.WriteByte(',')
+		}
+		if f.pretty {
+			f.newline(depth + 1)
+		}
+		f.sb.WriteString(quoteSNBTKey(entry.Name))
+		f.sb.WriteByte(':')
+		if f.pretty {
+			f.sb.WriteByte(' ')
+		}
+		if err := f.writeValue(entry.Value, depth+1); err != nil {
+			return fmt.Errorf("%s: %v", entry.Name, err)
+		}
+	}
+	if f.pretty {
+		f.newline(depth)
+	}
+	f.sb.WriteByte('}')
+	return nil
+}
+
+func formatSNBTFloat(v float64, bitSize int) string {
+	if math.IsInf(v, 0) || math.IsNaN(v) {
+		// Not representable, vanilla writes these the same way
+		return strconv.FormatFloat(v, 'g', -1, bitSize)
+	}
+	s := strconv.FormatFloat(v, 'g', -1, bitSize)
+	if !strings.ContainsAny(s, ".e") {
+		s += ".0"
+	}
+	return s
+}
+
+// quoteSNBT quotes a string, preferring double quotes like vanilla
+func quoteSNBT(s string) string {
+	quote := byte('"')
+	if strings.IndexByte(s, '"') >= 0 && strings.IndexByte(s, '\'') < 0 {
+		quote = '\''
+	}
+
+	var sb strings.Builder
+	sb.WriteByte(quote)
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '\\' || c == quote {
+			sb.WriteByte('\\')
+		}
+		sb.WriteByte(c)
+	}
+	sb.WriteByte(quote)
+	return sb.String()
+}
+
+func quoteSNBTKey(key string) string {
+	if key == "" {
+		return `""`
+	}
+	for i := 0; i < len(key); i++ {
+		if !isSNBTUnquotedChar(key[i]) {
+			return quoteSNBT(key)
+		}
+	}
+	return key
+}
@@ -0,0 +1,135 @@
+package types
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseSNBT(t *testing.T) {
+	tests := []struct {
+		input string
+		want  NBTValue
+	}{
+		{"1b", int8(1)},
+		{"true", int8(1)},
+		{"-3s", int16(-3)},
+		{"42", int32(42)},
+		{"9000000000L", int64(9000000000)},
+		{"1.5f", float32(1.5)},
+		{"2.5", float64(2.5)},
+		{"1e3d", float64(1000)},
+		{"stone", "stone"},
+		{`'minecraft:stone'`, "minecraft:stone"},
+		{`"say \"hi\""`, `say "hi"`},
+		{"[B;1b,2b]", []byte{1, 2}},
+		{"[I; 1, -2]", []int32{1, -2}},
+		{"[L;5l]", []int64{5}},
+		{"[]", &NBTList{}},
+		{"[1,2]", &NBTList{ElemType: TagInt, Values: []NBTValue{int32(1), int32(2)}}},
+		{
+			`{ b: 1b , a: {c: "x"} }`,
+			&NBTCompound{Entries: []NBTEntry{
+				{Name: "b", Value: int8(1)},
+				{Name: "a", Value: &NBTCompound{Entries: []NBTEntry{{Name: "c", Value: "x"}}}},
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseSNBT(tt.input)
+			if err != nil {
+				t.Fatalf("ParseSNBT: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseSNBT = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSNBTErrors(t *testing.T) {
+	tests := []string{
+		"",
+		"{",
+		"{a:1,}",
+		"{a 1}",
+		"[1,2b]",
+		"[I;1b]",
+		`"unterminated`,
+		"1 2",
+		"minecraft:stone",
+		`'it''s'`,
+		strings.Repeat("[", DefaultNBTLimits.MaxDepth+1) + strings.Repeat("]", DefaultNBTLimits.MaxDepth+1),
+		strings.Repeat("{a:", DefaultNBTLimits.MaxDepth+1) + "1" + strings.Repeat("}", DefaultNBTLimits.MaxDepth+1),
+	}
+	for _, input := range tests {
+		value, err := ParseSNBT(input)
+		var syntaxErr *SNBTSyntaxError
+		if !errors.As(err, &syntaxErr) {
+			t.Errorf("ParseSNBT(%.20q) = %v, %v, want SNBTSyntaxError", input, value, err)
+		}
+	}
+}
+
+func TestParseSNBTMaxDepth(t *testing.T) {
+	depth := DefaultNBTLimits.MaxDepth
+	input := strings.Repeat("[", depth) + strings.Repeat("]", depth)
+	if _, err := ParseSNBT(input); err != nil {
+		t.Errorf("ParseSNBT of %d nested lists: %v", depth, err)
+	}
+}
+
+func TestFormatSNBTRoundTrip(t *testing.T) {
+	tests := []string{
+		`{a:1b,b:2s,c:3,d:4L,e:5.5f,f:6.0d}`,
+		`{"with space":"x",list:[{id:1},{id:2}],bytes:[B;1B,2B],ints:[I;3,4],longs:[L;5L]}`,
+		`{nested:{empty:{},quote:'say "hi"',escaped:"back\\slash"}}`,
+		`[[1,2],[3]]`,
+	}
+	for _, input := range tests {
+		value, err := ParseSNBT(input)
+		if err != nil {
+			t.Fatalf("ParseSNBT(%q): %v", input, err)
+		}
+
+		for _, indent := range []string{"", "  "} {
+			var formatted string
+			if indent == "" {
+				formatted, err = FormatSNBT(value)
+			} else {
+				formatted, err = FormatSNBTIndent(value, indent)
+			}
+			if err != nil {
+				t.Fatalf("format %q: %v", input, err)
+			}
+			reparsed, err := ParseSNBT(formatted)
+			if err != nil {
+				t.Fatalf("ParseSNBT(%q): %v", formatted, err)
+			}
+			if !reflect.DeepEqual(reparsed, value) {
+				t.Errorf("round trip of %q through %q = %#v, want %#v", input, formatted, reparsed, value)
+			}
+		}
+	}
+}
+
+func TestFormatSNBT(t *testing.T) {
+	value := &NBTCompound{Entries: []NBTEntry{
+		{Name: "id", Value: "minecraft:stone"},
+		{Name: "Count", Value: int8(1)},
+		{Name: "list", Value: &NBTList{ElemType: TagShort, Values: []NBTValue{int16(1), int16(2)}}},
+	}}
+	got, err := FormatSNBT(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{id:"minecraft:stone",Count:1b,list:[1s,2s]}`; got != want {
+		t.Errorf("FormatSNBT = %s, want %s", got, want)
+	}
+
+	if _, err := FormatSNBT(struct{}{}); err == nil {
+		t.Error("FormatSNBT of an unsupported type succeeded")
+	}
+}
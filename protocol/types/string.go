@@ -1,64 +1,123 @@
 package types
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"io"
+	"unicode/utf16"
+	"unicode/utf8"
 )
 
 type String struct {
 	Value string
 }
 
+// Marshal encodes a string of at most DefaultMaxStringLength like WriteString
 func (s String) Marshal() ([]byte, error) {
-	strBytes := []byte(s.Value)
-	length := VarInt(len(strBytes))
-
-	// Marshal the length
-	lenBytes, err := length.Marshal()
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal string length: %v", err)
+	var buf bytes.Buffer
+	if err := WriteString(s, &buf); err != nil {
+		return nil, err
 	}
-
-	// Combine length and string data
-	result := append(lenBytes, strBytes...)
-	return result, nil
+	return buf.Bytes(), nil
 }
 
+// Unmarshal decodes a string of at most DefaultMaxStringLength like
+// ReadString
 func (s *String) Unmarshal(data []byte) error {
-	if len(data) == 0 {
-		return fmt.Errorf("string data is empty")
-	}
+	return unmarshalWith(s, data)
+}
 
-	// Read the length
-	var length VarInt
-	if err := length.Unmarshal(data); err != nil {
-		return fmt.Errorf("failed to unmarshal string length: %v", err)
-	}
+// DefaultMaxStringLength is the maximum length of strings without a more
+// specific limit, in UTF-16 code units
+const DefaultMaxStringLength = 32767
 
-	// Calculate where the VarInt ends
-	varIntSize := 0
-	for _, b := range data {
-		varIntSize++
-		if b&0x80 == 0 {
-			break
-		}
-	}
+// ErrInvalidUTF8 is returned for strings that are not valid UTF-8
+var ErrInvalidUTF8 = errors.New("string is not valid UTF-8")
 
-	// Check if we have enough data
-	if len(data) < varIntSize+int(length) {
-		return fmt.Errorf("string data too short")
+// StringTooLongError is returned for strings exceeding their maximum length.
+// Like in the game, lengths are counted in UTF-16 code units, so characters
+// outside the Basic Multilingual Plane count twice.
+type StringTooLongError struct {
+	Length int // in UTF-16 code units, or in bytes if the string was not read
+	Max    int
+}
+
+func (e *StringTooLongError) Error() string {
+	return fmt.Sprintf("string of length %d exceeds the maximum of %d", e.Length, e.Max)
+}
+
+// UTF16Length returns the number of UTF-16 code units encoding s
+func UTF16Length(s string) int {
+	length := 0
+	for _, r := range s {
+		length += utf16.RuneLen(r)
 	}
+	return length
+}
 
-	// Extract the string
-	s.Value = string(data[varIntSize : varIntSize+int(length)])
+// checkString validates a string against a maximum length
+func checkString(s string, max int) error {
+	if !utf8.ValidString(s) {
+		return ErrInvalidUTF8
+	}
+	// Each code unit takes at most three bytes, so short strings are fine
+	if len(s) > max {
+		if length := UTF16Length(s); length > max {
+			return &StringTooLongError{Length: length, Max: max}
+		}
+	}
 	return nil
 }
 
+// WriteString writes a string of at most DefaultMaxStringLength
 func WriteString(str String, w io.Writer) error {
-	buf, err := str.Marshal()
-	if err != nil {
+	return WriteStringMax(str, w, DefaultMaxStringLength)
+}
+
+// WriteStringMax writes a string after checking it is valid UTF-8 of at most
+// max UTF-16 code units
+func WriteStringMax(str String, w io.Writer, max int) error {
+	if err := checkString(str.Value, max); err != nil {
 		return err
 	}
-	_, err = w.Write(buf)
+	buf := make([]byte, VarIntSize(VarInt(len(str.Value)))+len(str.Value))
+	copy(buf[PutVarInt(buf, VarInt(len(str.Value))):], str.Value)
+	_, err := w.Write(buf)
+	return err
+}
+
+// ReadString reads a string of at most DefaultMaxStringLength
+func ReadString(r io.Reader) (String, error) {
+	return ReadStringMax(r, DefaultMaxStringLength)
+}
+
+func (s *String) Decode(r io.Reader) (err error) {
+	*s, err = ReadString(r)
 	return err
 }
+
+// ReadStringMax reads a string, rejecting invalid UTF-8 and strings longer
+// than max UTF-16 code units. Oversized lengths are rejected before reading.
+func ReadStringMax(r io.Reader, max int) (String, error) {
+	length, err := ReadVarInt(r)
+	if err != nil {
+		return String{}, fmt.Errorf("failed to read string length: %v", err)
+	}
+	if length < 0 {
+		return String{}, fmt.Errorf("string length is negative")
+	}
+	if int64(length) > int64(max)*3 {
+		return String{}, &StringTooLongError{Length: int(length), Max: max}
+	}
+
+	buf := make([]byte, length)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return String{}, fmt.Errorf("failed to read string: %v", err)
+	}
+	str := String{Value: string(buf)}
+	if err := checkString(str.Value, max); err != nil {
+		return String{}, err
+	}
+	return str, nil
+}
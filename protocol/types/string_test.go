@@ -0,0 +1,114 @@
+package types
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestUTF16Length(t *testing.T) {
+	tests := []struct {
+		s    string
+		want int
+	}{
+		{"", 0},
+		{"abc", 3},
+		{"é", 1},
+		{"€", 1},
+		{"🎉", 2},
+	}
+	for _, tt := range tests {
+		if got := UTF16Length(tt.s); got != tt.want {
+			t.Errorf("UTF16Length(%q) = %d, want %d", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestStringRoundTrip(t *testing.T) {
+	tests := []string{"", "hello", "héllo €", strings.Repeat("a", DefaultMaxStringLength), strings.Repeat("€", DefaultMaxStringLength)}
+	for _, s := range tests {
+		data, err := String{Value: s}.Marshal()
+		if err != nil {
+			t.Fatalf("Marshal(%.10q): %v", s, err)
+		}
+
+		var got String
+		if err := got.Unmarshal(data); err != nil || got.Value != s {
+			t.Errorf("Unmarshal(%.10q) = %.10q, %v", s, got.Value, err)
+		}
+		read, err := ReadString(bytes.NewReader(data))
+		if err != nil || read.Value != s {
+			t.Errorf("ReadString(%.10q) = %.10q, %v", s, read.Value, err)
+		}
+		buffered, err := NewBuffer(data).GetString()
+		if err != nil || buffered.Value != s {
+			t.Errorf("GetString(%.10q) = %.10q, %v", s, buffered.Value, err)
+		}
+	}
+}
+
+func TestStringWriteErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		max  int
+		want error // nil for a StringTooLongError
+	}{
+		{"invalid UTF-8", "a\xffb", 10, ErrInvalidUTF8},
+		{"too long", "abcd", 3, nil},
+		{"surrogate pairs count twice", "🎉🎉", 3, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := WriteStringMax(String{Value: tt.s}, &bytes.Buffer{}, tt.max)
+			var tooLong *StringTooLongError
+			if tt.want == nil {
+				if !errors.As(err, &tooLong) || tooLong.Max != tt.max {
+					t.Errorf("WriteStringMax = %v, want StringTooLongError", err)
+				}
+			} else if !errors.Is(err, tt.want) {
+				t.Errorf("WriteStringMax = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	var tooLong *StringTooLongError
+	if _, err := (String{Value: strings.Repeat("a", DefaultMaxStringLength+1)}).Marshal(); !errors.As(err, &tooLong) {
+		t.Errorf("Marshal of an oversized string = %v, want StringTooLongError", err)
+	}
+	if _, err := (String{Value: "\xff"}).Marshal(); !errors.Is(err, ErrInvalidUTF8) {
+		t.Errorf("Marshal of invalid UTF-8 = %v, want ErrInvalidUTF8", err)
+	}
+}
+
+func TestStringReadErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		max  int
+	}{
+		{"empty", nil, 10},
+		{"negative length", []byte{0xff, 0xff, 0xff, 0xff, 0x0f, 'a'}, 10},
+		{"truncated", []byte{0x03, 'a'}, 10},
+		{"invalid UTF-8", []byte{0x02, 'a', 0xff}, 10},
+		{"too long", []byte{0x04, 'a', 'b', 'c', 'd'}, 3},
+		{"length over three bytes per unit", []byte{0x0a}, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if s, err := ReadStringMax(bytes.NewReader(tt.data), tt.max); err == nil {
+				t.Errorf("ReadStringMax = %q, want error", s.Value)
+			}
+			if s, err := NewBuffer(tt.data).GetStringMax(tt.max); err == nil {
+				t.Errorf("GetStringMax = %q, want error", s.Value)
+			}
+			if tt.max == 10 {
+				var s String
+				if err := s.Unmarshal(tt.data); err == nil {
+					t.Errorf("Unmarshal = %q, want error", s.Value)
+				}
+			}
+		})
+	}
+}
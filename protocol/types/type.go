@@ -1,6 +1,37 @@
 package types
 
+import (
+	"bytes"
+	"io"
+)
+
 type MarshallableType interface {
 	Marshal() ([]byte, error)
 	Unmarshal([]byte) error
 }
+
+// Decoder is implemented by the pointers of types that can be read from a
+// stream. Unlike Unmarshal, it consumes exactly one value, which composite
+// types such as Optional and PrefixedArray rely on to read their elements.
+type Decoder interface {
+	Decode(r io.Reader) error
+}
+
+// marshaler is the encoding half of MarshallableType
+type marshaler interface {
+	Marshal() ([]byte, error)
+}
+
+// element constrains the pointer type PT of the elements of composite types
+// such as Optional and PrefixedArray to implement Decoder, so that an element
+// type T which can only be marshaled is rejected at compile time. PT is
+// always *T, as in Optional[VarInt, *VarInt].
+type element[T any] interface {
+	*T
+	Decoder
+}
+
+// unmarshalWith implements Unmarshal in terms of Decode
+func unmarshalWith(d Decoder, data []byte) error {
+	return d.Decode(bytes.NewReader(data))
+}
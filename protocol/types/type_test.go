@@ -0,0 +1,137 @@
+package types
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"strings"
+	"testing"
+)
+
+// onlyReader hides the io.ByteReader of a reader to test the slow paths
+type onlyReader struct {
+	io.Reader
+}
+
+// wireCase checks that write encodes value as want and that read decodes
+// it again
+type wireCase struct {
+	name  string
+	write func(w io.Writer) error
+	read  func(r io.Reader) (any, error)
+	value any
+	want  []byte
+}
+
+func wire[T comparable](name string, write func(T, io.Writer) error, read func(io.Reader) (T, error), value T, want ...byte) wireCase {
+	return wireCase{
+		name:  name,
+		write: func(w io.Writer) error { return write(value, w) },
+		read:  func(r io.Reader) (any, error) { return read(r) },
+		value: value,
+		want:  want,
+	}
+}
+
+func TestReadWrite(t *testing.T) {
+	tests := []wireCase{
+		wire("byte", WriteByte, ReadByte, -2, 0xfe),
+		wire("unsigned byte", WriteUnsignedByte, ReadUnsignedByte, 200, 0xc8),
+		wire("short", WriteShort, ReadShort, -2, 0xff, 0xfe),
+		wire("unsigned short", WriteUnsignedShort, ReadUnsignedShort, 25565, 0x63, 0xdd),
+		wire("int", WriteInt, ReadInt, -2, 0xff, 0xff, 0xff, 0xfe),
+		wire("long", WriteLong, ReadLong, 1<<40, 0, 0, 1, 0, 0, 0, 0, 0),
+		wire("float", WriteFloat, ReadFloat, 1.5, 0x3f, 0xc0, 0, 0),
+		wire("double", WriteDouble, ReadDouble, -2, 0xc0, 0, 0, 0, 0, 0, 0, 0),
+		wire("true", WriteBoolean, ReadBoolean, true, 1),
+		wire("false", WriteBoolean, ReadBoolean, false, 0),
+		wire("position", WritePosition, ReadPosition, Position{X: -1, Y: -2, Z: 3}, 0xff, 0xff, 0xff, 0xc0, 0, 0, 0x3f, 0xfe),
+		wire("uuid", WriteUUID, ReadUUID, UUID{MostSignificantBits: 1, LeastSignificantBits: -1}, 0, 0, 0, 0, 0, 0, 0, 1, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff),
+		wire("varint zero", WriteVarInt, ReadVarInt, 0, 0),
+		wire("varint", WriteVarInt, ReadVarInt, 25565, 0xdd, 0xc7, 0x01),
+		wire("varint max", WriteVarInt, ReadVarInt, math.MaxInt32, 0xff, 0xff, 0xff, 0xff, 0x07),
+		wire("varint negative", WriteVarInt, ReadVarInt, -1, 0xff, 0xff, 0xff, 0xff, 0x0f),
+		wire("varlong", WriteVarLong, ReadVarLong, 128, 0x80, 0x01),
+		wire("varlong negative", WriteVarLong, ReadVarLong, -1, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01),
+		wire("varlong min", WriteVarLong, ReadVarLong, math.MinInt64, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x01),
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := tt.write(&buf); err != nil {
+				t.Fatalf("write: %v", err)
+			}
+			if !bytes.Equal(buf.Bytes(), tt.want) {
+				t.Errorf("write = % x, want % x", buf.Bytes(), tt.want)
+			}
+
+			for _, r := range []io.Reader{bytes.NewReader(tt.want), onlyReader{bytes.NewReader(tt.want)}} {
+				got, err := tt.read(r)
+				if err != nil || got != tt.value {
+					t.Errorf("read(%T) = %v, %v, want %v", r, got, err, tt.value)
+				}
+			}
+
+			// Truncated values fail with io.ErrUnexpectedEOF or io.EOF
+			if _, err := tt.read(bytes.NewReader(tt.want[:len(tt.want)-1])); err == nil {
+				t.Error("read of a truncated value succeeded")
+			}
+		})
+	}
+}
+
+func TestVarIntErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"empty", nil, io.EOF},
+		{"incomplete", []byte{0x80}, io.ErrUnexpectedEOF},
+		{"too big", []byte{0x80, 0x80, 0x80, 0x80, 0x80, 0x01}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ReadVarInt(bytes.NewReader(tt.data))
+			// ReadVarInt wraps errors with %v, so only the message is kept
+			if err == nil || tt.want != nil && !strings.HasSuffix(err.Error(), tt.want.Error()) {
+				t.Errorf("ReadVarInt = %v, want %v", err, tt.want)
+			}
+			var v VarInt
+			if err := v.Unmarshal(tt.data); err == nil {
+				t.Errorf("Unmarshal = %d, want error", v)
+			}
+		})
+	}
+
+	if _, err := ReadVarLong(bytes.NewReader(bytes.Repeat([]byte{0x80}, 11))); err == nil {
+		t.Error("ReadVarLong of 11 bytes succeeded")
+	}
+}
+
+func TestVarSizes(t *testing.T) {
+	tests := []struct {
+		value int64
+		size  int
+	}{
+		{0, 1},
+		{127, 1},
+		{128, 2},
+		{16383, 2},
+		{16384, 3},
+		{math.MaxInt32, 5},
+		{-1, 5},
+	}
+	for _, tt := range tests {
+		if got := VarIntSize(VarInt(tt.value)); got != tt.size {
+			t.Errorf("VarIntSize(%d) = %d, want %d", tt.value, got, tt.size)
+		}
+		data, _ := VarInt(tt.value).Marshal()
+		if len(data) != tt.size {
+			t.Errorf("VarInt(%d).Marshal is %d bytes, want %d", tt.value, len(data), tt.size)
+		}
+	}
+	if got := VarLongSize(-1); got != MaxVarLongSize {
+		t.Errorf("VarLongSize(-1) = %d, want %d", got, MaxVarLongSize)
+	}
+}
@@ -1,6 +1,9 @@
 package types
 
-import "fmt"
+import (
+	"fmt"
+	"io"
+)
 
 type UnsignedByte uint8
 
@@ -15,3 +18,30 @@ func (ub *UnsignedByte) Unmarshal(data []byte) error {
 	*ub = UnsignedByte(data[0])
 	return nil
 }
+
+func WriteUnsignedByte(ub UnsignedByte, w io.Writer) error {
+	buf, err := ub.Marshal()
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(buf)
+	return err
+}
+
+func ReadUnsignedByte(r io.Reader) (UnsignedByte, error) {
+	buf := make([]byte, 1)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return 0, fmt.Errorf("failed to read unsigned byte: %v", err)
+	}
+
+	var ub UnsignedByte
+	if err := ub.Unmarshal(buf); err != nil {
+		return 0, err
+	}
+	return ub, nil
+}
+
+func (ub *UnsignedByte) Decode(r io.Reader) (err error) {
+	*ub, err = ReadUnsignedByte(r)
+	return err
+}
@@ -30,3 +30,21 @@ func WriteUnsignedShort(us UnsignedShort, w io.Writer) error {
 	_, err = w.Write(buf)
 	return err
 }
+
+func ReadUnsignedShort(r io.Reader) (UnsignedShort, error) {
+	buf := make([]byte, 2)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return 0, fmt.Errorf("failed to read unsigned short: %v", err)
+	}
+
+	var us UnsignedShort
+	if err := us.Unmarshal(buf); err != nil {
+		return 0, err
+	}
+	return us, nil
+}
+
+func (us *UnsignedShort) Decode(r io.Reader) (err error) {
+	*us, err = ReadUnsignedShort(r)
+	return err
+}
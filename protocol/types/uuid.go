@@ -2,7 +2,10 @@ package types
 
 import (
 	"encoding/binary"
+	"encoding/hex"
 	"fmt"
+	"io"
+	"strings"
 )
 
 type UUID struct {
@@ -27,12 +30,72 @@ func (u *UUID) Unmarshal(data []byte) error {
 	return nil
 }
 
+func WriteUUID(u UUID, w io.Writer) error {
+	buf, err := u.Marshal()
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(buf)
+	return err
+}
+
+func ReadUUID(r io.Reader) (UUID, error) {
+	buf := make([]byte, 16)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return UUID{}, fmt.Errorf("failed to read UUID: %v", err)
+	}
+
+	var u UUID
+	if err := u.Unmarshal(buf); err != nil {
+		return UUID{}, err
+	}
+	return u, nil
+}
+
+func (u *UUID) Decode(r io.Reader) (err error) {
+	*u, err = ReadUUID(r)
+	return err
+}
+
+// ParseUUID parses a UUID with or without dashes, as used by the session server
+func ParseUUID(s string) (UUID, error) {
+	data, err := hex.DecodeString(strings.ReplaceAll(s, "-", ""))
+	if err != nil || len(data) != 16 {
+		return UUID{}, fmt.Errorf("invalid UUID %q", s)
+	}
+
+	var u UUID
+	if err := u.Unmarshal(data); err != nil {
+		return UUID{}, err
+	}
+	return u, nil
+}
+
 // String returns the string representation of the UUID
 func (u UUID) String() string {
-	return fmt.Sprintf("%x-%x-%x-%x-%x",
+	return fmt.Sprintf("%08x-%04x-%04x-%04x-%012x",
 		uint32(u.MostSignificantBits>>32),
 		uint16(u.MostSignificantBits>>16),
 		uint16(u.MostSignificantBits),
 		uint16(u.LeastSignificantBits>>48),
 		uint64(u.LeastSignificantBits)&0x0000FFFFFFFFFFFF)
 }
+
+// UUIDFromInts builds a UUID from four ints, most significant first, as
+// stored in NBT
+func UUIDFromInts(ints [4]int32) UUID {
+	return UUID{
+		MostSignificantBits:  int64(ints[0])<<32 | int64(uint32(ints[1])),
+		LeastSignificantBits: int64(ints[2])<<32 | int64(uint32(ints[3])),
+	}
+}
+
+// Ints returns the UUID as four ints, most significant first
+func (u UUID) Ints() [4]int32 {
+	return [4]int32{
+		int32(u.MostSignificantBits >> 32),
+		int32(u.MostSignificantBits),
+		int32(u.LeastSignificantBits >> 32),
+		int32(u.LeastSignificantBits),
+	}
+}
@@ -8,19 +8,8 @@ import (
 type VarInt int32
 
 func (v VarInt) Marshal() ([]byte, error) {
-	var value uint32 = uint32(v)
-	var buf []byte
-	for {
-		b := byte(value & 0x7F)
-		value >>= 7
-		if value != 0 {
-			b |= 0x80
-		}
-		buf = append(buf, b)
-		if value == 0 {
-			break
-		}
-	}
+	buf := make([]byte, VarIntSize(v))
+	PutVarInt(buf, v)
 	return buf, nil
 }
 
@@ -47,32 +36,61 @@ func (v *VarInt) Unmarshal(data []byte) error {
 }
 
 func WriteVarInt(varInt VarInt, w io.Writer) error {
-	buf, err := varInt.Marshal()
+	var buf [MaxVarIntSize]byte
+	_, err := w.Write(buf[:PutVarInt(buf[:], varInt)])
+	return err
+}
+
+func ReadVarInt(r io.Reader) (VarInt, error) {
+	value, err := readVarUint(r, MaxVarIntSize)
 	if err != nil {
-		return err
+		return 0, fmt.Errorf("failed to read VarInt: %v", err)
 	}
-	_, err = w.Write(buf)
+	return VarInt(value), nil
+}
+
+func (v *VarInt) Decode(r io.Reader) (err error) {
+	*v, err = ReadVarInt(r)
 	return err
 }
 
-func ReadVarInt(r io.Reader) (VarInt, error) {
-	var value VarInt
-	var buf []byte
-	for {
-		tmp := make([]byte, 1)
-		_, err := r.Read(tmp)
-		if err != nil {
-			return 0, fmt.Errorf("failed to read VarInt: %v", err)
+// readVarUint reads a VarInt or VarLong of at most maxBytes bytes. Readers
+// implementing io.ByteReader, like Buffer and bufio.Reader, are read without
+// allocating.
+func readVarUint(r io.Reader, maxBytes int) (uint64, error) {
+	byteReader, ok := r.(io.ByteReader)
+	var scratch []byte
+	if !ok {
+		scratch = make([]byte, 1)
+	}
+
+	var value uint64
+	for i := 0; i < maxBytes; i++ {
+		var b byte
+		if ok {
+			var err error
+			if b, err = byteReader.ReadByte(); err != nil {
+				return 0, unexpectedEOF(err, i)
+			}
+		} else {
+			if _, err := io.ReadFull(r, scratch); err != nil {
+				return 0, unexpectedEOF(err, i)
+			}
+			b = scratch[0]
 		}
 
-		buf = append(buf, tmp[0])
-		if tmp[0]&0x80 == 0 {
-			break
+		value |= uint64(b&0x7F) << (7 * i)
+		if b&0x80 == 0 {
+			return value, nil
 		}
 	}
+	return 0, fmt.Errorf("value is too big")
+}
 
-	if err := value.Unmarshal(buf); err != nil {
-		return 0, err
+// unexpectedEOF turns io.EOF in the middle of a value into io.ErrUnexpectedEOF
+func unexpectedEOF(err error, read int) error {
+	if err == io.EOF && read > 0 {
+		return io.ErrUnexpectedEOF
 	}
-	return value, nil
+	return err
 }
@@ -2,24 +2,16 @@ package types
 
 import (
 	"fmt"
+	"io"
 )
 
+// VarLong is a variable-length int64 in the same format as VarInt. Negative
+// values always take ten bytes.
 type VarLong int64
 
 func (v VarLong) Marshal() ([]byte, error) {
-	var value uint64 = uint64((v << 1) ^ (v >> 63)) // zigzag encoding
-	var buf []byte
-	for {
-		b := byte(value & 0x7F)
-		value >>= 7
-		if value != 0 {
-			b |= 0x80
-		}
-		buf = append(buf, b)
-		if value == 0 {
-			break
-		}
-	}
+	buf := make([]byte, VarLongSize(v))
+	PutVarLong(buf, v)
 	return buf, nil
 }
 
@@ -32,8 +24,7 @@ func (v *VarLong) Unmarshal(data []byte) error {
 		value |= uint64(currentByte&0x7F) << position
 
 		if currentByte&0x80 == 0 {
-			// Convert back from zigzag encoding
-			*v = VarLong((value >> 1) ^ -(value & 1))
+			*v = VarLong(value)
 			return nil
 		}
 
@@ -45,3 +36,22 @@ func (v *VarLong) Unmarshal(data []byte) error {
 
 	return fmt.Errorf("VarLong is incomplete")
 }
+
+func WriteVarLong(varLong VarLong, w io.Writer) error {
+	var buf [MaxVarLongSize]byte
+	_, err := w.Write(buf[:PutVarLong(buf[:], varLong)])
+	return err
+}
+
+func ReadVarLong(r io.Reader) (VarLong, error) {
+	value, err := readVarUint(r, MaxVarLongSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read VarLong: %v", err)
+	}
+	return VarLong(value), nil
+}
+
+func (v *VarLong) Decode(r io.Reader) (err error) {
+	*v, err = ReadVarLong(r)
+	return err
+}
@@ -0,0 +1,112 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"fmt"
+	"time"
+
+	"mc-proxy/protocol/auth"
+	"mc-proxy/protocol/packet"
+	"mc-proxy/protocol/types"
+)
+
+const sessionTimeout = 10 * time.Second
+
+// authenticate runs the encryption handshake with the client and verifies the
+// player with the session server. The backend is expected to run in offline
+// mode and is logged in with the verified name and UUID.
+func (c *Connection) authenticate() error {
+	p, err := c.clientConn.ReadPacket()
+	if err != nil {
+		return fmt.Errorf("failed to read login start: %v", err)
+	}
+	loginStart, ok := p.(*packet.LoginStart)
+	if !ok {
+		return fmt.Errorf("expected login start, got packet 0x%02X", p.ID())
+	}
+
+	verifyToken := make([]byte, 4)
+	if _, err := rand.Read(verifyToken); err != nil {
+		return fmt.Errorf("failed to generate verify token: %v", err)
+	}
+
+	request := &packet.EncryptionRequest{
+		PublicKey:          c.proxy.publicKey,
+		VerifyToken:        verifyToken,
+		ShouldAuthenticate: true,
+	}
+	if err := c.clientConn.WritePacket(request); err != nil {
+		return fmt.Errorf("failed to send encryption request: %v", err)
+	}
+
+	p, err = c.clientConn.ReadPacket()
+	if err != nil {
+		return fmt.Errorf("failed to read encryption response: %v", err)
+	}
+	response, ok := p.(*packet.EncryptionResponse)
+	if !ok {
+		return fmt.Errorf("expected encryption response, got packet 0x%02X", p.ID())
+	}
+
+	sharedSecret, err := rsa.DecryptPKCS1v15(nil, c.proxy.privateKey, response.SharedSecret)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt shared secret: %v", err)
+	}
+	if len(sharedSecret) != 16 {
+		return fmt.Errorf("shared secret has invalid length %d", len(sharedSecret))
+	}
+	token, err := rsa.DecryptPKCS1v15(nil, c.proxy.privateKey, response.VerifyToken)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt verify token: %v", err)
+	}
+	if !bytes.Equal(token, verifyToken) {
+		return fmt.Errorf("verify token does not match")
+	}
+
+	if err := c.clientConn.EnableEncryption(sharedSecret); err != nil {
+		return fmt.Errorf("failed to enable encryption: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), sessionTimeout)
+	defer cancel()
+
+	serverHash := auth.ServerHash("", sharedSecret, c.proxy.publicKey)
+	profile, err := c.proxy.sessionServer.HasJoined(ctx, loginStart.Name.Value, serverHash, "")
+	if err != nil {
+		return fmt.Errorf("failed to verify %s: %v", loginStart.Name.Value, err)
+	}
+	uuid, err := profile.UUID()
+	if err != nil {
+		return err
+	}
+	c.profile = profile
+
+	// Log in to the backend with the verified profile
+	backendStart := &packet.LoginStart{
+		Name:       types.String{Value: profile.Name},
+		PlayerUUID: uuid,
+	}
+	if err := c.serverConn.WritePacket(backendStart); err != nil {
+		return fmt.Errorf("failed to forward login start: %v", err)
+	}
+	return nil
+}
+
+// loginProperties converts the properties of a verified profile for Login Success
+func loginProperties(profile *auth.Profile) []packet.LoginProperty {
+	properties := make([]packet.LoginProperty, 0, len(profile.Properties))
+	for _, property := range profile.Properties {
+		loginProperty := packet.LoginProperty{
+			Name:  types.String{Value: property.Name},
+			Value: types.String{Value: property.Value},
+		}
+		if property.Signature != "" {
+			loginProperty.Signature = &types.String{Value: property.Signature}
+		}
+		properties = append(properties, loginProperty)
+	}
+	return properties
+}
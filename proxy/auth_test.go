@@ -0,0 +1,159 @@
+package proxy
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"errors"
+	"net"
+	"testing"
+
+	"mc-proxy/protocol/auth"
+	"mc-proxy/protocol/packet"
+	"mc-proxy/protocol/types"
+)
+
+// newTestProxy creates a proxy in online mode without a listener
+func newTestProxy(t *testing.T, sessionServer auth.SessionServer) *Proxy {
+	t.Helper()
+	privateKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKey, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Proxy{
+		onlineMode:    true,
+		sessionServer: sessionServer,
+		privateKey:    privateKey,
+		publicKey:     publicKey,
+	}
+}
+
+// pipe returns the two ends of an in-memory connection in the login state.
+// The first end reads clientbound packets.
+func pipe(t *testing.T) (*packet.Conn, *packet.Conn) {
+	a, b := net.Pipe()
+	clientbound, serverbound := packet.NewConn(a, packet.Clientbound), packet.NewConn(b, packet.Serverbound)
+	clientbound.SetState(packet.StateLogin)
+	serverbound.SetState(packet.StateLogin)
+	t.Cleanup(func() {
+		clientbound.Close()
+		serverbound.Close()
+	})
+	return clientbound, serverbound
+}
+
+var notch = auth.Profile{ID: "069a79f444e94726a5befca90e38aaf5", Name: "Notch"}
+
+// loginClient runs the client side of the encryption handshake. modify may
+// change the response before it is sent, and join reports whether the player
+// announces joining to the session server.
+func loginClient(conn *packet.Conn, sessionServer *auth.LocalSessionServer, join bool, modify func(*packet.EncryptionResponse)) error {
+	if err := conn.WritePacket(&packet.LoginStart{Name: types.String{Value: notch.Name}}); err != nil {
+		return err
+	}
+	p, err := conn.ReadPacket()
+	if err != nil {
+		return err
+	}
+	request, ok := p.(*packet.EncryptionRequest)
+	if !ok {
+		return errors.New("expected encryption request")
+	}
+
+	key, err := x509.ParsePKIXPublicKey(request.PublicKey)
+	if err != nil {
+		return err
+	}
+	secret := make([]byte, 16)
+	rand.Read(secret)
+	encryptedSecret, err := rsa.EncryptPKCS1v15(rand.Reader, key.(*rsa.PublicKey), secret)
+	if err != nil {
+		return err
+	}
+	encryptedToken, err := rsa.EncryptPKCS1v15(rand.Reader, key.(*rsa.PublicKey), request.VerifyToken)
+	if err != nil {
+		return err
+	}
+	if join {
+		sessionServer.Join(auth.ServerHash(request.ServerID.Value, secret, request.PublicKey), notch)
+	}
+
+	response := &packet.EncryptionResponse{SharedSecret: encryptedSecret, VerifyToken: encryptedToken}
+	if modify != nil {
+		modify(response)
+	}
+	if err := conn.WritePacket(response); err != nil {
+		return err
+	}
+	if err := conn.EnableEncryption(secret); err != nil {
+		return err
+	}
+	// The proxy can read what the client encrypts
+	return conn.WriteFrame([]byte{0x7f, 1, 2, 3})
+}
+
+func TestAuthenticate(t *testing.T) {
+	tests := []struct {
+		name   string
+		join   bool
+		modify func(*packet.EncryptionResponse)
+		ok     bool
+	}{
+		{"authenticated", true, nil, true},
+		{"not joined", false, nil, false},
+		{"wrong verify token", true, func(r *packet.EncryptionResponse) { r.VerifyToken = r.SharedSecret }, false},
+		{"undecryptable secret", true, func(r *packet.EncryptionResponse) { r.SharedSecret = bytes.Repeat([]byte{1}, 128) }, false},
+		{"oversized secret", true, func(r *packet.EncryptionResponse) { r.SharedSecret = make([]byte, 4096) }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sessionServer := auth.NewLocalSessionServer()
+			client, clientConn := pipe(t)
+			serverConn, backendConn := pipe(t)
+			c := &Connection{
+				clientConn: clientConn,
+				serverConn: serverConn,
+				proxy:      newTestProxy(t, sessionServer),
+			}
+
+			clientErr := make(chan error, 1)
+			go func() {
+				clientErr <- loginClient(client, sessionServer, tt.join, tt.modify)
+			}()
+			backend := make(chan packet.Packet, 1)
+			go func() {
+				p, _ := backendConn.ReadPacket()
+				backend <- p
+			}()
+
+			err := c.authenticate()
+			if !tt.ok {
+				if err == nil {
+					t.Fatal("authenticate succeeded")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("authenticate: %v", err)
+			}
+
+			uuid, _ := notch.UUID()
+			want := &packet.LoginStart{Name: types.String{Value: notch.Name}, PlayerUUID: uuid}
+			if got := <-backend; got == nil || *got.(*packet.LoginStart) != *want {
+				t.Errorf("backend received %+v, want %+v", got, want)
+			}
+			frame, err := clientConn.ReadFrame()
+			if err != nil || !bytes.Equal(frame, []byte{0x7f, 1, 2, 3}) {
+				t.Errorf("encrypted frame = % x, %v", frame, err)
+			}
+			if err := <-clientErr; err != nil {
+				t.Errorf("client: %v", err)
+			}
+		})
+	}
+}
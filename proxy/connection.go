@@ -1,37 +1,36 @@
 package proxy
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"net"
 	"sync"
 
+	"mc-proxy/lang"
+	"mc-proxy/protocol/auth"
+	"mc-proxy/protocol/packet"
 	"mc-proxy/protocol/types"
 )
 
-type clientState int
-
-const (
-	stateHandshake clientState = iota
-	stateStatus
-	stateLogin
-	statePlay
-)
-
 type Connection struct {
-	clientConn net.Conn
-	serverConn net.Conn
-	state      clientState
+	clientConn *packet.Conn
+	serverConn *packet.Conn
+	state      packet.State
 	proxy      *Proxy
+	profile    *auth.Profile // verified profile in online mode
+	username   string
+	uuid       types.UUID
+	locale     string // from Client Information, empty until configuration
 	closed     bool
 	mutex      sync.Mutex
 }
 
 func (p *Proxy) handleConnection(clientConn net.Conn) {
 	conn := &Connection{
-		clientConn: clientConn,
+		clientConn: packet.NewConn(clientConn, packet.Serverbound),
 		proxy:      p,
-		state:      stateHandshake,
+		state:      packet.StateHandshake,
 	}
 
 	defer conn.close()
@@ -43,343 +42,283 @@ func (p *Proxy) handleConnection(clientConn net.Conn) {
 	}
 
 	// Based on the state after handshake, handle accordingly
-	switch conn.state {
-	case stateStatus:
+	switch conn.currentState() {
+	case packet.StateStatus:
 		if err := conn.handleStatus(); err != nil {
 			fmt.Printf("Status error: %v\n", err)
 		}
-	case stateLogin:
+	case packet.StateLogin:
 		if err := conn.handleLogin(); err != nil {
 			fmt.Printf("Login error: %v\n", err)
 		}
 	}
 }
 
-func (c *Connection) readVarInt() (types.VarInt, error) {
-	var value types.VarInt
-	buf := make([]byte, 0, 5)
-	tmp := make([]byte, 1)
-
-	for i := 0; i < 5; i++ {
-		n, err := c.clientConn.Read(tmp)
-		if err != nil || n == 0 {
-			return 0, fmt.Errorf("failed to read VarInt: %v", err)
-		}
-
-		buf = append(buf, tmp[0])
-		if tmp[0]&0x80 == 0 {
-			break
-		}
-	}
-
-	if err := value.Unmarshal(buf); err != nil {
-		return 0, err
-	}
-	return value, nil
-}
-
-func (c *Connection) readPacket() ([]byte, error) {
-	// Read packet length
-	length, err := c.readVarInt()
+func (c *Connection) handleHandshake() error {
+	frame, err := c.clientConn.ReadFrame()
 	if err != nil {
-		return nil, fmt.Errorf("failed to read packet length: %v", err)
+		return fmt.Errorf("failed to read handshake packet: %v", err)
 	}
 
-	// Read the entire packet
-	packet := make([]byte, length)
-	_, err = io.ReadFull(c.clientConn, packet)
+	decoded, err := packet.Decode(packet.StateHandshake, packet.Serverbound, packet.AnyVersion, frame)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read packet: %v", err)
+		return fmt.Errorf("failed to decode handshake packet: %v", err)
+	}
+	handshake, ok := decoded.(*packet.Handshake)
+	if !ok {
+		return fmt.Errorf("unexpected packet 0x%02X during handshake", decoded.ID())
 	}
 
-	return packet, nil
-}
+	var state packet.State
+	switch handshake.NextState {
+	case 1:
+		state = packet.StateStatus
+	case 2, 3: // 3 is a login after a transfer
+		state = packet.StateLogin
+	default:
+		return fmt.Errorf("invalid next state %d", handshake.NextState)
+	}
+	c.clientConn.SetVersion(int32(handshake.ProtocolVersion))
 
-func (c *Connection) handleHandshake() error {
-	packet, err := c.readPacket()
+	// Connect to the actual server and forward the original packet
+	serverConn, err := packet.Dial(c.proxy.serverAddr)
 	if err != nil {
-		return fmt.Errorf("failed to read handshake packet: %v", err)
+		return fmt.Errorf("failed to connect to server: %v", err)
 	}
+	c.serverConn = serverConn
+	c.serverConn.SetVersion(int32(handshake.ProtocolVersion))
+	c.setState(state)
 
-	var pos int
-
-	// Read packet ID
-	var packetID types.VarInt
-	if err := packetID.Unmarshal(packet[pos:]); err != nil {
-		return fmt.Errorf("failed to read packet ID: %v", err)
-	}
-	// Increment position by counting VarInt bytes
-	for i := pos; i < len(packet); i++ {
-		pos++
-		if packet[i]&0x80 == 0 {
-			break
-		}
+	if err := c.serverConn.WriteFrame(frame); err != nil {
+		return fmt.Errorf("failed to forward handshake packet: %v", err)
 	}
 
-	// Read protocol version
-	var protocolVersion types.VarInt
-	if err := protocolVersion.Unmarshal(packet[pos:]); err != nil {
-		return fmt.Errorf("failed to read protocol version: %v", err)
-	}
-	// Increment position by counting VarInt bytes
-	for i := pos; i < len(packet); i++ {
-		pos++
-		if packet[i]&0x80 == 0 {
-			break
-		}
-	}
+	return nil
+}
 
-	// Read server address
-	var serverAddr types.String
-	if err := serverAddr.Unmarshal(packet[pos:]); err != nil {
-		return fmt.Errorf("failed to read server address: %v", err)
-	}
-	// String length is prefixed by a VarInt
-	strLenStart := pos
-	for i := pos; i < len(packet); i++ {
-		pos++
-		if packet[i]&0x80 == 0 {
-			break
-		}
-	}
-	var strLen types.VarInt
-	strLen.Unmarshal(packet[strLenStart:pos])
-	pos += int(strLen) // Skip the string content
-
-	// Read server port
-	var serverPort types.UnsignedShort
-	if err := serverPort.Unmarshal(packet[pos:]); err != nil {
-		return fmt.Errorf("failed to read server port: %v", err)
-	}
-	pos += 2 // UnsignedShort is always 2 bytes
+func (c *Connection) handleStatus() error {
+	return c.forward(copyFrames, copyFrames)
+}
 
-	// Read next state
-	var nextState types.VarInt
-	if err := nextState.Unmarshal(packet[pos:]); err != nil {
-		return fmt.Errorf("failed to read next state: %v", err)
+func (c *Connection) handleLogin() error {
+	if c.proxy.onlineMode {
+		if err := c.authenticate(); err != nil {
+			c.disconnect(types.ChatComponent{Translate: "multiplayer.disconnect.unverified_username", Fallback: "Failed to verify username!"})
+			return fmt.Errorf("authentication failed: %v", err)
+		}
 	}
+	return c.forward(c.forwardServerbound, c.forwardLogin)
+}
 
-	c.state = clientState(nextState)
+// forwardServerbound copies serverbound frames and follows the state
+// transitions acknowledged by the client. The new state is applied before the
+// acknowledgement is forwarded, as the backend answers in the new state.
+func (c *Connection) forwardServerbound(dst, src *packet.Conn) error {
+	buf := types.GetBuffer()
+	defer types.PutBuffer(buf)
 
-	// If we're going to login state, connect to the actual server
-	if c.state == stateLogin {
-		serverConn, err := net.Dial("tcp", c.proxy.serverAddr)
+	for {
+		frame, err := src.ReadFrameTo(buf)
 		if err != nil {
-			return fmt.Errorf("failed to connect to server: %v", err)
+			return err
 		}
-		c.serverConn = serverConn
 
-		// Forward the original packet with length prefix
-		lengthBytes, err := types.VarInt(len(packet)).Marshal()
+		id, err := packet.FrameID(frame)
 		if err != nil {
-			return fmt.Errorf("failed to marshal packet length: %v", err)
+			return err
 		}
 
-		if _, err := c.serverConn.Write(lengthBytes); err != nil {
-			return fmt.Errorf("failed to write packet length: %v", err)
+		switch state := c.currentState(); {
+		case state != packet.StateLogin && isPacket(src, id, &packet.ClientInformation{}):
+			// The locale only affects messages, so a client information
+			// packet that cannot be decoded is still forwarded
+			decoded, err := packet.Decode(state, packet.Serverbound, src.Version(), frame)
+			if err != nil {
+				fmt.Printf("Failed to read client information: %v\n", err)
+			} else {
+				c.setLocale(decoded.(*packet.ClientInformation).Locale.Value)
+			}
+		case state == packet.StateLogin && isPacket(src, id, &packet.LoginAcknowledged{}):
+			c.setState(packet.StateConfiguration)
+		case state == packet.StateConfiguration && isPacket(src, id, &packet.AcknowledgeFinishConfiguration{}):
+			c.setState(packet.StatePlay)
+		case state == packet.StatePlay && isPacket(src, id, &packet.ConfigurationAcknowledged{}):
+			c.setState(packet.StateConfiguration)
 		}
-		if _, err := c.serverConn.Write(packet); err != nil {
-			return fmt.Errorf("failed to write packet: %v", err)
+
+		if err := dst.WriteFrame(frame); err != nil {
+			return err
 		}
 	}
-
-	return nil
 }
 
-func (c *Connection) handleStatus() error {
-	// Connect to the server for status requests
-	serverConn, err := net.Dial("tcp", c.proxy.serverAddr)
-	if err != nil {
-		return fmt.Errorf("failed to connect to server: %v", err)
-	}
-	c.serverConn = serverConn
-	defer c.serverConn.Close()
-
-	// Forward handshake packet
-	packet, err := c.readPacket()
-	if err != nil {
-		return fmt.Errorf("failed to read status packet: %v", err)
-	}
-
-	// Forward the packet with length prefix
-	lengthBytes, err := types.VarInt(len(packet)).Marshal()
-	if err != nil {
-		return fmt.Errorf("failed to marshal packet length: %v", err)
-	}
-
-	if _, err := c.serverConn.Write(lengthBytes); err != nil {
-		return fmt.Errorf("failed to write packet length: %v", err)
-	}
-	if _, err := c.serverConn.Write(packet); err != nil {
-		return fmt.Errorf("failed to write packet: %v", err)
-	}
+// forwardLogin copies clientbound login frames. The backend's Set Compression
+// only applies to the backend link, the client link uses the proxy's own
+// threshold which is announced right before Login Success.
+func (c *Connection) forwardLogin(dst, src *packet.Conn) error {
+	for {
+		frame, err := src.ReadFrame()
+		if err != nil {
+			return err
+		}
 
-	// Forward remaining packets in both directions
-	errChan := make(chan error, 2)
+		id, err := packet.FrameID(frame)
+		if err != nil {
+			return err
+		}
 
-	// Client -> Server
-	go func() {
-		for {
-			packet, err := c.readPacket()
+		switch {
+		case isPacket(src, id, &packet.LoginDisconnect{}):
+			decoded, err := packet.Decode(packet.StateLogin, packet.Clientbound, src.Version(), frame)
 			if err != nil {
-				errChan <- err
-				return
+				return err
 			}
-
-			lengthBytes, err := types.VarInt(len(packet)).Marshal()
+			reason := types.ChatComponent(decoded.(*packet.LoginDisconnect).Reason)
+			fmt.Printf("Backend refused login: %s\n", types.ANSIRenderer{Mode: types.ANSI256, Translator: c.proxy.languages.Default().Translate}.Render(reason))
+		case isPacket(src, id, &packet.SetCompression{}):
+			decoded, err := packet.Decode(packet.StateLogin, packet.Clientbound, src.Version(), frame)
 			if err != nil {
-				errChan <- err
-				return
+				return err
 			}
-
-			if _, err := c.serverConn.Write(lengthBytes); err != nil {
-				errChan <- err
-				return
-			}
-			if _, err := c.serverConn.Write(packet); err != nil {
-				errChan <- err
-				return
-			}
-		}
-	}()
-
-	// Server -> Client
-	go func() {
-		for {
-			// Read packet length
-			length, err := c.readVarIntFrom(c.serverConn)
+			src.SetCompressionThreshold(int(decoded.(*packet.SetCompression).Threshold))
+			continue
+		case isPacket(src, id, &packet.LoginSuccess{}):
+			decoded, err := packet.Decode(packet.StateLogin, packet.Clientbound, src.Version(), frame)
 			if err != nil {
-				errChan <- err
-				return
+				return err
 			}
-
-			// Read packet data
-			packet := make([]byte, length)
-			if _, err := io.ReadFull(c.serverConn, packet); err != nil {
-				errChan <- err
-				return
+			loginSuccess := decoded.(*packet.LoginSuccess)
+			c.username = loginSuccess.Username.Value
+			c.uuid = loginSuccess.UUID
+			fmt.Printf("%s (%s) logged in\n", c.username, c.uuid)
+
+			// Offline mode backends do not know the verified skin properties
+			if c.profile != nil && len(loginSuccess.Properties) == 0 {
+				loginSuccess.Properties = loginProperties(c.profile)
+				frame, err = packet.DefaultRegistry.Encode(packet.StateLogin, packet.Clientbound, src.Version(), loginSuccess)
+				if err != nil {
+					return err
+				}
 			}
 
-			// Forward length and packet
-			lengthBytes, err := types.VarInt(len(packet)).Marshal()
-			if err != nil {
-				errChan <- err
-				return
+			if threshold := c.proxy.compressionThreshold; threshold >= 0 {
+				setCompression := &packet.SetCompression{Threshold: types.VarInt(threshold)}
+				if err := dst.WritePacket(setCompression); err != nil {
+					return err
+				}
 			}
-
-			if _, err := c.clientConn.Write(lengthBytes); err != nil {
-				errChan <- err
-				return
+			// Older clients switch to the play state right after Login Success
+			if src.Version() < packet.Protocol1_20_2 {
+				c.setState(packet.StatePlay)
 			}
-			if _, err := c.clientConn.Write(packet); err != nil {
-				errChan <- err
-				return
+			if err := dst.WriteFrame(frame); err != nil {
+				return err
 			}
+			return copyFrames(dst, src)
+		}
+
+		if err := dst.WriteFrame(frame); err != nil {
+			return err
 		}
+	}
+}
+
+// forward copies frames in both directions using the given functions until
+// either side fails
+func (c *Connection) forward(serverbound, clientbound func(dst, src *packet.Conn) error) error {
+	errChan := make(chan error, 2)
+
+	// Client -> Server
+	go func() {
+		errChan <- serverbound(c.serverConn, c.clientConn)
+	}()
+
+	// Server -> Client
+	go func() {
+		errChan <- clientbound(c.clientConn, c.serverConn)
 	}()
 
 	// Wait for any error
-	err = <-errChan
-	if err == io.EOF {
+	err := <-errChan
+	if errors.Is(err, io.EOF) {
 		return nil
 	}
 	return err
 }
 
-func (c *Connection) readVarIntFrom(conn net.Conn) (types.VarInt, error) {
-	var value types.VarInt
-	buf := make([]byte, 0, 5)
-	tmp := make([]byte, 1)
+// copyFrames forwards frames unchanged, reusing a pooled buffer as the hot
+// path of the proxy
+func copyFrames(dst, src *packet.Conn) error {
+	buf := types.GetBuffer()
+	defer types.PutBuffer(buf)
 
-	for i := 0; i < 5; i++ {
-		n, err := conn.Read(tmp)
-		if err != nil || n == 0 {
-			return 0, fmt.Errorf("failed to read VarInt: %v", err)
+	for {
+		frame, err := src.ReadFrameTo(buf)
+		if err != nil {
+			return err
 		}
-
-		buf = append(buf, tmp[0])
-		if tmp[0]&0x80 == 0 {
-			break
+		if err := dst.WriteFrame(frame); err != nil {
+			return err
 		}
 	}
-
-	if err := value.Unmarshal(buf); err != nil {
-		return 0, err
-	}
-	return value, nil
 }
 
-func (c *Connection) handleLogin() error {
-	// Start forwarding packets in both directions
-	errChan := make(chan error, 2)
-
-	// Client -> Server
-	go func() {
-		for {
-			packet, err := c.readPacket()
-			if err != nil {
-				errChan <- err
-				return
-			}
-
-			lengthBytes, err := types.VarInt(len(packet)).Marshal()
-			if err != nil {
-				errChan <- err
-				return
-			}
+// isPacket reports whether a frame read from src with the given ID is the
+// packet p in the current state and protocol version of src
+func isPacket(src *packet.Conn, id int32, p packet.Packet) bool {
+	expected, ok := packet.DefaultRegistry.PacketID(src.State(), src.Inbound(), src.Version(), p)
+	return ok && expected == id
+}
 
-			if _, err := c.serverConn.Write(lengthBytes); err != nil {
-				errChan <- err
-				return
-			}
-			if _, err := c.serverConn.Write(packet); err != nil {
-				errChan <- err
-				return
-			}
-		}
-	}()
+func (c *Connection) currentState() packet.State {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.state
+}
 
-	// Server -> Client
-	go func() {
-		for {
-			// Read packet length
-			length, err := c.readVarIntFrom(c.serverConn)
-			if err != nil {
-				errChan <- err
-				return
-			}
+// setState moves the connection and both links to a new state
+func (c *Connection) setState(state packet.State) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
 
-			// Read packet data
-			packet := make([]byte, length)
-			if _, err := io.ReadFull(c.serverConn, packet); err != nil {
-				errChan <- err
-				return
-			}
+	c.state = state
+	c.clientConn.SetState(state)
+	if c.serverConn != nil {
+		c.serverConn.SetState(state)
+	}
+}
 
-			// Forward length and packet
-			lengthBytes, err := types.VarInt(len(packet)).Marshal()
-			if err != nil {
-				errChan <- err
-				return
-			}
+// setLocale records the locale the client uses
+func (c *Connection) setLocale(locale string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.locale = locale
+}
 
-			if _, err := c.clientConn.Write(lengthBytes); err != nil {
-				errChan <- err
-				return
-			}
-			if _, err := c.clientConn.Write(packet); err != nil {
-				errChan <- err
-				return
-			}
-		}
-	}()
+// language returns the proxy language matching the client's locale
+func (c *Connection) language() *lang.Language {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.proxy.languages.Get(c.locale)
+}
 
-	// Wait for any error
-	err := <-errChan
-	if err == io.EOF {
-		return nil
+// disconnect kicks the client with the given reason, using the disconnect
+// packet of the current state. Translatable parts of the reason are rendered
+// in the client's language, as clients only know the keys of the game.
+func (c *Connection) disconnect(reason types.ChatComponent) {
+	message := types.Chat(c.language().Render(reason))
+	var kick packet.Packet
+	switch c.currentState() {
+	case packet.StateConfiguration:
+		kick = &packet.ConfigurationDisconnect{Reason: message}
+	case packet.StatePlay:
+		kick = &packet.PlayDisconnect{Reason: message}
+	default:
+		kick = &packet.LoginDisconnect{Reason: message}
+	}
+	if err := c.clientConn.WritePacket(kick); err != nil {
+		fmt.Printf("Failed to disconnect client: %v\n", err)
 	}
-	return err
 }
 
 func (c *Connection) close() {
@@ -0,0 +1,157 @@
+package proxy
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"testing"
+
+	"mc-proxy/protocol/packet"
+	"mc-proxy/protocol/types"
+)
+
+// frameConn is a net.Conn reading the same encoded frame count times and
+// discarding everything written to it
+type frameConn struct {
+	net.Conn
+	frame []byte
+	off   int
+	count int
+}
+
+func (c *frameConn) Read(p []byte) (int, error) {
+	if c.count == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, c.frame[c.off:])
+	c.off += n
+	if c.off == len(c.frame) {
+		c.off = 0
+		c.count--
+	}
+	return n, nil
+}
+
+func (c *frameConn) Write(p []byte) (int, error) {
+	return len(p), nil
+}
+
+// writerConn is a net.Conn writing to a writer
+type writerConn struct {
+	net.Conn
+	io.Writer
+}
+
+func (c *writerConn) Write(p []byte) (int, error) {
+	return c.Writer.Write(p)
+}
+
+func encodeFrame(t testing.TB, threshold int, frame []byte) []byte {
+	var buf bytes.Buffer
+	fw := packet.NewFrameWriter(&buf)
+	fw.SetCompressionThreshold(threshold)
+	if err := fw.WriteFrame(frame); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestCopyFrames(t *testing.T) {
+	frame := []byte{0x27, 1, 2, 3}
+	var out bytes.Buffer
+	src := packet.NewConn(&frameConn{frame: encodeFrame(t, packet.CompressionDisabled, frame), count: 3}, packet.Clientbound)
+	dst := packet.NewConn(&writerConn{Writer: &out}, packet.Serverbound)
+
+	if err := copyFrames(dst, src); err != io.EOF {
+		t.Fatalf("copyFrames = %v, want io.EOF", err)
+	}
+	if want := bytes.Repeat(encodeFrame(t, packet.CompressionDisabled, frame), 3); !bytes.Equal(out.Bytes(), want) {
+		t.Errorf("copied % x, want % x", out.Bytes(), want)
+	}
+}
+
+// TestForwardClientInformation checks that Client Information is forwarded
+// unchanged in configuration and play while its locale is recorded
+func TestForwardClientInformation(t *testing.T) {
+	client, proxyClient := pipe(t)
+	proxyServer, backend := pipe(t)
+	for _, conn := range []*packet.Conn{client, proxyClient, proxyServer, backend} {
+		conn.SetVersion(packet.Protocol1_21)
+	}
+	c := &Connection{clientConn: proxyClient, serverConn: proxyServer, proxy: &Proxy{}}
+	c.setState(packet.StateConfiguration)
+	client.SetState(packet.StateConfiguration)
+	backend.SetState(packet.StateConfiguration)
+	go c.forwardServerbound(proxyServer, proxyClient)
+
+	// forward sends a frame from the client and checks that the backend gets it
+	forward := func(frame []byte) {
+		t.Helper()
+		go client.WriteFrame(frame)
+		got, err := backend.ReadFrame()
+		if err != nil {
+			t.Fatalf("backend: %v", err)
+		}
+		if !bytes.Equal(got, frame) {
+			t.Fatalf("backend got % x, want % x", got, frame)
+		}
+	}
+	encode := func(state packet.State, p packet.Packet) []byte {
+		t.Helper()
+		frame, err := packet.DefaultRegistry.Encode(state, packet.Serverbound, packet.Protocol1_21, p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return frame
+	}
+	locale := func() string {
+		c.mutex.Lock()
+		defer c.mutex.Unlock()
+		return c.locale
+	}
+
+	information := &packet.ClientInformation{Locale: types.String{Value: "de_de"}, ViewDistance: 10, MainHand: 1}
+	forward(encode(packet.StateConfiguration, information))
+	if got := locale(); got != "de_de" {
+		t.Errorf("configuration: locale %q, want de_de", got)
+	}
+
+	// A broken packet is still forwarded and keeps the locale
+	id, _ := packet.DefaultRegistry.PacketID(packet.StateConfiguration, packet.Serverbound, packet.Protocol1_21, information)
+	forward([]byte{byte(id), 0x7f})
+	if got := locale(); got != "de_de" {
+		t.Errorf("broken packet: locale %q, want de_de", got)
+	}
+
+	forward(encode(packet.StateConfiguration, &packet.AcknowledgeFinishConfiguration{}))
+	if state := c.currentState(); state != packet.StatePlay {
+		t.Fatalf("state %v after acknowledging the end of configuration, want play", state)
+	}
+	information.Locale.Value = "fr_fr"
+	forward(encode(packet.StatePlay, information))
+	if got := locale(); got != "fr_fr" {
+		t.Errorf("play: locale %q, want fr_fr", got)
+	}
+}
+
+func benchmarkCopyFrames(b *testing.B, threshold int) {
+	frame := bytes.Repeat([]byte("entity data "), 50)
+	src := packet.NewConn(&frameConn{frame: encodeFrame(b, threshold, frame), count: b.N}, packet.Clientbound)
+	dst := packet.NewConn(&frameConn{}, packet.Serverbound)
+	src.SetCompressionThreshold(threshold)
+	dst.SetCompressionThreshold(threshold)
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(frame)))
+	b.ResetTimer()
+	if err := copyFrames(dst, src); err != io.EOF {
+		b.Fatal(err)
+	}
+}
+
+// BenchmarkCopyFrames forwards frames with a single pooled buffer, so the
+// allocations per frame should round to zero
+func BenchmarkCopyFrames(b *testing.B) {
+	b.Run("Uncompressed", func(b *testing.B) { benchmarkCopyFrames(b, packet.CompressionDisabled) })
+	b.Run("Compressed", func(b *testing.B) { benchmarkCopyFrames(b, 256) })
+}
@@ -1,15 +1,30 @@
 package proxy
 
 import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
 	"fmt"
 	"net"
 	"sync"
+
+	"mc-proxy/lang"
+	"mc-proxy/protocol/auth"
 )
 
+// DefaultCompressionThreshold matches the vanilla network-compression-threshold
+const DefaultCompressionThreshold = 256
+
 type Proxy struct {
-	listener    net.Listener
-	serverAddr  string
-	connections sync.Map
+	listener             net.Listener
+	serverAddr           string
+	compressionThreshold int
+	onlineMode           bool
+	sessionServer        auth.SessionServer
+	privateKey           *rsa.PrivateKey
+	publicKey            []byte
+	languages            *lang.Languages
+	connections          sync.Map
 }
 
 // NewProxy creates a new Minecraft proxy
@@ -19,12 +34,52 @@ func NewProxy(listenAddr, serverAddr string) (*Proxy, error) {
 		return nil, fmt.Errorf("failed to start proxy listener: %v", err)
 	}
 
+	// Generate the key pair used for the encryption handshake with clients
+	privateKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to generate key pair: %v", err)
+	}
+	publicKey, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	if err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to encode public key: %v", err)
+	}
+
 	return &Proxy{
-		listener:   listener,
-		serverAddr: serverAddr,
+		listener:             listener,
+		serverAddr:           serverAddr,
+		compressionThreshold: DefaultCompressionThreshold,
+		sessionServer:        auth.NewHTTPSessionServer(auth.MojangSessionServerURL),
+		privateKey:           privateKey,
+		publicKey:            publicKey,
 	}, nil
 }
 
+// SetCompressionThreshold sets the compression threshold used towards clients,
+// independent of the one the backend asks for. A negative threshold disables
+// compression towards clients.
+func (p *Proxy) SetCompressionThreshold(threshold int) {
+	p.compressionThreshold = threshold
+}
+
+// SetOnlineMode enables encryption and authentication of clients at the
+// proxy. The backend server must run in offline mode.
+func (p *Proxy) SetOnlineMode(onlineMode bool) {
+	p.onlineMode = onlineMode
+}
+
+// SetSessionServer changes the session server used to authenticate players
+func (p *Proxy) SetSessionServer(sessionServer auth.SessionServer) {
+	p.sessionServer = sessionServer
+}
+
+// SetLanguages sets the languages used for messages of the proxy and logs.
+// Without languages, translatable messages show their fallback text.
+func (p *Proxy) SetLanguages(languages *lang.Languages) {
+	p.languages = languages
+}
+
 // Start begins accepting client connections
 func (p *Proxy) Start() error {
 	for {